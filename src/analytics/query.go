@@ -0,0 +1,50 @@
+package analytics
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Reader runs read-only queries over the analytics database
+type Reader struct {
+	db *sql.DB
+}
+
+// OpenReader opens the database read-only, so it can be queried while the consumer is writing to it
+func OpenReader(dbPath string) (*Reader, error) {
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?mode=ro&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open analytics database: %v", err)
+	}
+	// the temporary views below only exist on the connection that created them
+	db.SetMaxOpenConns(1)
+
+	err = db.Ping()
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to open analytics database %s: %v", dbPath, err)
+	}
+	return &Reader{db: db}, nil
+}
+
+func (r *Reader) Close() error {
+	return r.db.Close()
+}
+
+// Query runs a query over the rows recorded since the given time. Temporary views with the same names as the tables
+// hide older rows, as SQLite resolves unqualified names to temporary objects first.
+func (r *Reader) Query(ctx context.Context, since time.Time, query string) (*sql.Rows, error) {
+	for _, table := range tables {
+		_, err := r.db.ExecContext(ctx, fmt.Sprintf(
+			`CREATE TEMP VIEW IF NOT EXISTS %s AS SELECT * FROM main.%s WHERE time >= '%s'`,
+			table, table, since.UTC().Format(timeFormat),
+		))
+		if err != nil {
+			return nil, fmt.Errorf("failed to limit %s to recent rows: %v", table, err)
+		}
+	}
+
+	return r.db.QueryContext(ctx, query)
+}
@@ -0,0 +1,252 @@
+package analytics
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gemini-push-port/darwintime"
+	"gemini-push-port/logging"
+	"gemini-push-port/pushport"
+	"gemini-push-port/rawstore"
+	"gemini-push-port/trainstate"
+	"time"
+)
+
+const (
+	queueSize     = 10_000
+	maxBatchSize  = 1_000
+	flushInterval = time.Second
+)
+
+type archivedMessage struct {
+	raw      *rawstore.XmlMessageWithTime
+	location rawstore.ArchiveLocation
+}
+
+// Sink records archived messages in the analytics database, parsing and writing them in batches so it never holds
+// up archiving
+type Sink struct {
+	db      *sql.DB
+	engine  *trainstate.Engine
+	batcher *rawstore.Batcher[archivedMessage]
+}
+
+// OpenSinkFromEnv opens the database at ANALYTICS_DB_PATH, or analytics.db in the workdir. The engine, if given, is
+// used to find the operator of services in messages which don't include it.
+func OpenSinkFromEnv(engine *trainstate.Engine) (*Sink, error) {
+	db, err := openDB(DBPathFromEnv())
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Sink{db: db, engine: engine}
+	s.batcher = rawstore.NewBatcher("analytics", queueSize, maxBatchSize, flushInterval, s.write)
+	return s, nil
+}
+
+func (s *Sink) MessageArchived(msg *rawstore.XmlMessageWithTime, location rawstore.ArchiveLocation) {
+	s.batcher.Add(archivedMessage{raw: msg, location: location})
+}
+
+// Thread writes queued messages to the database until the sink is closed
+func (s *Sink) Thread() {
+	s.batcher.Thread()
+}
+
+func (s *Sink) write(batch []archivedMessage) {
+	err := s.insert(batch)
+	if err != nil {
+		logging.Logger.ErrorE(fmt.Sprintf("failed to write %d messages to the analytics database", len(batch)), err)
+	}
+}
+
+func (s *Sink) insert(batch []archivedMessage) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	insertMessage, err := tx.Prepare(`INSERT INTO messages (time, type, rid, toc, archive_file, archive_offset) VALUES (?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer insertMessage.Close()
+
+	insertLocation, err := tx.Prepare(`
+		INSERT INTO location_times (time, rid, ssd, toc, tiploc, working_arrival, working_departure, working_pass,
+			public_arrival, public_departure, estimated_arrival, actual_arrival, estimated_departure, actual_departure,
+			estimated_pass, actual_pass, platform, archive_file, archive_offset)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer insertLocation.Close()
+
+	for _, archived := range batch {
+		msg, err := pushport.NewMessage(archived.raw)
+		if err != nil {
+			// validated before it was archived, so this shouldn't happen
+			continue
+		}
+
+		for _, row := range s.messageRows(msg) {
+			_, err = insertMessage.Exec(formatTime(msg.Time), row.messageType, row.rid, row.toc, archived.location.File, archived.location.Offset)
+			if err != nil {
+				return err
+			}
+		}
+
+		response := msg.Pport.Response()
+		for i := range response.TrainStatuses {
+			ts := &response.TrainStatuses[i]
+			toc := s.toc(ts.RID)
+			for _, loc := range resolveLocations(ts, msg.Time) {
+				_, err = insertLocation.Exec(formatTime(msg.Time), ts.RID, ts.SSD, toc, loc.tiploc,
+					formatTime(loc.workingArrival), formatTime(loc.workingDeparture), formatTime(loc.workingPass),
+					formatTime(loc.publicArrival), formatTime(loc.publicDeparture),
+					formatTime(loc.estimatedArrival), formatTime(loc.actualArrival),
+					formatTime(loc.estimatedDeparture), formatTime(loc.actualDeparture),
+					formatTime(loc.estimatedPass), formatTime(loc.actualPass),
+					loc.platform, archived.location.File, archived.location.Offset)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+type messageRow struct {
+	messageType string
+	rid         string
+	toc         string
+}
+
+// messageRows returns a row for each service a message is about, and for each station message in it
+func (s *Sink) messageRows(msg *pushport.Message) []messageRow {
+	response := msg.Pport.Response()
+
+	var rows []messageRow
+	for _, schedule := range response.Schedules {
+		rows = append(rows, messageRow{messageType: pushport.TypeSchedule, rid: schedule.RID, toc: schedule.TOC})
+	}
+	for _, d := range response.Deactivated {
+		rows = append(rows, messageRow{messageType: pushport.TypeDeactivated, rid: d.RID, toc: s.toc(d.RID)})
+	}
+	for _, a := range response.Associations {
+		rows = append(rows,
+			messageRow{messageType: pushport.TypeAssociation, rid: a.Main.RID, toc: s.toc(a.Main.RID)},
+			messageRow{messageType: pushport.TypeAssociation, rid: a.Assoc.RID, toc: s.toc(a.Assoc.RID)},
+		)
+	}
+	for _, ts := range response.TrainStatuses {
+		rows = append(rows, messageRow{messageType: pushport.TypeTrainStatus, rid: ts.RID, toc: s.toc(ts.RID)})
+	}
+	for range response.StationMessages {
+		rows = append(rows, messageRow{messageType: pushport.TypeStationMessage})
+	}
+	return rows
+}
+
+func (s *Sink) toc(rid string) string {
+	if s.engine == nil {
+		return ""
+	}
+	svc, ok := s.engine.Get(rid)
+	if !ok {
+		return ""
+	}
+	return svc.TOC
+}
+
+type resolvedLocation struct {
+	tiploc                                        string
+	workingArrival, workingDeparture, workingPass time.Time
+	publicArrival, publicDeparture                time.Time
+	estimatedArrival, actualArrival               time.Time
+	estimatedDeparture, actualDeparture           time.Time
+	estimatedPass, actualPass                     time.Time
+	platform                                      any
+}
+
+// resolveLocations turns the times at each location in a TS message into absolute times
+func resolveLocations(ts *pushport.TrainStatus, msgTime time.Time) []resolvedLocation {
+	ssd, err := darwintime.ParseSSD(ts.SSD)
+	if err != nil {
+		return nil
+	}
+
+	// a TS can start part way through the service, possibly after midnight
+	seq := darwintime.NewSequenceNear(ssd, msgTime)
+	locations := make([]resolvedLocation, 0, len(ts.Locations))
+	for _, l := range ts.Locations {
+		loc := resolvedLocation{
+			tiploc:           l.Tiploc,
+			workingArrival:   seq.Next(l.Wta),
+			workingPass:      seq.Next(l.Wtp),
+			workingDeparture: seq.Next(l.Wtd),
+		}
+		loc.publicArrival = darwintime.Near(ssd, l.Pta, loc.workingArrival)
+		loc.publicDeparture = darwintime.Near(ssd, l.Ptd, loc.workingDeparture)
+		loc.estimatedArrival, loc.actualArrival = resolveForecast(ssd, l.Arrival, loc.workingArrival)
+		loc.estimatedDeparture, loc.actualDeparture = resolveForecast(ssd, l.Departure, loc.workingDeparture)
+		loc.estimatedPass, loc.actualPass = resolveForecast(ssd, l.Pass, loc.workingPass)
+		if l.Platform != nil && l.Platform.Number != "" {
+			loc.platform = l.Platform.Number
+		}
+		locations = append(locations, loc)
+	}
+	return locations
+}
+
+func resolveForecast(ssd time.Time, t *pushport.TSTimeData, scheduled time.Time) (time.Time, time.Time) {
+	if t == nil || scheduled.IsZero() {
+		return time.Time{}, time.Time{}
+	}
+	estimated := t.Estimated
+	if estimated == "" {
+		estimated = t.WorkingEstimated
+	}
+	actual := t.Actual
+	if t.ActualRemoved {
+		actual = ""
+	}
+	return darwintime.Near(ssd, estimated, scheduled), darwintime.Near(ssd, actual, scheduled)
+}
+
+// Prune deletes rows older than the retention period
+func (s *Sink) Prune(ctx context.Context, retention time.Duration) (int64, error) {
+	cutoff := formatTime(time.Now().Add(-retention))
+
+	var deleted int64
+	for _, table := range tables {
+		result, err := s.db.ExecContext(ctx, `DELETE FROM `+table+` WHERE time < ?`, cutoff)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to prune %s: %v", table, err)
+		}
+		n, _ := result.RowsAffected()
+		deleted += n
+	}
+	return deleted, nil
+}
+
+func PruneJob(s *Sink, retention time.Duration) {
+	deleted, err := s.Prune(context.Background(), retention)
+	if err != nil {
+		logging.Logger.ErrorE("failed to prune analytics database", err)
+		return
+	}
+	logging.Logger.Infof("Pruned %d rows from the analytics database", deleted)
+}
+
+// Close stops queueing messages, writes those already queued, and closes the database
+func (s *Sink) Close() {
+	s.batcher.Close()
+	err := s.db.Close()
+	if err != nil {
+		logging.Logger.ErrorE("failed to close analytics database", err)
+	}
+}
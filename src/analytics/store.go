@@ -0,0 +1,118 @@
+// Package analytics records every archived message in an embedded SQLite database, for deployments without a database
+// server. Each row points back to the message's line in the hourly archive, and TS messages are also broken down into
+// resolved times at each location, so recent traffic can be queried with SQL without downloading the archives.
+package analytics
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+const defaultRetentionDays = 30
+
+// timeFormat is how times are stored, which SQLite's date and time functions understand
+const timeFormat = "2006-01-02T15:04:05Z"
+
+const schema = `
+CREATE TABLE IF NOT EXISTS messages (
+	time           TEXT NOT NULL,
+	type           TEXT NOT NULL,
+	rid            TEXT NOT NULL DEFAULT '',
+	toc            TEXT NOT NULL DEFAULT '',
+	archive_file   TEXT NOT NULL,
+	archive_offset INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS messages_time_idx ON messages (time);
+CREATE INDEX IF NOT EXISTS messages_rid_idx ON messages (rid);
+
+CREATE TABLE IF NOT EXISTS location_times (
+	time                TEXT NOT NULL,
+	rid                 TEXT NOT NULL,
+	ssd                 TEXT NOT NULL,
+	toc                 TEXT NOT NULL DEFAULT '',
+	tiploc              TEXT NOT NULL,
+	working_arrival     TEXT,
+	working_departure   TEXT,
+	working_pass        TEXT,
+	public_arrival      TEXT,
+	public_departure    TEXT,
+	estimated_arrival   TEXT,
+	actual_arrival      TEXT,
+	estimated_departure TEXT,
+	actual_departure    TEXT,
+	estimated_pass      TEXT,
+	actual_pass         TEXT,
+	platform            TEXT,
+	archive_file        TEXT NOT NULL,
+	archive_offset      INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS location_times_time_idx ON location_times (time);
+CREATE INDEX IF NOT EXISTS location_times_rid_idx ON location_times (rid);
+CREATE INDEX IF NOT EXISTS location_times_tiploc_idx ON location_times (tiploc, time)`
+
+// tables are the tables which can be queried, each of which has a time column
+var tables = []string{"messages", "location_times"}
+
+// DBPathFromEnv returns ANALYTICS_DB_PATH, or analytics.db in the workdir
+func DBPathFromEnv() string {
+	dbPath := os.Getenv("ANALYTICS_DB_PATH")
+	if dbPath == "" {
+		workdir := os.Getenv("PUSH_PORT_DUMP_WORKDIR")
+		if workdir == "" {
+			panic("PUSH_PORT_DUMP_WORKDIR environment variable not set")
+		}
+		dbPath = filepath.Join(workdir, "analytics.db")
+	}
+	return dbPath
+}
+
+// EnabledFromEnv returns whether ANALYTICS_ENABLED is true
+func EnabledFromEnv() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("ANALYTICS_ENABLED"))
+	return enabled
+}
+
+// RetentionFromEnv returns how long rows are kept for: ANALYTICS_RETENTION_DAYS days, 30 by default
+func RetentionFromEnv() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("ANALYTICS_RETENTION_DAYS"))
+	if err != nil || days < 1 {
+		days = defaultRetentionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// openDB opens the database for writing, creating it if needed. WAL mode lets the CLI read it while the consumer is
+// writing to it.
+func openDB(dbPath string) (*sql.DB, error) {
+	err := os.MkdirAll(filepath.Dir(dbPath), 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open analytics database: %v", err)
+	}
+	// SQLite only allows one writer at a time
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(schema)
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to create analytics database: %v", err)
+	}
+	return db, nil
+}
+
+func formatTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format(timeFormat)
+}
@@ -0,0 +1,327 @@
+package boards
+
+import (
+	"gemini-push-port/pushport"
+	"gemini-push-port/refdata"
+	"gemini-push-port/trainstate"
+	"slices"
+	"strings"
+	"time"
+)
+
+type BoardType string
+
+const (
+	Departures BoardType = "departures"
+	Arrivals   BoardType = "arrivals"
+)
+
+// Status values for a service at a board's station
+const (
+	StatusOnTime    = "on-time"
+	StatusLate      = "late"
+	StatusDelayed   = "delayed"
+	StatusCancelled = "cancelled"
+	StatusArrived   = "arrived"
+	StatusDeparted  = "departed"
+)
+
+type Query struct {
+	CRS  string
+	Type BoardType
+	From time.Time
+	// Window is how far after From to include services for
+	Window time.Duration
+	// Destination is an optional CRS which services must call at after the board's station
+	Destination string
+	// Origin is an optional CRS which services must call at before the board's station
+	Origin              string
+	ExpandCallingPoints bool
+	Limit               int
+}
+
+type Board struct {
+	CRS         string         `json:"crs"`
+	StationName string         `json:"stationName"`
+	Tiplocs     []string       `json:"tiplocs"`
+	Type        BoardType      `json:"type"`
+	From        time.Time      `json:"from"`
+	To          time.Time      `json:"to"`
+	GeneratedAt time.Time      `json:"generatedAt"`
+	Services    []BoardService `json:"services"`
+}
+
+type BoardService struct {
+	RID               string           `json:"rid"`
+	UID               string           `json:"uid"`
+	TrainID           string           `json:"trainId"`
+	RSID              string           `json:"rsid,omitempty"`
+	TOC               string           `json:"toc"`
+	OperatorName      string           `json:"operatorName"`
+	Tiploc            string           `json:"tiploc"`
+	Origin            string           `json:"origin"`
+	OriginName        string           `json:"originName"`
+	Destination       string           `json:"destination"`
+	DestinationName   string           `json:"destinationName"`
+	Via               string           `json:"via,omitempty"`
+	Scheduled         time.Time        `json:"scheduled"`
+	Expected          *time.Time       `json:"expected,omitempty"`
+	Actual            *time.Time       `json:"actual,omitempty"`
+	Status            string           `json:"status"`
+	DelayMinutes      int              `json:"delayMinutes"`
+	Platform          string           `json:"platform,omitempty"`
+	PlatformConfirmed bool             `json:"platformConfirmed"`
+	IsCancelled       bool             `json:"isCancelled"`
+	CancelReason      *pushport.Reason `json:"cancelReason,omitempty"`
+	CancelReasonText  string           `json:"cancelReasonText,omitempty"`
+	LateReason        *pushport.Reason `json:"lateReason,omitempty"`
+	LateReasonText    string           `json:"lateReasonText,omitempty"`
+	Length            string           `json:"length,omitempty"`
+	CallingPoints     []CallingPoint   `json:"callingPoints,omitempty"`
+}
+
+type CallingPoint struct {
+	Tiploc      string     `json:"tiploc"`
+	CRS         string     `json:"crs,omitempty"`
+	Name        string     `json:"name"`
+	Scheduled   time.Time  `json:"scheduled"`
+	Expected    *time.Time `json:"expected,omitempty"`
+	Actual      *time.Time `json:"actual,omitempty"`
+	IsCancelled bool       `json:"isCancelled"`
+}
+
+// Builder builds station boards from the live train state
+type Builder struct {
+	engine   *trainstate.Engine
+	resolver Resolver
+	names    *refdata.Store
+}
+
+func NewBuilder(engine *trainstate.Engine, resolver Resolver, names *refdata.Store) *Builder {
+	return &Builder{
+		engine:   engine,
+		resolver: resolver,
+		names:    names,
+	}
+}
+
+func (b *Builder) Build(q Query) Board {
+	tiplocs := b.resolver.TiplocsForCRS(q.CRS)
+	to := q.From.Add(q.Window)
+
+	board := Board{
+		CRS:         strings.ToUpper(q.CRS),
+		StationName: b.names.StationName(q.CRS),
+		Tiplocs:     tiplocs,
+		Type:        q.Type,
+		From:        q.From,
+		To:          to,
+		GeneratedAt: time.Now().UTC(),
+		Services:    []BoardService{},
+	}
+
+	var destinationTiplocs, originTiplocs []string
+	if q.Destination != "" {
+		destinationTiplocs = b.resolver.TiplocsForCRS(q.Destination)
+	}
+	if q.Origin != "" {
+		originTiplocs = b.resolver.TiplocsForCRS(q.Origin)
+	}
+
+	for _, svc := range b.engine.ServicesAt(tiplocs) {
+		if !svc.HasSchedule || svc.IsDeleted || !svc.IsPassengerSvc {
+			continue
+		}
+
+		times := svc.Times()
+		for i, loc := range svc.Locations {
+			if !slices.Contains(tiplocs, loc.Tiploc) {
+				continue
+			}
+
+			entry, ok := b.buildEntry(&svc, times, i, q.Type, board.CRS)
+			if !ok {
+				continue
+			}
+
+			effective := entry.Scheduled
+			if entry.Actual != nil {
+				effective = *entry.Actual
+			} else if entry.Expected != nil {
+				effective = *entry.Expected
+			}
+
+			inWindow := func(t time.Time) bool {
+				return !t.Before(q.From) && !t.After(to)
+			}
+			if !inWindow(effective) && (entry.Actual != nil || !inWindow(entry.Scheduled)) {
+				continue
+			}
+
+			if destinationTiplocs != nil && !callsAtAfter(&svc, i, destinationTiplocs) {
+				continue
+			}
+			if originTiplocs != nil && !callsAtBefore(&svc, i, originTiplocs) {
+				continue
+			}
+
+			if q.ExpandCallingPoints {
+				entry.CallingPoints = b.callingPoints(&svc, times, i, q.Type)
+			}
+
+			board.Services = append(board.Services, entry)
+		}
+	}
+
+	slices.SortFunc(board.Services, func(a, b BoardService) int {
+		return a.Scheduled.Compare(b.Scheduled)
+	})
+
+	if q.Limit > 0 && len(board.Services) > q.Limit {
+		board.Services = board.Services[:q.Limit]
+	}
+
+	return board
+}
+
+func (b *Builder) buildEntry(svc *trainstate.Service, times []trainstate.LocationTimes, i int, boardType BoardType, crs string) (BoardService, bool) {
+	loc := svc.Locations[i]
+	t := times[i]
+
+	var scheduled, expected, actual time.Time
+	var estimate *trainstate.TimeEstimate
+	if boardType == Departures {
+		if loc.Ptd == "" {
+			return BoardService{}, false
+		}
+		scheduled, expected, actual, estimate = t.ScheduledDeparture, t.ExpectedDeparture, t.ActualDeparture, loc.Departure
+	} else {
+		if loc.Pta == "" {
+			return BoardService{}, false
+		}
+		scheduled, expected, actual, estimate = t.ScheduledArrival, t.ExpectedArrival, t.ActualArrival, loc.Arrival
+	}
+
+	entry := BoardService{
+		RID:          svc.RID,
+		UID:          svc.UID,
+		TrainID:      svc.TrainID,
+		RSID:         svc.RSID,
+		TOC:          svc.TOC,
+		Tiploc:       loc.Tiploc,
+		Scheduled:    scheduled.UTC(),
+		IsCancelled:  loc.Cancelled || svc.IsCancelled,
+		CancelReason: svc.CancelReason,
+		LateReason:   svc.LateReason,
+		Length:       loc.Length,
+	}
+
+	entry.OperatorName = b.names.OperatorName(svc.TOC)
+	entry.CancelReasonText = b.names.CancelReasonText(svc.CancelReason)
+	entry.LateReasonText = b.names.LateReasonText(svc.LateReason)
+
+	if origin, ok := svc.Origin(); ok {
+		entry.Origin = origin.Tiploc
+		entry.OriginName = b.names.LocationName(origin.Tiploc)
+	}
+	if destination, ok := svc.Destination(); ok {
+		entry.Destination = destination.Tiploc
+		entry.DestinationName = b.names.LocationName(destination.Tiploc)
+
+		subsequent := make([]string, 0, len(svc.Locations)-i)
+		for _, l := range svc.Locations[i+1:] {
+			subsequent = append(subsequent, l.Tiploc)
+		}
+		entry.Via = b.names.ViaText(crs, destination.Tiploc, subsequent)
+	}
+
+	if !loc.PlatformSuppressed {
+		entry.Platform = loc.Platform
+		entry.PlatformConfirmed = loc.PlatformConfirmed
+	}
+
+	if !expected.IsZero() {
+		expected = expected.UTC()
+		entry.Expected = &expected
+		entry.DelayMinutes = int(expected.Sub(scheduled).Minutes())
+	}
+	if !actual.IsZero() {
+		actual = actual.UTC()
+		entry.Actual = &actual
+	}
+
+	switch {
+	case entry.IsCancelled:
+		entry.Status = StatusCancelled
+	case entry.Actual != nil && boardType == Departures:
+		entry.Status = StatusDeparted
+	case entry.Actual != nil:
+		entry.Status = StatusArrived
+	case estimate != nil && (estimate.Delayed || estimate.EstimateUnknown):
+		entry.Status = StatusDelayed
+	case entry.DelayMinutes > 0:
+		entry.Status = StatusLate
+	default:
+		entry.Status = StatusOnTime
+	}
+
+	return entry, true
+}
+
+func callsAtAfter(svc *trainstate.Service, i int, tiplocs []string) bool {
+	for _, loc := range svc.Locations[i+1:] {
+		if loc.Pta != "" && !loc.Cancelled && slices.Contains(tiplocs, loc.Tiploc) {
+			return true
+		}
+	}
+	return false
+}
+
+func callsAtBefore(svc *trainstate.Service, i int, tiplocs []string) bool {
+	for _, loc := range svc.Locations[:i] {
+		if loc.Ptd != "" && !loc.Cancelled && slices.Contains(tiplocs, loc.Tiploc) {
+			return true
+		}
+	}
+	return false
+}
+
+// callingPoints returns the public calls after the board's location for departures, or before it for arrivals
+func (b *Builder) callingPoints(svc *trainstate.Service, times []trainstate.LocationTimes, i int, boardType BoardType) []CallingPoint {
+	var points []CallingPoint
+
+	add := func(j int, scheduled time.Time, expected time.Time, actual time.Time) {
+		point := CallingPoint{
+			Tiploc:      svc.Locations[j].Tiploc,
+			CRS:         b.names.CRS(svc.Locations[j].Tiploc),
+			Name:        b.names.LocationName(svc.Locations[j].Tiploc),
+			Scheduled:   scheduled.UTC(),
+			IsCancelled: svc.Locations[j].Cancelled,
+		}
+		if !expected.IsZero() {
+			expected = expected.UTC()
+			point.Expected = &expected
+		}
+		if !actual.IsZero() {
+			actual = actual.UTC()
+			point.Actual = &actual
+		}
+		points = append(points, point)
+	}
+
+	if boardType == Departures {
+		for j := i + 1; j < len(svc.Locations); j++ {
+			if svc.Locations[j].Pta != "" {
+				add(j, times[j].ScheduledArrival, times[j].ExpectedArrival, times[j].ActualArrival)
+			}
+		}
+	} else {
+		for j := 0; j < i; j++ {
+			if svc.Locations[j].Ptd != "" {
+				add(j, times[j].ScheduledDeparture, times[j].ExpectedDeparture, times[j].ActualDeparture)
+			}
+		}
+	}
+
+	return points
+}
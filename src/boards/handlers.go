@@ -0,0 +1,79 @@
+package boards
+
+import (
+	"errors"
+	"gemini-push-port/httpapi"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultWindow = 2 * time.Hour
+	maxWindow     = 24 * time.Hour
+	defaultLimit  = 50
+)
+
+func (b *Builder) RegisterRoutes(server *httpapi.Server) {
+	server.HandleFunc("GET /boards/{crs}/departures", b.boardHandler(Departures))
+	server.HandleFunc("GET /boards/{crs}/arrivals", b.boardHandler(Arrivals))
+}
+
+func (b *Builder) boardHandler(boardType BoardType) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q, err := parseQuery(r, boardType)
+		if err != nil {
+			httpapi.WriteError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		httpapi.WriteJSON(w, r, http.StatusOK, b.Build(q))
+	}
+}
+
+func parseQuery(r *http.Request, boardType BoardType) (Query, error) {
+	params := r.URL.Query()
+
+	q := Query{
+		CRS:         r.PathValue("crs"),
+		Type:        boardType,
+		From:        time.Now().UTC(),
+		Window:      defaultWindow,
+		Destination: params.Get("destination"),
+		Limit:       defaultLimit,
+	}
+
+	if from := params.Get("from"); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return q, errors.New("from must be an RFC 3339 time")
+		}
+		q.From = t.UTC()
+	}
+
+	if window := params.Get("window"); window != "" {
+		minutes, err := strconv.Atoi(window)
+		if err != nil || minutes < 1 || time.Duration(minutes)*time.Minute > maxWindow {
+			return q, errors.New("window must be a number of minutes between 1 and 1440")
+		}
+		q.Window = time.Duration(minutes) * time.Minute
+	}
+
+	if expand := params.Get("expand"); expand != "" {
+		v, err := strconv.ParseBool(expand)
+		if err != nil {
+			return q, errors.New("expand must be true or false")
+		}
+		q.ExpandCallingPoints = v
+	}
+
+	if limit := params.Get("limit"); limit != "" {
+		v, err := strconv.Atoi(limit)
+		if err != nil || v < 1 {
+			return q, errors.New("limit must be a positive number")
+		}
+		q.Limit = v
+	}
+
+	return q, nil
+}
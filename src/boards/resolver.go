@@ -0,0 +1,74 @@
+package boards
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"gemini-push-port/logging"
+	"io"
+	"os"
+	"strings"
+)
+
+// Resolver maps station CRS codes to the TIPLOCs used by Push Port messages
+type Resolver interface {
+	TiplocsForCRS(crs string) []string
+}
+
+// StaticResolver is a Resolver backed by a fixed CRS to TIPLOC mapping
+type StaticResolver struct {
+	tiplocs map[string][]string
+}
+
+// NewStaticResolverFromEnv loads the CSV file at CRS_TIPLOC_MAP_PATH, which has one "CRS,TIPLOC" pair per line. Codes
+// which aren't in the file are treated as TIPLOCs, so boards can still be requested without a mapping.
+func NewStaticResolverFromEnv() *StaticResolver {
+	resolver := &StaticResolver{tiplocs: make(map[string][]string)}
+
+	mapPath := os.Getenv("CRS_TIPLOC_MAP_PATH")
+	if mapPath == "" {
+		return resolver
+	}
+
+	err := resolver.load(mapPath)
+	if err != nil {
+		logging.Logger.FatalE("failed to load CRS to TIPLOC mapping", err)
+	}
+
+	logging.Logger.Infof("Loaded TIPLOCs for %d CRS codes from %s", len(resolver.tiplocs), mapPath)
+	return resolver
+}
+
+func (r *StaticResolver) load(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = 2
+	reader.Comment = '#'
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", path, err)
+		}
+
+		crs := strings.ToUpper(strings.TrimSpace(record[0]))
+		tiploc := strings.ToUpper(strings.TrimSpace(record[1]))
+		r.tiplocs[crs] = append(r.tiplocs[crs], tiploc)
+	}
+}
+
+func (r *StaticResolver) TiplocsForCRS(crs string) []string {
+	crs = strings.ToUpper(crs)
+	if tiplocs, ok := r.tiplocs[crs]; ok {
+		return tiplocs
+	}
+	return []string{crs}
+}
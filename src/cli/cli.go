@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+type command struct {
+	usage string
+	run   func(args []string, s3client *s3.Client) error
+}
+
+var commands = map[string]command{
+	"report": {
+		usage: "report [-from YYYY-MM-DD] [-to YYYY-MM-DD] [-format csv|json] [-upload]\tpunctuality of services, operators and stations, from the archive",
+		run:   runReport,
+	},
+	"service": {
+		usage: "service [-raw] <rid>\tprint a service's state and update history, rebuilt from the archive",
+		run:   runService,
+	},
+	"sql": {
+		usage: "sql [-days N] [-csv] [-db path] <query>\trun SQL over the last N days of the analytics database",
+		run:   runSQL,
+	},
+}
+
+// Run runs one of the command line tools, returning the exit code for the process
+func Run(args []string, s3client *s3.Client) int {
+	if len(args) == 0 {
+		printUsage()
+		return 2
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+		printUsage()
+		return 2
+	}
+
+	err := cmd.run(args[1:], s3client)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: pushport [command]")
+	fmt.Fprintln(os.Stderr, "\nRuns the consumer when no command is given. Commands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %s\n", commands[name].usage)
+	}
+}
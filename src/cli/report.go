@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"gemini-push-port/darwintime"
+	"gemini-push-port/performance"
+	"gemini-push-port/rawstore"
+	"gemini-push-port/refdata"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+func runReport(args []string, s3client *s3.Client) error {
+	yesterday := time.Now().In(darwintime.UK).AddDate(0, 0, -1).Format(time.DateOnly)
+
+	flags := flag.NewFlagSet("report", flag.ContinueOnError)
+	from := flags.String("from", yesterday, "the first day of services to report on, as YYYY-MM-DD")
+	to := flags.String("to", "", "the last day of services to report on, as YYYY-MM-DD (defaults to -from)")
+	format := flags.String("format", performance.FormatCSV, "csv or json")
+	upload := flags.Bool("upload", false, "upload the report to the bucket instead of printing it")
+	lookbehind := flags.Duration("lookbehind", performance.DefaultLookbehind, "how far before -from to read the archive from, to pick up schedules sent in advance")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New("unexpected arguments")
+	}
+	if *format != performance.FormatCSV && *format != performance.FormatJSON {
+		return errors.New("-format must be csv or json")
+	}
+	if *to == "" {
+		*to = *from
+	}
+
+	builder := performance.NewBuilder(rawstore.NewArchiveReaderFromEnv(s3client), refdata.NewStoreFromEnv(s3client), *lookbehind)
+	report, err := builder.Build(context.Background(), *from, *to)
+	if err != nil {
+		return err
+	}
+
+	if !*upload {
+		return report.Write(os.Stdout, *format)
+	}
+
+	key, err := report.Upload(context.Background(), s3client, *format)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Uploaded report to %s\n", key)
+	return nil
+}
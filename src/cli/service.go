@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"gemini-push-port/rawstore"
+	"gemini-push-port/refdata"
+	"gemini-push-port/servicehistory"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+func runService(args []string, s3client *s3.Client) error {
+	flags := flag.NewFlagSet("service", flag.ContinueOnError)
+	includeRaw := flags.Bool("raw", false, "include the raw XML of each update")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("expected a single RID")
+	}
+
+	lookup := servicehistory.NewLookup(nil, nil, rawstore.NewArchiveReaderFromEnv(s3client), refdata.NewStoreFromEnv(s3client))
+	detail, err := lookup.Get(context.Background(), flags.Arg(0), *includeRaw)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(detail)
+}
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"gemini-push-port/analytics"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+func runSQL(args []string, _ *s3.Client) error {
+	flags := flag.NewFlagSet("sql", flag.ContinueOnError)
+	days := flags.Int("days", 1, "only include messages from the last N days")
+	asCSV := flags.Bool("csv", false, "print the results as CSV rather than a table")
+	dbPath := flags.String("db", "", "the analytics database (defaults to ANALYTICS_DB_PATH, or analytics.db in the workdir)")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("expected a single query")
+	}
+	if *days < 1 {
+		return errors.New("-days must be at least 1")
+	}
+	if *dbPath == "" {
+		*dbPath = analytics.DBPathFromEnv()
+	}
+
+	reader, err := analytics.OpenReader(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	since := time.Now().Add(-time.Duration(*days) * 24 * time.Hour)
+	rows, err := reader.Query(context.Background(), since, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if *asCSV {
+		return writeRows(rows, csvRowWriter(csv.NewWriter(os.Stdout)))
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	return writeRows(rows, func(values []string, last bool) error {
+		if last {
+			return tw.Flush()
+		}
+		_, err := fmt.Fprintln(tw, strings.Join(values, "\t"))
+		return err
+	})
+}
+
+// writeRows writes the column names, then every row, calling write with last set once there are no more
+func writeRows(rows *sql.Rows, write func(values []string, last bool) error) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	err = write(columns, false)
+	if err != nil {
+		return err
+	}
+
+	values := make([]sql.NullString, len(columns))
+	pointers := make([]any, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	for rows.Next() {
+		err = rows.Scan(pointers...)
+		if err != nil {
+			return err
+		}
+
+		row := make([]string, len(values))
+		for i, v := range values {
+			row[i] = v.String
+		}
+		err = write(row, false)
+		if err != nil {
+			return err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	return write(nil, true)
+}
+
+func csvRowWriter(w *csv.Writer) func(values []string, last bool) error {
+	return func(values []string, last bool) error {
+		if last {
+			w.Flush()
+			return w.Error()
+		}
+		return w.Write(values)
+	}
+}
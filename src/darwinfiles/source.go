@@ -0,0 +1,149 @@
+package darwinfiles
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Source finds the newest of a kind of Darwin file (timetable, reference data) either on disk or in the bucket. Darwin
+// file names start with a timestamp, so the newest file is the one whose name sorts last.
+type Source struct {
+	// Kind describes the files in error messages, e.g. "reference data"
+	Kind       string
+	LocalPath  string
+	S3Client   *s3.Client
+	BucketName string
+	S3Prefix   string
+	// Match returns whether a file name is one of the files this source is looking for
+	Match func(name string) bool
+}
+
+// File is a file found by a Source, which can be opened to read its (decompressed) contents
+type File struct {
+	Name string
+	open func() (io.ReadCloser, error)
+}
+
+// Configured returns whether a local path or bucket prefix has been set
+func (s *Source) Configured() bool {
+	return s.LocalPath != "" || s.S3Prefix != ""
+}
+
+// Newest returns the newest matching file, preferring LocalPath (a file, or a directory of files) over the bucket
+func (s *Source) Newest(ctx context.Context) (*File, error) {
+	if s.LocalPath != "" {
+		return s.newestLocalFile()
+	}
+	return s.newestBucketFile(ctx)
+}
+
+func (s *Source) newestLocalFile() (*File, error) {
+	info, err := os.Stat(s.LocalPath)
+	if err != nil {
+		return nil, err
+	}
+
+	filePath := s.LocalPath
+	if info.IsDir() {
+		entries, err := os.ReadDir(s.LocalPath)
+		if err != nil {
+			return nil, err
+		}
+
+		newest := ""
+		for _, entry := range entries {
+			if !entry.IsDir() && s.Match(entry.Name()) && entry.Name() > newest {
+				newest = entry.Name()
+			}
+		}
+		if newest == "" {
+			return nil, fmt.Errorf("no %s files found in %s", s.Kind, s.LocalPath)
+		}
+		filePath = filepath.Join(s.LocalPath, newest)
+	}
+
+	return &File{
+		Name: filepath.Base(filePath),
+		open: func() (io.ReadCloser, error) {
+			return os.Open(filePath)
+		},
+	}, nil
+}
+
+func (s *Source) newestBucketFile(ctx context.Context) (*File, error) {
+	if s.S3Client == nil || s.BucketName == "" {
+		return nil, fmt.Errorf("no bucket configured to load %s from", s.Kind)
+	}
+
+	newestKey := ""
+	paginator := s3.NewListObjectsV2Paginator(s.S3Client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.BucketName),
+		Prefix: aws.String(s.S3Prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range page.Contents {
+			key := aws.ToString(obj.Key)
+			if s.Match(path.Base(key)) && path.Base(key) > path.Base(newestKey) {
+				newestKey = key
+			}
+		}
+	}
+	if newestKey == "" {
+		return nil, fmt.Errorf("no %s files found under %s", s.Kind, s.S3Prefix)
+	}
+
+	return &File{
+		Name: path.Base(newestKey),
+		open: func() (io.ReadCloser, error) {
+			obj, err := s.S3Client.GetObject(ctx, &s3.GetObjectInput{
+				Bucket: aws.String(s.BucketName),
+				Key:    aws.String(newestKey),
+			})
+			if err != nil {
+				return nil, err
+			}
+			return obj.Body, nil
+		},
+	}, nil
+}
+
+// Open opens the file, decompressing it if its name ends in .gz
+func (f *File) Open() (io.ReadCloser, error) {
+	reader, err := f.open()
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(f.Name, ".gz") {
+		return reader, nil
+	}
+
+	gzReader, err := gzip.NewReader(reader)
+	if err != nil {
+		reader.Close()
+		return nil, fmt.Errorf("failed to read %s: %v", f.Name, err)
+	}
+	return &gzipFile{Reader: gzReader, underlying: reader}, nil
+}
+
+type gzipFile struct {
+	*gzip.Reader
+	underlying io.ReadCloser
+}
+
+func (g *gzipFile) Close() error {
+	return errors.Join(g.Reader.Close(), g.underlying.Close())
+}
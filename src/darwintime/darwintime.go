@@ -0,0 +1,183 @@
+// Package darwintime turns Darwin's HH:MM times into absolute times.
+//
+// Darwin gives every time in a service as a UK local wall-clock time, relative to the service's scheduled start date
+// (SSD), without saying when midnight has been crossed. A time is resolved against a reference time, usually the
+// previous time in the schedule: a time more than 6 hours earlier than the reference is on the next day, and one more
+// than 18 hours later is on the previous day.
+//
+// When the clocks go back, the wall-clock times between 01:00 and 02:00 happen twice. The first occurrence at or after
+// the reference (or the first on the day, without one) is used, so a schedule running through the repeated hour stays
+// in order. When the clocks go forward, times between 01:00 and 02:00 don't exist, and are treated as if the clocks
+// hadn't changed yet (01:30 is 02:30 BST).
+//
+// Resolved times are in the UK time zone. Call UTC() on them for UTC.
+package darwintime
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata"
+)
+
+// UK is the time zone Darwin's times are in
+var UK = mustLoadLocation("Europe/London")
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+const (
+	// times further than this before the reference are on the next day
+	maxBehind = 6 * time.Hour
+	// times further than this after the reference are on the previous day
+	maxAhead = 18 * time.Hour
+)
+
+// Clock is a wall-clock time of day, as given in Darwin's HH:MM and HH:MM:SS times
+type Clock struct {
+	Hour   int
+	Minute int
+	Second int
+}
+
+// ParseClock parses a HH:MM or HH:MM:SS time
+func ParseClock(clock string) (Clock, error) {
+	parts := strings.Split(clock, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Clock{}, fmt.Errorf("invalid Darwin time %q", clock)
+	}
+
+	values := make([]int, 3)
+	for i, part := range parts {
+		v, err := strconv.Atoi(part)
+		if err != nil {
+			return Clock{}, fmt.Errorf("invalid Darwin time %q", clock)
+		}
+		values[i] = v
+	}
+
+	c := Clock{Hour: values[0], Minute: values[1], Second: values[2]}
+	if c.Hour < 0 || c.Hour > 23 || c.Minute < 0 || c.Minute > 59 || c.Second < 0 || c.Second > 59 {
+		return Clock{}, fmt.Errorf("invalid Darwin time %q", clock)
+	}
+	return c, nil
+}
+
+// ParseSSD parses a scheduled start date (YYYY-MM-DD), returning midnight on that date in the UK
+func ParseSSD(ssd string) (time.Time, error) {
+	return time.ParseInLocation(time.DateOnly, ssd, UK)
+}
+
+// Resolve turns a HH:MM or HH:MM:SS time into an absolute time on or around the scheduled start date. With a zero
+// reference, the time is on the scheduled start date itself. Returns false if clock is empty or invalid, or if it
+// doesn't occur within the window around the reference, which can happen across the 25-hour day when the clocks go
+// back.
+func Resolve(ssd time.Time, clock string, reference time.Time) (time.Time, bool) {
+	if clock == "" {
+		return time.Time{}, false
+	}
+	c, err := ParseClock(clock)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	ssd = ssd.In(UK)
+	if reference.IsZero() {
+		return slices.MinFunc(c.occurrences(ssd.Year(), ssd.Month(), ssd.Day()), time.Time.Compare), true
+	}
+
+	// look at the days either side of the reference's date, and pick the occurrence within the window around it
+	refLocal := reference.In(UK)
+	var best time.Time
+	for dayOffset := -1; dayOffset <= 1; dayOffset++ {
+		for _, candidate := range c.occurrences(refLocal.Year(), refLocal.Month(), refLocal.Day()+dayOffset) {
+			diff := candidate.Sub(reference)
+			if diff < -maxBehind || diff > maxAhead {
+				continue
+			}
+			if best.IsZero() || better(candidate, best, reference) {
+				best = candidate
+			}
+		}
+	}
+	return best, !best.IsZero()
+}
+
+// better returns whether candidate is a better resolution than current: the earliest time at or after the reference,
+// or failing that the latest time before it
+func better(candidate time.Time, current time.Time, reference time.Time) bool {
+	candidateAfter := !candidate.Before(reference)
+	currentAfter := !current.Before(reference)
+	switch {
+	case candidateAfter && currentAfter:
+		return candidate.Before(current)
+	case candidateAfter != currentAfter:
+		return candidateAfter
+	default:
+		return candidate.After(current)
+	}
+}
+
+func (c Clock) on(year int, month time.Month, day int) time.Time {
+	t := time.Date(year, month, day, c.Hour, c.Minute, c.Second, 0, UK)
+	if t.Hour() != c.Hour || t.Minute() != c.Minute {
+		// the time doesn't exist because the clocks went forward, so use the offset from before they did
+		_, offset := t.Add(-2 * time.Hour).Zone()
+		t = time.Date(year, month, day, c.Hour, c.Minute, c.Second, 0, time.FixedZone("", offset)).In(UK)
+	}
+	return t
+}
+
+// occurrences returns every instant the clock shows this time on the date, which is two when the clocks go back
+func (c Clock) occurrences(year int, month time.Month, day int) []time.Time {
+	t := c.on(year, month, day)
+	result := []time.Time{t}
+	for _, other := range []time.Time{t.Add(-time.Hour), t.Add(time.Hour)} {
+		local := other.In(UK)
+		if local.Hour() == c.Hour && local.Minute() == c.Minute && local.Second() == c.Second && local.Day() == t.Day() {
+			result = append(result, other)
+		}
+	}
+	return result
+}
+
+// Sequence resolves the times of a schedule in order, each relative to the last one resolved
+type Sequence struct {
+	ssd      time.Time
+	previous time.Time
+}
+
+func NewSequence(ssd time.Time) *Sequence {
+	return &Sequence{ssd: ssd}
+}
+
+// NewSequenceNear creates a sequence whose first time is resolved near reference rather than on the scheduled start
+// date, for times which don't start at the service's origin, such as the locations in a TS message. The message's
+// timestamp makes a good reference.
+func NewSequenceNear(ssd time.Time, reference time.Time) *Sequence {
+	return &Sequence{ssd: ssd, previous: reference}
+}
+
+// Next resolves the next time in the schedule, returning the zero time if clock is empty or invalid, or can't be
+// resolved. The following time is then resolved relative to the last one which could be.
+func (s *Sequence) Next(clock string) time.Time {
+	t, ok := Resolve(s.ssd, clock, s.previous)
+	if ok {
+		s.previous = t
+	}
+	return t
+}
+
+// Near resolves a time which isn't part of the sequence, such as a forecast, relative to a time which is. Returns the
+// zero time if clock is empty or invalid.
+func Near(ssd time.Time, clock string, reference time.Time) time.Time {
+	t, _ := Resolve(ssd, clock, reference)
+	return t
+}
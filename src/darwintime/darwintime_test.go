@@ -0,0 +1,209 @@
+package darwintime
+
+import (
+	"testing"
+	"time"
+)
+
+// the clocks go forward at 01:00 GMT on 2026-03-29, and back at 02:00 BST on 2026-10-25
+const (
+	springForward = "2026-03-29"
+	fallBack      = "2026-10-25"
+)
+
+func mustSSD(t *testing.T, ssd string) time.Time {
+	t.Helper()
+	d, err := ParseSSD(ssd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func mustUTC(t *testing.T, s string) time.Time {
+	t.Helper()
+	if s == "" {
+		return time.Time{}
+	}
+	v, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name      string
+		ssd       string
+		clock     string
+		reference string
+		want      string
+		wantOK    bool
+	}{
+		{name: "no reference is on the SSD", ssd: "2026-06-01", clock: "08:15", want: "2026-06-01T07:15:00Z", wantOK: true},
+		{name: "seconds", ssd: "2026-06-01", clock: "08:15:30", want: "2026-06-01T07:15:30Z", wantOK: true},
+		{name: "winter is GMT", ssd: "2026-01-15", clock: "08:15", want: "2026-01-15T08:15:00Z", wantOK: true},
+		{name: "empty", ssd: "2026-06-01", clock: ""},
+		{name: "hour out of range", ssd: "2026-06-01", clock: "24:00"},
+		{name: "not a time", ssd: "2026-06-01", clock: "0815"},
+
+		{name: "later the same day", ssd: "2026-06-01", clock: "10:30", reference: "2026-06-01T09:00:00Z", want: "2026-06-01T09:30:00Z", wantOK: true},
+		{name: "past midnight is the next day", ssd: "2026-06-01", clock: "00:10", reference: "2026-06-01T22:50:00Z", want: "2026-06-01T23:10:00Z", wantOK: true},
+		{name: "past midnight in winter", ssd: "2026-01-15", clock: "00:10", reference: "2026-01-15T23:50:00Z", want: "2026-01-16T00:10:00Z", wantOK: true},
+		{name: "slightly earlier stays on the same day", ssd: "2026-06-01", clock: "09:55", reference: "2026-06-01T09:00:00Z", want: "2026-06-01T08:55:00Z", wantOK: true},
+		{name: "just under 6 hours earlier stays on the same day", ssd: "2026-06-01", clock: "04:01", reference: "2026-06-01T09:00:00Z", want: "2026-06-01T03:01:00Z", wantOK: true},
+		{name: "over 6 hours earlier is the next day", ssd: "2026-06-01", clock: "03:00", reference: "2026-06-01T09:00:00Z", want: "2026-06-02T02:00:00Z", wantOK: true},
+		{name: "over 18 hours later is the previous day", ssd: "2026-06-02", clock: "23:30", reference: "2026-06-02T00:00:00Z", want: "2026-06-01T22:30:00Z", wantOK: true},
+
+		{name: "spring forward gap uses GMT", ssd: springForward, clock: "01:30", want: "2026-03-29T01:30:00Z", wantOK: true},
+		{name: "spring forward after the gap is BST", ssd: springForward, clock: "02:10", want: "2026-03-29T01:10:00Z", wantOK: true},
+		{name: "spring forward before the gap is GMT", ssd: springForward, clock: "00:50", want: "2026-03-29T00:50:00Z", wantOK: true},
+
+		{name: "fall back repeated hour without a reference is the first", ssd: fallBack, clock: "01:30", want: "2026-10-25T00:30:00Z", wantOK: true},
+		{name: "fall back repeated hour after the first", ssd: fallBack, clock: "01:30", reference: "2026-10-25T00:10:00Z", want: "2026-10-25T00:30:00Z", wantOK: true},
+		{name: "fall back repeated hour after the second", ssd: fallBack, clock: "01:10", reference: "2026-10-25T00:50:00Z", want: "2026-10-25T01:10:00Z", wantOK: true},
+		{name: "fall back after the repeated hour is GMT", ssd: fallBack, clock: "02:30", want: "2026-10-25T02:30:00Z", wantOK: true},
+		// 03:00 BST on the 24th is over 6 hours earlier, and 03:00 GMT on the 25th over 18 hours later
+		{name: "outside the window before the fall back", ssd: "2026-10-24", clock: "03:00", reference: "2026-10-24T08:30:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Resolve(mustSSD(t, tt.ssd), tt.clock, mustUTC(t, tt.reference))
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if want := mustUTC(t, tt.want); !got.Equal(want) {
+				t.Errorf("got %v, want %v", got.UTC(), want)
+			}
+			if ok && got.Location() != UK {
+				t.Errorf("got location %v, want UK", got.Location())
+			}
+		})
+	}
+}
+
+func TestSequence(t *testing.T) {
+	tests := []struct {
+		name      string
+		ssd       string
+		reference string
+		clocks    []string
+		want      []string
+	}{
+		{
+			name:   "across midnight",
+			ssd:    "2026-06-01",
+			clocks: []string{"23:40", "23:58", "00:12", "", "00:30"},
+			want:   []string{"2026-06-01T22:40:00Z", "2026-06-01T22:58:00Z", "2026-06-01T23:12:00Z", "", "2026-06-01T23:30:00Z"},
+		},
+		{
+			name:   "early running doesn't roll over to the next day",
+			ssd:    "2026-06-01",
+			clocks: []string{"10:00", "10:05", "10:03", "10:20"},
+			want:   []string{"2026-06-01T09:00:00Z", "2026-06-01T09:05:00Z", "2026-06-01T09:03:00Z", "2026-06-01T09:20:00Z"},
+		},
+		{
+			name:   "through the spring forward gap",
+			ssd:    springForward,
+			clocks: []string{"00:30", "00:50", "01:30", "02:10", "03:00"},
+			want:   []string{"2026-03-29T00:30:00Z", "2026-03-29T00:50:00Z", "2026-03-29T01:30:00Z", "2026-03-29T01:10:00Z", "2026-03-29T02:00:00Z"},
+		},
+		{
+			name:   "through the fall back repeated hour",
+			ssd:    fallBack,
+			clocks: []string{"00:50", "01:10", "01:50", "01:10", "01:50", "02:05"},
+			want:   []string{"2026-10-24T23:50:00Z", "2026-10-25T00:10:00Z", "2026-10-25T00:50:00Z", "2026-10-25T01:10:00Z", "2026-10-25T01:50:00Z", "2026-10-25T02:05:00Z"},
+		},
+		{
+			name:   "overnight service over the fall back",
+			ssd:    "2026-10-24",
+			clocks: []string{"22:30", "23:55", "00:40", "01:20", "01:05", "05:30"},
+			want:   []string{"2026-10-24T21:30:00Z", "2026-10-24T22:55:00Z", "2026-10-24T23:40:00Z", "2026-10-25T00:20:00Z", "2026-10-25T01:05:00Z", "2026-10-25T05:30:00Z"},
+		},
+		{
+			name:      "near a reference after midnight",
+			ssd:       "2026-06-01",
+			reference: "2026-06-01T23:30:00Z",
+			clocks:    []string{"00:45", "01:10"},
+			want:      []string{"2026-06-01T23:45:00Z", "2026-06-02T00:10:00Z"},
+		},
+		{
+			name:      "unresolvable time before the fall back keeps the previous time",
+			ssd:       "2026-10-24",
+			reference: "2026-10-24T08:30:00Z",
+			clocks:    []string{"03:00", "09:45"},
+			want:      []string{"", "2026-10-24T08:45:00Z"},
+		},
+		{
+			name:      "near a reference before midnight",
+			ssd:       "2026-06-01",
+			reference: "2026-06-01T20:00:00Z",
+			clocks:    []string{"21:15", "00:05"},
+			want:      []string{"2026-06-01T20:15:00Z", "2026-06-01T23:05:00Z"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ssd := mustSSD(t, tt.ssd)
+			seq := NewSequence(ssd)
+			if tt.reference != "" {
+				seq = NewSequenceNear(ssd, mustUTC(t, tt.reference))
+			}
+
+			for i, clock := range tt.clocks {
+				got := seq.Next(clock)
+				if want := mustUTC(t, tt.want[i]); !got.Equal(want) {
+					t.Errorf("%s: got %v, want %v", clock, got.UTC(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewSequenceNearDiffersFromSSD(t *testing.T) {
+	ssd := mustSSD(t, "2026-06-01")
+
+	// a TS message received just after midnight only has times after midnight, which belong to the next day
+	near := NewSequenceNear(ssd, mustUTC(t, "2026-06-01T23:20:00Z")).Next("00:45")
+	if want := mustUTC(t, "2026-06-01T23:45:00Z"); !near.Equal(want) {
+		t.Errorf("near: got %v, want %v", near.UTC(), want)
+	}
+
+	onSSD := NewSequence(ssd).Next("00:45")
+	if want := mustUTC(t, "2026-05-31T23:45:00Z"); !onSSD.Equal(want) {
+		t.Errorf("on SSD: got %v, want %v", onSSD.UTC(), want)
+	}
+}
+
+func TestNear(t *testing.T) {
+	tests := []struct {
+		name      string
+		ssd       string
+		clock     string
+		reference string
+		want      string
+	}{
+		{name: "early forecast", ssd: "2026-06-01", clock: "09:58", reference: "2026-06-01T09:00:00Z", want: "2026-06-01T08:58:00Z"},
+		{name: "late forecast", ssd: "2026-06-01", clock: "10:25", reference: "2026-06-01T09:00:00Z", want: "2026-06-01T09:25:00Z"},
+		{name: "delayed past midnight", ssd: "2026-06-01", clock: "00:05", reference: "2026-06-01T22:55:00Z", want: "2026-06-01T23:05:00Z"},
+		{name: "early before midnight", ssd: "2026-06-01", clock: "23:58", reference: "2026-06-01T23:02:00Z", want: "2026-06-01T22:58:00Z"},
+		{name: "hours late stays on the day", ssd: "2026-06-01", clock: "15:00", reference: "2026-06-01T09:00:00Z", want: "2026-06-01T14:00:00Z"},
+		{name: "no reference is on the SSD", ssd: "2026-06-01", clock: "09:58", want: "2026-06-01T08:58:00Z"},
+		{name: "repeated hour forecast after the first", ssd: fallBack, clock: "01:40", reference: "2026-10-25T00:30:00Z", want: "2026-10-25T00:40:00Z"},
+		{name: "repeated hour forecast before the second", ssd: fallBack, clock: "01:25", reference: "2026-10-25T01:30:00Z", want: "2026-10-25T01:25:00Z"},
+		{name: "empty", ssd: "2026-06-01", clock: "", reference: "2026-06-01T09:00:00Z"},
+		{name: "invalid", ssd: "2026-06-01", clock: "9:75", reference: "2026-06-01T09:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Near(mustSSD(t, tt.ssd), tt.clock, mustUTC(t, tt.reference))
+			if want := mustUTC(t, tt.want); !got.Equal(want) {
+				t.Errorf("got %v, want %v", got.UTC(), want)
+			}
+		})
+	}
+}
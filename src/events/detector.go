@@ -0,0 +1,341 @@
+package events
+
+import (
+	"errors"
+	"gemini-push-port/darwintime"
+	"gemini-push-port/logging"
+	"gemini-push-port/pushport"
+	"gemini-push-port/trainstate"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultDelayThresholds = "5,15,30,60"
+
+// locationState is what was last known about a service at a location
+type locationState struct {
+	tiploc            string
+	scheduled         string
+	platform          string
+	platformConfirmed bool
+	cancelled         bool
+	departed          bool
+	arrived           bool
+	// delayLevel is the number of delay thresholds the delay has crossed
+	delayLevel int
+}
+
+type serviceState struct {
+	locations map[string]*locationState
+	updatedAt time.Time
+}
+
+// Detector compares each schedule and TS message for a service with what was known before, and sends an event on
+// eventChan for every change at a location. Events are dropped if eventChan is full, so a slow sink never holds up the
+// other handlers.
+type Detector struct {
+	mu         sync.Mutex
+	services   map[string]*serviceState
+	engine     *trainstate.Engine
+	thresholds []int
+	eventChan  chan Event
+	expiry     time.Duration
+	dropped    int
+}
+
+// NewDetectorFromEnv creates a detector which emits delay events as a service's delay at a location crosses each of
+// EVENTS_DELAY_THRESHOLDS (comma-separated minutes, 5,15,30,60 by default). The engine, if given, is used to add the
+// headcode and operator to events.
+func NewDetectorFromEnv(engine *trainstate.Engine, eventChan chan Event, expiry time.Duration) (*Detector, error) {
+	thresholdsEnv := os.Getenv("EVENTS_DELAY_THRESHOLDS")
+	if thresholdsEnv == "" {
+		thresholdsEnv = defaultDelayThresholds
+	}
+
+	var thresholds []int
+	for _, part := range strings.Split(thresholdsEnv, ",") {
+		threshold, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || threshold < 1 {
+			return nil, errors.New("EVENTS_DELAY_THRESHOLDS must be a comma-separated list of positive minutes")
+		}
+		thresholds = append(thresholds, threshold)
+	}
+
+	return &Detector{
+		services:   make(map[string]*serviceState),
+		engine:     engine,
+		thresholds: thresholds,
+		eventChan:  eventChan,
+		expiry:     expiry,
+	}, nil
+}
+
+func (d *Detector) HandleMessage(msg *pushport.Message) {
+	d.handle(msg, true)
+}
+
+// Baseline returns a handler which records the state of services without emitting any events, for replaying messages
+// which have already been seen
+func (d *Detector) Baseline() pushport.Handler {
+	return baseline{detector: d}
+}
+
+type baseline struct {
+	detector *Detector
+}
+
+func (b baseline) HandleMessage(msg *pushport.Message) {
+	b.detector.handle(msg, false)
+}
+
+func (d *Detector) handle(msg *pushport.Message, emit bool) {
+	response := msg.Pport.Response()
+	if len(response.Schedules) == 0 && len(response.TrainStatuses) == 0 {
+		return
+	}
+
+	d.mu.Lock()
+	var events []Event
+	for i := range response.Schedules {
+		events = append(events, d.applySchedule(&response.Schedules[i], msg.Time)...)
+	}
+	for i := range response.TrainStatuses {
+		events = append(events, d.applyTrainStatus(&response.TrainStatuses[i], msg.Time)...)
+	}
+	d.mu.Unlock()
+
+	if emit {
+		d.publish(events)
+	}
+}
+
+func (d *Detector) service(rid string, msgTime time.Time) *serviceState {
+	svc, ok := d.services[rid]
+	if !ok {
+		svc = &serviceState{locations: make(map[string]*locationState)}
+		d.services[rid] = svc
+	}
+	svc.updatedAt = msgTime
+	return svc
+}
+
+func (d *Detector) applySchedule(schedule *pushport.Schedule, msgTime time.Time) []Event {
+	svc := d.service(schedule.RID, msgTime)
+
+	var events []Event
+	for _, sl := range schedule.Locations {
+		if sl.Type == pushport.LocationTypePassing {
+			continue
+		}
+
+		key := locationKey(sl.Tiploc, sl.Wta, sl.Wtd, sl.Wtp)
+		loc, known := svc.locations[key]
+		if !known {
+			loc = &locationState{
+				tiploc:    sl.Tiploc,
+				scheduled: firstNonEmpty(sl.Ptd, sl.Pta, sl.Wtd, sl.Wta),
+				platform:  sl.Platform,
+				cancelled: sl.Cancelled,
+			}
+			svc.locations[key] = loc
+
+			// a service seen for the first time in a cancelled state is still news
+			if sl.Cancelled {
+				events = append(events, d.newEvent(TypeCancelled, schedule.RID, schedule.UID, schedule.SSD, msgTime, loc))
+			}
+			continue
+		}
+
+		if sl.Cancelled != loc.cancelled {
+			loc.cancelled = sl.Cancelled
+			eventType := TypeReinstated
+			if sl.Cancelled {
+				eventType = TypeCancelled
+			}
+			events = append(events, d.newEvent(eventType, schedule.RID, schedule.UID, schedule.SSD, msgTime, loc))
+		}
+		if loc.platform == "" {
+			loc.platform = sl.Platform
+		}
+	}
+	return events
+}
+
+func (d *Detector) applyTrainStatus(ts *pushport.TrainStatus, msgTime time.Time) []Event {
+	svc := d.service(ts.RID, msgTime)
+	ssd, ssdErr := darwintime.ParseSSD(ts.SSD)
+
+	var events []Event
+	for _, tl := range ts.Locations {
+		// passing points don't have a platform or public times to tell anyone about
+		if tl.Pass != nil && tl.Arrival == nil && tl.Departure == nil {
+			continue
+		}
+
+		key := locationKey(tl.Tiploc, tl.Wta, tl.Wtd, tl.Wtp)
+		loc, ok := svc.locations[key]
+		if !ok {
+			loc = &locationState{
+				tiploc:    tl.Tiploc,
+				scheduled: firstNonEmpty(tl.Ptd, tl.Pta, tl.Wtd, tl.Wta),
+			}
+			svc.locations[key] = loc
+		}
+		newEvent := func(eventType string) Event {
+			return d.newEvent(eventType, ts.RID, ts.UID, ts.SSD, msgTime, loc)
+		}
+
+		if tl.Platform != nil && tl.Platform.Number != "" {
+			if loc.platform != "" && tl.Platform.Number != loc.platform {
+				event := newEvent(TypePlatformAltered)
+				event.Platform = tl.Platform.Number
+				event.PreviousPlatform = loc.platform
+				events = append(events, event)
+				// the new platform needs confirming again
+				loc.platformConfirmed = false
+			}
+			loc.platform = tl.Platform.Number
+
+			if tl.Platform.Confirmed && !loc.platformConfirmed {
+				event := newEvent(TypePlatformConfirmed)
+				event.Platform = tl.Platform.Number
+				events = append(events, event)
+			}
+			loc.platformConfirmed = tl.Platform.Confirmed
+		}
+
+		if tl.Arrival != nil && tl.Arrival.Actual != "" && !loc.arrived {
+			loc.arrived = true
+			event := newEvent(TypeArrived)
+			event.ActualTime = tl.Arrival.Actual
+			events = append(events, event)
+		}
+		if tl.Departure != nil && tl.Departure.Actual != "" && !loc.departed {
+			loc.departed = true
+			event := newEvent(TypeDeparted)
+			event.ActualTime = tl.Departure.Actual
+			events = append(events, event)
+		}
+
+		if ssdErr == nil {
+			if delay, ok := delayMinutes(ssd, &tl); ok {
+				level := d.delayLevel(delay)
+				if level > loc.delayLevel {
+					event := newEvent(TypeDelay)
+					event.DelayMinutes = delay
+					event.Threshold = d.thresholds[level-1]
+					events = append(events, event)
+				}
+				loc.delayLevel = level
+			}
+		}
+	}
+	return events
+}
+
+// delayLevel returns the number of thresholds a delay has crossed
+func (d *Detector) delayLevel(delay int) int {
+	level := 0
+	for i, threshold := range d.thresholds {
+		if delay >= threshold {
+			level = i + 1
+		}
+	}
+	return level
+}
+
+// delayMinutes returns how late a service is (or is expected to be) at a location, preferring the departure
+func delayMinutes(ssd time.Time, tl *pushport.TSLocation) (int, bool) {
+	for _, candidate := range []struct {
+		scheduled string
+		data      *pushport.TSTimeData
+	}{
+		{firstNonEmpty(tl.Ptd, tl.Wtd), tl.Departure},
+		{firstNonEmpty(tl.Pta, tl.Wta), tl.Arrival},
+	} {
+		if candidate.data == nil || candidate.scheduled == "" {
+			continue
+		}
+
+		scheduled, ok := darwintime.Resolve(ssd, candidate.scheduled, time.Time{})
+		if !ok {
+			continue
+		}
+		expected := darwintime.Near(ssd, firstNonEmpty(candidate.data.Actual, candidate.data.Estimated, candidate.data.WorkingEstimated), scheduled)
+		if expected.IsZero() {
+			continue
+		}
+		return int(expected.Sub(scheduled).Minutes()), true
+	}
+	return 0, false
+}
+
+func (d *Detector) newEvent(eventType string, rid string, uid string, ssd string, msgTime time.Time, loc *locationState) Event {
+	return Event{
+		Type:          eventType,
+		Time:          msgTime,
+		RID:           rid,
+		UID:           uid,
+		SSD:           ssd,
+		Tiploc:        loc.tiploc,
+		ScheduledTime: loc.scheduled,
+	}
+}
+
+func (d *Detector) publish(events []Event) {
+	for i := range events {
+		if d.engine != nil {
+			if svc, ok := d.engine.Get(events[i].RID); ok {
+				events[i].TrainID = svc.TrainID
+				events[i].TOC = svc.TOC
+			}
+		}
+
+		select {
+		case d.eventChan <- events[i]:
+		default:
+			d.dropped++
+			if d.dropped%100 == 1 {
+				logging.Logger.Warnf("Event channel full, %d events dropped so far", d.dropped)
+			}
+		}
+	}
+}
+
+// Expire forgets services which haven't been updated within the detector's expiry duration
+func (d *Detector) Expire(now time.Time) int {
+	cutoff := now.Add(-d.expiry)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	removed := 0
+	for rid, svc := range d.services {
+		if svc.updatedAt.Before(cutoff) {
+			delete(d.services, rid)
+			removed++
+		}
+	}
+	return removed
+}
+
+func ExpireJob(detector *Detector) {
+	removed := detector.Expire(time.Now())
+	logging.Logger.Infof("expired %d services from event detector", removed)
+}
+
+func locationKey(tiploc string, wta string, wtd string, wtp string) string {
+	return tiploc + "|" + wta + "|" + wtd + "|" + wtp
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
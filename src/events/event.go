@@ -0,0 +1,37 @@
+package events
+
+import "time"
+
+// Event types
+const (
+	TypeDelay             = "delay"
+	TypePlatformAltered   = "platform_altered"
+	TypePlatformConfirmed = "platform_confirmed"
+	TypeCancelled         = "cancelled"
+	TypeReinstated        = "reinstated"
+	TypeDeparted          = "departed"
+	TypeArrived           = "arrived"
+)
+
+// Event is a change to a service at a location, derived from successive schedule and TS messages
+type Event struct {
+	Type string `json:"type"`
+	// Time is Darwin's timestamp for the message which caused the event
+	Time    time.Time `json:"time"`
+	RID     string    `json:"rid"`
+	UID     string    `json:"uid,omitempty"`
+	SSD     string    `json:"ssd,omitempty"`
+	TrainID string    `json:"trainId,omitempty"`
+	TOC     string    `json:"toc,omitempty"`
+	Tiploc  string    `json:"tiploc"`
+	// ScheduledTime is the public (or working, if there isn't a public one) time at the location, as HH:MM
+	ScheduledTime string `json:"scheduledTime,omitempty"`
+	// DelayMinutes and Threshold are set on delay events: the delay crossed Threshold minutes
+	DelayMinutes int `json:"delayMinutes,omitempty"`
+	Threshold    int `json:"threshold,omitempty"`
+	// Platform and PreviousPlatform are set on platform events
+	Platform         string `json:"platform,omitempty"`
+	PreviousPlatform string `json:"previousPlatform,omitempty"`
+	// ActualTime is set on departed and arrived events, as HH:MM
+	ActualTime string `json:"actualTime,omitempty"`
+}
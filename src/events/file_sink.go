@@ -0,0 +1,74 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileSink appends events as JSON lines to a file per day
+type FileSink struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// NewFileSinkFromEnv writes events to EVENTS_FILE_DIR, or an events directory in the workdir
+func NewFileSinkFromEnv() (*FileSink, error) {
+	dir := os.Getenv("EVENTS_FILE_DIR")
+	if dir == "" {
+		workdir := os.Getenv("PUSH_PORT_DUMP_WORKDIR")
+		if workdir == "" {
+			return nil, errors.New("PUSH_PORT_DUMP_WORKDIR environment variable not set")
+		}
+		dir = filepath.Join(workdir, "events")
+	}
+
+	return &FileSink{dir: dir}, nil
+}
+
+func (f *FileSink) Publish(_ context.Context, events []Event) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	err := os.MkdirAll(f.dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	var file *os.File
+	currentPath := ""
+	defer func() {
+		if file != nil {
+			_ = file.Close()
+		}
+	}()
+
+	for _, event := range events {
+		filePath := filepath.Join(f.dir, event.Time.UTC().Format(time.DateOnly)+".log")
+		if filePath != currentPath {
+			if file != nil {
+				_ = file.Close()
+			}
+			file, err = os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+			if err != nil {
+				file = nil
+				return err
+			}
+			currentPath = filePath
+		}
+
+		line, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		_, err = file.Write(append(line, '\n'))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
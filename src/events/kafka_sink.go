@@ -0,0 +1,53 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaSink produces events to a Kafka topic, keyed by RID so each service's events stay in order
+type KafkaSink struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaSinkFromEnv produces events to EVENTS_KAFKA_TOPIC on EVENTS_KAFKA_BROKERS (comma-separated host:port)
+func NewKafkaSinkFromEnv() (*KafkaSink, error) {
+	brokers := os.Getenv("EVENTS_KAFKA_BROKERS")
+	if brokers == "" {
+		return nil, errors.New("EVENTS_KAFKA_BROKERS environment variable not set")
+	}
+	topic := os.Getenv("EVENTS_KAFKA_TOPIC")
+	if topic == "" {
+		return nil, errors.New("EVENTS_KAFKA_TOPIC environment variable not set")
+	}
+
+	return &KafkaSink{
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(strings.Split(brokers, ",")...),
+			Topic:                  topic,
+			Balancer:               &kafka.Hash{},
+			AllowAutoTopicCreation: true,
+		},
+	}, nil
+}
+
+func (k *KafkaSink) Publish(ctx context.Context, events []Event) error {
+	messages := make([]kafka.Message, len(events))
+	for i, event := range events {
+		value, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		messages[i] = kafka.Message{
+			Key:   []byte(event.RID),
+			Value: value,
+		}
+	}
+
+	return k.writer.WriteMessages(ctx, messages...)
+}
@@ -0,0 +1,92 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gemini-push-port/logging"
+	"os"
+	"strings"
+)
+
+// Sink publishes events somewhere outside the service
+type Sink interface {
+	Publish(ctx context.Context, events []Event) error
+}
+
+// NewSinkFromEnv creates the sinks listed in EVENTS_SINKS (a comma-separated list of file, webhook and kafka). Returns
+// nil if no sinks are configured, in which case events shouldn't be detected at all.
+func NewSinkFromEnv() (Sink, error) {
+	names := os.Getenv("EVENTS_SINKS")
+	if names == "" {
+		return nil, nil
+	}
+
+	var sinks multiSink
+	for _, name := range strings.Split(names, ",") {
+		var sink Sink
+		var err error
+		switch strings.TrimSpace(name) {
+		case "file":
+			sink, err = NewFileSinkFromEnv()
+		case "webhook":
+			sink, err = NewWebhookSinkFromEnv()
+		case "kafka":
+			sink, err = NewKafkaSinkFromEnv()
+		default:
+			err = fmt.Errorf("unknown event sink %q in EVENTS_SINKS", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+
+	if len(sinks) == 1 {
+		return sinks[0], nil
+	}
+	return sinks, nil
+}
+
+// multiSink publishes to every sink, even if an earlier one fails
+type multiSink []Sink
+
+func (m multiSink) Publish(ctx context.Context, events []Event) error {
+	var errs []error
+	for _, sink := range m {
+		errs = append(errs, sink.Publish(ctx, events))
+	}
+	return errors.Join(errs...)
+}
+
+const maxBatchSize = 500
+
+// Thread publishes events from eventChan to the sink, in batches of whatever has arrived since the last publish
+func Thread(eventChan chan Event, sink Sink) {
+	for {
+		event, ok := <-eventChan
+		if !ok {
+			// Channel closed, exit the loop
+			return
+		}
+
+		batch := []Event{event}
+	drain:
+		for len(batch) < maxBatchSize {
+			select {
+			case event, ok = <-eventChan:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, event)
+			default:
+				break drain
+			}
+		}
+
+		err := sink.Publish(context.Background(), batch)
+		if err != nil {
+			logging.Logger.ErrorE(fmt.Sprintf("failed to publish %d events", len(batch)), err)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// WebhookSink POSTs each batch of events to a URL as a JSON array
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookSinkFromEnv posts events to EVENTS_WEBHOOK_URL
+func NewWebhookSinkFromEnv() (*WebhookSink, error) {
+	url := os.Getenv("EVENTS_WEBHOOK_URL")
+	if url == "" {
+		return nil, errors.New("EVENTS_WEBHOOK_URL environment variable not set")
+	}
+
+	return &WebhookSink{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (w *WebhookSink) Publish(ctx context.Context, events []Event) error {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("event webhook returned %s", resp.Status)
+	}
+	return nil
+}
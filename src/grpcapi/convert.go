@@ -0,0 +1,258 @@
+package grpcapi
+
+import (
+	"gemini-push-port/generic"
+	"gemini-push-port/grpcapi/pushportpb"
+	"gemini-push-port/pushport"
+	"gemini-push-port/trainstate"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func toProtoMessage(msg *pushport.Message, includeXML bool, dropped int) *pushportpb.Message {
+	response := msg.Pport.Response()
+
+	m := &pushportpb.Message{
+		Time:            timestamppb.New(msg.Time),
+		ReceivedAt:      timestamppb.New(msg.Raw.MessageTime),
+		Types:           response.MessageTypes(),
+		Rids:            response.RIDs(),
+		UpdateOrigin:    response.UpdateOrigin,
+		RequestSource:   response.RequestSource,
+		Snapshot:        msg.Pport.SnapshotResponse != nil,
+		Schedules:       generic.Map(response.Schedules, toProtoSchedule),
+		Deactivated:     generic.Map(response.Deactivated, toProtoDeactivated),
+		Associations:    generic.Map(response.Associations, toProtoAssociation),
+		TrainStatuses:   generic.Map(response.TrainStatuses, toProtoTrainStatus),
+		StationMessages: generic.Map(response.StationMessages, toProtoStationMessage),
+		Dropped:         int32(dropped),
+	}
+	if includeXML {
+		m.Xml = msg.Raw.Message
+	}
+	return m
+}
+
+func toProtoReason(r *pushport.Reason) *pushportpb.Reason {
+	if r == nil {
+		return nil
+	}
+	return &pushportpb.Reason{
+		Code:   r.Code,
+		Tiploc: r.Tiploc,
+		Near:   r.Near,
+	}
+}
+
+func toProtoSchedule(s pushport.Schedule) *pushportpb.Schedule {
+	return &pushportpb.Schedule{
+		Rid:             s.RID,
+		Uid:             s.UID,
+		TrainId:         s.TrainID,
+		Rsid:            s.RSID,
+		Ssd:             s.SSD,
+		Toc:             s.TOC,
+		Status:          s.Status,
+		TrainCat:        s.TrainCat,
+		IsPassengerSvc:  s.PassengerService(),
+		IsActive:        s.Active(),
+		Deleted:         s.IsDeleted(),
+		IsCharter:       s.Charter(),
+		Locations:       generic.Map(s.Locations, toProtoScheduleLocation),
+		CancelReason:    toProtoReason(s.CancelReason),
+		DivertedVia:     s.DivertedVia,
+		DiversionReason: toProtoReason(s.DiversionReason),
+	}
+}
+
+func toProtoScheduleLocation(l pushport.ScheduleLocation) *pushportpb.ScheduleLocation {
+	return &pushportpb.ScheduleLocation{
+		Type:              l.Type,
+		Tiploc:            l.Tiploc,
+		Activities:        l.Activities,
+		PlannedActivities: l.PlannedActivities,
+		Cancelled:         l.Cancelled,
+		FormationId:       l.FormationID,
+		AffectedBy:        l.AffectedBy,
+		Platform:          l.Platform,
+		Pta:               l.Pta,
+		Ptd:               l.Ptd,
+		Wta:               l.Wta,
+		Wtd:               l.Wtd,
+		Wtp:               l.Wtp,
+		FalseDestination:  l.FalseDestination,
+		RouteDelay:        l.RouteDelay,
+	}
+}
+
+func toProtoDeactivated(d pushport.Deactivated) *pushportpb.Deactivated {
+	return &pushportpb.Deactivated{Rid: d.RID}
+}
+
+func toProtoAssociationService(s pushport.AssociationService) *pushportpb.AssociationService {
+	return &pushportpb.AssociationService{
+		Rid: s.RID,
+		Wta: s.Wta,
+		Wtd: s.Wtd,
+		Wtp: s.Wtp,
+		Pta: s.Pta,
+		Ptd: s.Ptd,
+	}
+}
+
+func toProtoAssociation(a pushport.Association) *pushportpb.Association {
+	return &pushportpb.Association{
+		Tiploc:      a.Tiploc,
+		Category:    a.Category,
+		IsCancelled: a.IsCancelled,
+		IsDeleted:   a.IsDeleted,
+		Main:        toProtoAssociationService(a.Main),
+		Assoc:       toProtoAssociationService(a.Assoc),
+	}
+}
+
+func toProtoTrainStatus(ts pushport.TrainStatus) *pushportpb.TrainStatus {
+	return &pushportpb.TrainStatus{
+		Rid:                ts.RID,
+		Uid:                ts.UID,
+		Ssd:                ts.SSD,
+		IsReverseFormation: ts.IsReverseFormation,
+		LateReason:         toProtoReason(ts.LateReason),
+		Locations:          generic.Map(ts.Locations, toProtoTSLocation),
+	}
+}
+
+func toProtoTSLocation(l pushport.TSLocation) *pushportpb.TSLocation {
+	loc := &pushportpb.TSLocation{
+		Tiploc:      l.Tiploc,
+		Pta:         l.Pta,
+		Ptd:         l.Ptd,
+		Wta:         l.Wta,
+		Wtd:         l.Wtd,
+		Wtp:         l.Wtp,
+		Arrival:     toProtoTimeData(l.Arrival),
+		Departure:   toProtoTimeData(l.Departure),
+		Pass:        toProtoTimeData(l.Pass),
+		Suppressed:  l.Suppressed,
+		Length:      l.Length,
+		DetachFront: l.DetachFront,
+	}
+	if l.Platform != nil {
+		loc.Platform = &pushportpb.Platform{
+			Number:        l.Platform.Number,
+			Suppressed:    l.Platform.Suppressed,
+			CisSuppressed: l.Platform.CisSuppressed,
+			Source:        l.Platform.Source,
+			Confirmed:     l.Platform.Confirmed,
+		}
+	}
+	return loc
+}
+
+func toProtoTimeData(t *pushport.TSTimeData) *pushportpb.TimeData {
+	if t == nil {
+		return nil
+	}
+	return &pushportpb.TimeData{
+		Estimated:        t.Estimated,
+		WorkingEstimated: t.WorkingEstimated,
+		Actual:           t.Actual,
+		ActualRemoved:    t.ActualRemoved,
+		ActualClass:      t.ActualClass,
+		EstimatedMinimum: t.EstimatedMinimum,
+		EstimateUnknown:  t.EstimateUnknown,
+		Delayed:          t.Delayed,
+		Source:           t.Source,
+		SourceInstance:   t.SourceInstance,
+	}
+}
+
+func toProtoStationMessage(sm pushport.StationMessage) *pushportpb.StationMessage {
+	return &pushportpb.StationMessage{
+		Id:       sm.ID,
+		Category: sm.Category,
+		Severity: sm.Severity,
+		Suppress: sm.Suppress,
+		Stations: generic.Map(sm.Stations, func(s pushport.StationMessageStation) string { return s.CRS }),
+		Text:     sm.Msg.Text(),
+		Html:     sm.Msg.InnerXML,
+	}
+}
+
+func toProtoService(s *trainstate.Service) *pushportpb.Service {
+	return &pushportpb.Service{
+		Rid:                s.RID,
+		Uid:                s.UID,
+		TrainId:            s.TrainID,
+		Rsid:               s.RSID,
+		Ssd:                s.SSD,
+		Toc:                s.TOC,
+		Status:             s.Status,
+		TrainCat:           s.TrainCat,
+		IsPassengerSvc:     s.IsPassengerSvc,
+		IsActive:           s.IsActive,
+		IsCharter:          s.IsCharter,
+		IsDeleted:          s.IsDeleted,
+		IsCancelled:        s.IsCancelled,
+		Deactivated:        s.Deactivated,
+		HasSchedule:        s.HasSchedule,
+		ScheduleSource:     s.ScheduleSource,
+		IsReverseFormation: s.IsReverseFormation,
+		CancelReason:       toProtoReason(s.CancelReason),
+		LateReason:         toProtoReason(s.LateReason),
+		DivertedVia:        s.DivertedVia,
+		Locations:          generic.Map(s.Locations, toProtoServiceLocation),
+		Associations:       generic.Map(s.Associations, toProtoServiceAssociation),
+		UpdatedAt:          timestamppb.New(s.UpdatedAt),
+	}
+}
+
+func toProtoServiceLocation(l trainstate.Location) *pushportpb.ServiceLocation {
+	return &pushportpb.ServiceLocation{
+		Tiploc:             l.Tiploc,
+		Type:               l.Type,
+		Activities:         l.Activities,
+		PlannedActivities:  l.PlannedActivities,
+		Cancelled:          l.Cancelled,
+		FalseDestination:   l.FalseDestination,
+		Pta:                l.Pta,
+		Ptd:                l.Ptd,
+		Wta:                l.Wta,
+		Wtd:                l.Wtd,
+		Wtp:                l.Wtp,
+		Arrival:            toProtoTimeEstimate(l.Arrival),
+		Departure:          toProtoTimeEstimate(l.Departure),
+		Pass:               toProtoTimeEstimate(l.Pass),
+		Platform:           l.Platform,
+		PlatformSuppressed: l.PlatformSuppressed,
+		PlatformConfirmed:  l.PlatformConfirmed,
+		PlatformSource:     l.PlatformSource,
+		Suppressed:         l.Suppressed,
+		Length:             l.Length,
+		DetachFront:        l.DetachFront,
+	}
+}
+
+func toProtoTimeEstimate(t *trainstate.TimeEstimate) *pushportpb.TimeEstimate {
+	if t == nil {
+		return nil
+	}
+	return &pushportpb.TimeEstimate{
+		Estimated:        t.Estimated,
+		WorkingEstimated: t.WorkingEstimated,
+		Actual:           t.Actual,
+		Delayed:          t.Delayed,
+		EstimateUnknown:  t.EstimateUnknown,
+		Source:           t.Source,
+	}
+}
+
+func toProtoServiceAssociation(a trainstate.Association) *pushportpb.ServiceAssociation {
+	return &pushportpb.ServiceAssociation{
+		Category:    a.Category,
+		Tiploc:      a.Tiploc,
+		MainRid:     a.MainRID,
+		AssocRid:    a.AssocRID,
+		IsCancelled: a.IsCancelled,
+	}
+}
@@ -0,0 +1,2433 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v6.32.1
+// source: pushport/v1/pushport.proto
+
+// Parsed Darwin Push Port messages, and the live state of services built from them. Field names follow the Push Port
+// schema's, and times are kept as Darwin's HH:MM strings unless they are timestamps.
+
+package pushportpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Filter selects messages. Each field matches if it's empty or any of its values match, and a message is sent if
+// every field matches.
+type Filter struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Message types: schedule, deactivated, association, TS and OW
+	Types []string `protobuf:"bytes,1,rep,name=types,proto3" json:"types,omitempty"`
+	Tocs  []string `protobuf:"bytes,2,rep,name=tocs,proto3" json:"tocs,omitempty"`
+	// Station codes, which match station messages for the station and services calling at it
+	Crs     []string `protobuf:"bytes,3,rep,name=crs,proto3" json:"crs,omitempty"`
+	Tiplocs []string `protobuf:"bytes,4,rep,name=tiplocs,proto3" json:"tiplocs,omitempty"`
+	Rids    []string `protobuf:"bytes,5,rep,name=rids,proto3" json:"rids,omitempty"`
+	// Whether to include the raw XML of each message
+	IncludeXml    bool `protobuf:"varint,6,opt,name=include_xml,json=includeXml,proto3" json:"include_xml,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Filter) Reset() {
+	*x = Filter{}
+	mi := &file_pushport_v1_pushport_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Filter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Filter) ProtoMessage() {}
+
+func (x *Filter) ProtoReflect() protoreflect.Message {
+	mi := &file_pushport_v1_pushport_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Filter.ProtoReflect.Descriptor instead.
+func (*Filter) Descriptor() ([]byte, []int) {
+	return file_pushport_v1_pushport_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Filter) GetTypes() []string {
+	if x != nil {
+		return x.Types
+	}
+	return nil
+}
+
+func (x *Filter) GetTocs() []string {
+	if x != nil {
+		return x.Tocs
+	}
+	return nil
+}
+
+func (x *Filter) GetCrs() []string {
+	if x != nil {
+		return x.Crs
+	}
+	return nil
+}
+
+func (x *Filter) GetTiplocs() []string {
+	if x != nil {
+		return x.Tiplocs
+	}
+	return nil
+}
+
+func (x *Filter) GetRids() []string {
+	if x != nil {
+		return x.Rids
+	}
+	return nil
+}
+
+func (x *Filter) GetIncludeXml() bool {
+	if x != nil {
+		return x.IncludeXml
+	}
+	return false
+}
+
+type SubscribeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filter        *Filter                `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeRequest) Reset() {
+	*x = SubscribeRequest{}
+	mi := &file_pushport_v1_pushport_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeRequest) ProtoMessage() {}
+
+func (x *SubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pushport_v1_pushport_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_pushport_v1_pushport_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SubscribeRequest) GetFilter() *Filter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+type GetServiceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rid           string                 `protobuf:"bytes,1,opt,name=rid,proto3" json:"rid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetServiceRequest) Reset() {
+	*x = GetServiceRequest{}
+	mi := &file_pushport_v1_pushport_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetServiceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetServiceRequest) ProtoMessage() {}
+
+func (x *GetServiceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pushport_v1_pushport_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetServiceRequest.ProtoReflect.Descriptor instead.
+func (*GetServiceRequest) Descriptor() ([]byte, []int) {
+	return file_pushport_v1_pushport_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetServiceRequest) GetRid() string {
+	if x != nil {
+		return x.Rid
+	}
+	return ""
+}
+
+type ReplayRangeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To            *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	Filter        *Filter                `protobuf:"bytes,3,opt,name=filter,proto3" json:"filter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplayRangeRequest) Reset() {
+	*x = ReplayRangeRequest{}
+	mi := &file_pushport_v1_pushport_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplayRangeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplayRangeRequest) ProtoMessage() {}
+
+func (x *ReplayRangeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pushport_v1_pushport_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplayRangeRequest.ProtoReflect.Descriptor instead.
+func (*ReplayRangeRequest) Descriptor() ([]byte, []int) {
+	return file_pushport_v1_pushport_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ReplayRangeRequest) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *ReplayRangeRequest) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *ReplayRangeRequest) GetFilter() *Filter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+type Message struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Darwin's timestamp for the message
+	Time *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	// When the message was received from Kafka. Archived messages only know the hour they were received in.
+	ReceivedAt      *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=received_at,json=receivedAt,proto3" json:"received_at,omitempty"`
+	Types           []string               `protobuf:"bytes,3,rep,name=types,proto3" json:"types,omitempty"`
+	Rids            []string               `protobuf:"bytes,4,rep,name=rids,proto3" json:"rids,omitempty"`
+	UpdateOrigin    string                 `protobuf:"bytes,5,opt,name=update_origin,json=updateOrigin,proto3" json:"update_origin,omitempty"`
+	RequestSource   string                 `protobuf:"bytes,6,opt,name=request_source,json=requestSource,proto3" json:"request_source,omitempty"`
+	Snapshot        bool                   `protobuf:"varint,7,opt,name=snapshot,proto3" json:"snapshot,omitempty"`
+	Schedules       []*Schedule            `protobuf:"bytes,8,rep,name=schedules,proto3" json:"schedules,omitempty"`
+	Deactivated     []*Deactivated         `protobuf:"bytes,9,rep,name=deactivated,proto3" json:"deactivated,omitempty"`
+	Associations    []*Association         `protobuf:"bytes,10,rep,name=associations,proto3" json:"associations,omitempty"`
+	TrainStatuses   []*TrainStatus         `protobuf:"bytes,11,rep,name=train_statuses,json=trainStatuses,proto3" json:"train_statuses,omitempty"`
+	StationMessages []*StationMessage      `protobuf:"bytes,12,rep,name=station_messages,json=stationMessages,proto3" json:"station_messages,omitempty"`
+	Xml             string                 `protobuf:"bytes,13,opt,name=xml,proto3" json:"xml,omitempty"`
+	// Messages dropped for this subscriber since the last one it was sent
+	Dropped       int32 `protobuf:"varint,14,opt,name=dropped,proto3" json:"dropped,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Message) Reset() {
+	*x = Message{}
+	mi := &file_pushport_v1_pushport_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Message) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Message) ProtoMessage() {}
+
+func (x *Message) ProtoReflect() protoreflect.Message {
+	mi := &file_pushport_v1_pushport_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Message.ProtoReflect.Descriptor instead.
+func (*Message) Descriptor() ([]byte, []int) {
+	return file_pushport_v1_pushport_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Message) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *Message) GetReceivedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ReceivedAt
+	}
+	return nil
+}
+
+func (x *Message) GetTypes() []string {
+	if x != nil {
+		return x.Types
+	}
+	return nil
+}
+
+func (x *Message) GetRids() []string {
+	if x != nil {
+		return x.Rids
+	}
+	return nil
+}
+
+func (x *Message) GetUpdateOrigin() string {
+	if x != nil {
+		return x.UpdateOrigin
+	}
+	return ""
+}
+
+func (x *Message) GetRequestSource() string {
+	if x != nil {
+		return x.RequestSource
+	}
+	return ""
+}
+
+func (x *Message) GetSnapshot() bool {
+	if x != nil {
+		return x.Snapshot
+	}
+	return false
+}
+
+func (x *Message) GetSchedules() []*Schedule {
+	if x != nil {
+		return x.Schedules
+	}
+	return nil
+}
+
+func (x *Message) GetDeactivated() []*Deactivated {
+	if x != nil {
+		return x.Deactivated
+	}
+	return nil
+}
+
+func (x *Message) GetAssociations() []*Association {
+	if x != nil {
+		return x.Associations
+	}
+	return nil
+}
+
+func (x *Message) GetTrainStatuses() []*TrainStatus {
+	if x != nil {
+		return x.TrainStatuses
+	}
+	return nil
+}
+
+func (x *Message) GetStationMessages() []*StationMessage {
+	if x != nil {
+		return x.StationMessages
+	}
+	return nil
+}
+
+func (x *Message) GetXml() string {
+	if x != nil {
+		return x.Xml
+	}
+	return ""
+}
+
+func (x *Message) GetDropped() int32 {
+	if x != nil {
+		return x.Dropped
+	}
+	return 0
+}
+
+type Reason struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	Tiploc        string                 `protobuf:"bytes,2,opt,name=tiploc,proto3" json:"tiploc,omitempty"`
+	Near          bool                   `protobuf:"varint,3,opt,name=near,proto3" json:"near,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Reason) Reset() {
+	*x = Reason{}
+	mi := &file_pushport_v1_pushport_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Reason) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Reason) ProtoMessage() {}
+
+func (x *Reason) ProtoReflect() protoreflect.Message {
+	mi := &file_pushport_v1_pushport_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Reason.ProtoReflect.Descriptor instead.
+func (*Reason) Descriptor() ([]byte, []int) {
+	return file_pushport_v1_pushport_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Reason) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *Reason) GetTiploc() string {
+	if x != nil {
+		return x.Tiploc
+	}
+	return ""
+}
+
+func (x *Reason) GetNear() bool {
+	if x != nil {
+		return x.Near
+	}
+	return false
+}
+
+type Schedule struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Rid             string                 `protobuf:"bytes,1,opt,name=rid,proto3" json:"rid,omitempty"`
+	Uid             string                 `protobuf:"bytes,2,opt,name=uid,proto3" json:"uid,omitempty"`
+	TrainId         string                 `protobuf:"bytes,3,opt,name=train_id,json=trainId,proto3" json:"train_id,omitempty"`
+	Rsid            string                 `protobuf:"bytes,4,opt,name=rsid,proto3" json:"rsid,omitempty"`
+	Ssd             string                 `protobuf:"bytes,5,opt,name=ssd,proto3" json:"ssd,omitempty"`
+	Toc             string                 `protobuf:"bytes,6,opt,name=toc,proto3" json:"toc,omitempty"`
+	Status          string                 `protobuf:"bytes,7,opt,name=status,proto3" json:"status,omitempty"`
+	TrainCat        string                 `protobuf:"bytes,8,opt,name=train_cat,json=trainCat,proto3" json:"train_cat,omitempty"`
+	IsPassengerSvc  bool                   `protobuf:"varint,9,opt,name=is_passenger_svc,json=isPassengerSvc,proto3" json:"is_passenger_svc,omitempty"`
+	IsActive        bool                   `protobuf:"varint,10,opt,name=is_active,json=isActive,proto3" json:"is_active,omitempty"`
+	Deleted         bool                   `protobuf:"varint,11,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	IsCharter       bool                   `protobuf:"varint,12,opt,name=is_charter,json=isCharter,proto3" json:"is_charter,omitempty"`
+	Locations       []*ScheduleLocation    `protobuf:"bytes,13,rep,name=locations,proto3" json:"locations,omitempty"`
+	CancelReason    *Reason                `protobuf:"bytes,14,opt,name=cancel_reason,json=cancelReason,proto3" json:"cancel_reason,omitempty"`
+	DivertedVia     string                 `protobuf:"bytes,15,opt,name=diverted_via,json=divertedVia,proto3" json:"diverted_via,omitempty"`
+	DiversionReason *Reason                `protobuf:"bytes,16,opt,name=diversion_reason,json=diversionReason,proto3" json:"diversion_reason,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Schedule) Reset() {
+	*x = Schedule{}
+	mi := &file_pushport_v1_pushport_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Schedule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Schedule) ProtoMessage() {}
+
+func (x *Schedule) ProtoReflect() protoreflect.Message {
+	mi := &file_pushport_v1_pushport_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Schedule.ProtoReflect.Descriptor instead.
+func (*Schedule) Descriptor() ([]byte, []int) {
+	return file_pushport_v1_pushport_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Schedule) GetRid() string {
+	if x != nil {
+		return x.Rid
+	}
+	return ""
+}
+
+func (x *Schedule) GetUid() string {
+	if x != nil {
+		return x.Uid
+	}
+	return ""
+}
+
+func (x *Schedule) GetTrainId() string {
+	if x != nil {
+		return x.TrainId
+	}
+	return ""
+}
+
+func (x *Schedule) GetRsid() string {
+	if x != nil {
+		return x.Rsid
+	}
+	return ""
+}
+
+func (x *Schedule) GetSsd() string {
+	if x != nil {
+		return x.Ssd
+	}
+	return ""
+}
+
+func (x *Schedule) GetToc() string {
+	if x != nil {
+		return x.Toc
+	}
+	return ""
+}
+
+func (x *Schedule) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Schedule) GetTrainCat() string {
+	if x != nil {
+		return x.TrainCat
+	}
+	return ""
+}
+
+func (x *Schedule) GetIsPassengerSvc() bool {
+	if x != nil {
+		return x.IsPassengerSvc
+	}
+	return false
+}
+
+func (x *Schedule) GetIsActive() bool {
+	if x != nil {
+		return x.IsActive
+	}
+	return false
+}
+
+func (x *Schedule) GetDeleted() bool {
+	if x != nil {
+		return x.Deleted
+	}
+	return false
+}
+
+func (x *Schedule) GetIsCharter() bool {
+	if x != nil {
+		return x.IsCharter
+	}
+	return false
+}
+
+func (x *Schedule) GetLocations() []*ScheduleLocation {
+	if x != nil {
+		return x.Locations
+	}
+	return nil
+}
+
+func (x *Schedule) GetCancelReason() *Reason {
+	if x != nil {
+		return x.CancelReason
+	}
+	return nil
+}
+
+func (x *Schedule) GetDivertedVia() string {
+	if x != nil {
+		return x.DivertedVia
+	}
+	return ""
+}
+
+func (x *Schedule) GetDiversionReason() *Reason {
+	if x != nil {
+		return x.DiversionReason
+	}
+	return nil
+}
+
+type ScheduleLocation struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// OR, OPOR, IP, OPIP, PP, DT or OPDT
+	Type              string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Tiploc            string `protobuf:"bytes,2,opt,name=tiploc,proto3" json:"tiploc,omitempty"`
+	Activities        string `protobuf:"bytes,3,opt,name=activities,proto3" json:"activities,omitempty"`
+	PlannedActivities string `protobuf:"bytes,4,opt,name=planned_activities,json=plannedActivities,proto3" json:"planned_activities,omitempty"`
+	Cancelled         bool   `protobuf:"varint,5,opt,name=cancelled,proto3" json:"cancelled,omitempty"`
+	FormationId       string `protobuf:"bytes,6,opt,name=formation_id,json=formationId,proto3" json:"formation_id,omitempty"`
+	AffectedBy        string `protobuf:"bytes,7,opt,name=affected_by,json=affectedBy,proto3" json:"affected_by,omitempty"`
+	Platform          string `protobuf:"bytes,8,opt,name=platform,proto3" json:"platform,omitempty"`
+	Pta               string `protobuf:"bytes,9,opt,name=pta,proto3" json:"pta,omitempty"`
+	Ptd               string `protobuf:"bytes,10,opt,name=ptd,proto3" json:"ptd,omitempty"`
+	Wta               string `protobuf:"bytes,11,opt,name=wta,proto3" json:"wta,omitempty"`
+	Wtd               string `protobuf:"bytes,12,opt,name=wtd,proto3" json:"wtd,omitempty"`
+	Wtp               string `protobuf:"bytes,13,opt,name=wtp,proto3" json:"wtp,omitempty"`
+	FalseDestination  string `protobuf:"bytes,14,opt,name=false_destination,json=falseDestination,proto3" json:"false_destination,omitempty"`
+	RouteDelay        string `protobuf:"bytes,15,opt,name=route_delay,json=routeDelay,proto3" json:"route_delay,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *ScheduleLocation) Reset() {
+	*x = ScheduleLocation{}
+	mi := &file_pushport_v1_pushport_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScheduleLocation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScheduleLocation) ProtoMessage() {}
+
+func (x *ScheduleLocation) ProtoReflect() protoreflect.Message {
+	mi := &file_pushport_v1_pushport_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScheduleLocation.ProtoReflect.Descriptor instead.
+func (*ScheduleLocation) Descriptor() ([]byte, []int) {
+	return file_pushport_v1_pushport_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ScheduleLocation) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *ScheduleLocation) GetTiploc() string {
+	if x != nil {
+		return x.Tiploc
+	}
+	return ""
+}
+
+func (x *ScheduleLocation) GetActivities() string {
+	if x != nil {
+		return x.Activities
+	}
+	return ""
+}
+
+func (x *ScheduleLocation) GetPlannedActivities() string {
+	if x != nil {
+		return x.PlannedActivities
+	}
+	return ""
+}
+
+func (x *ScheduleLocation) GetCancelled() bool {
+	if x != nil {
+		return x.Cancelled
+	}
+	return false
+}
+
+func (x *ScheduleLocation) GetFormationId() string {
+	if x != nil {
+		return x.FormationId
+	}
+	return ""
+}
+
+func (x *ScheduleLocation) GetAffectedBy() string {
+	if x != nil {
+		return x.AffectedBy
+	}
+	return ""
+}
+
+func (x *ScheduleLocation) GetPlatform() string {
+	if x != nil {
+		return x.Platform
+	}
+	return ""
+}
+
+func (x *ScheduleLocation) GetPta() string {
+	if x != nil {
+		return x.Pta
+	}
+	return ""
+}
+
+func (x *ScheduleLocation) GetPtd() string {
+	if x != nil {
+		return x.Ptd
+	}
+	return ""
+}
+
+func (x *ScheduleLocation) GetWta() string {
+	if x != nil {
+		return x.Wta
+	}
+	return ""
+}
+
+func (x *ScheduleLocation) GetWtd() string {
+	if x != nil {
+		return x.Wtd
+	}
+	return ""
+}
+
+func (x *ScheduleLocation) GetWtp() string {
+	if x != nil {
+		return x.Wtp
+	}
+	return ""
+}
+
+func (x *ScheduleLocation) GetFalseDestination() string {
+	if x != nil {
+		return x.FalseDestination
+	}
+	return ""
+}
+
+func (x *ScheduleLocation) GetRouteDelay() string {
+	if x != nil {
+		return x.RouteDelay
+	}
+	return ""
+}
+
+type Deactivated struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rid           string                 `protobuf:"bytes,1,opt,name=rid,proto3" json:"rid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Deactivated) Reset() {
+	*x = Deactivated{}
+	mi := &file_pushport_v1_pushport_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Deactivated) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Deactivated) ProtoMessage() {}
+
+func (x *Deactivated) ProtoReflect() protoreflect.Message {
+	mi := &file_pushport_v1_pushport_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Deactivated.ProtoReflect.Descriptor instead.
+func (*Deactivated) Descriptor() ([]byte, []int) {
+	return file_pushport_v1_pushport_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Deactivated) GetRid() string {
+	if x != nil {
+		return x.Rid
+	}
+	return ""
+}
+
+type AssociationService struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rid           string                 `protobuf:"bytes,1,opt,name=rid,proto3" json:"rid,omitempty"`
+	Wta           string                 `protobuf:"bytes,2,opt,name=wta,proto3" json:"wta,omitempty"`
+	Wtd           string                 `protobuf:"bytes,3,opt,name=wtd,proto3" json:"wtd,omitempty"`
+	Wtp           string                 `protobuf:"bytes,4,opt,name=wtp,proto3" json:"wtp,omitempty"`
+	Pta           string                 `protobuf:"bytes,5,opt,name=pta,proto3" json:"pta,omitempty"`
+	Ptd           string                 `protobuf:"bytes,6,opt,name=ptd,proto3" json:"ptd,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AssociationService) Reset() {
+	*x = AssociationService{}
+	mi := &file_pushport_v1_pushport_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AssociationService) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AssociationService) ProtoMessage() {}
+
+func (x *AssociationService) ProtoReflect() protoreflect.Message {
+	mi := &file_pushport_v1_pushport_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AssociationService.ProtoReflect.Descriptor instead.
+func (*AssociationService) Descriptor() ([]byte, []int) {
+	return file_pushport_v1_pushport_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *AssociationService) GetRid() string {
+	if x != nil {
+		return x.Rid
+	}
+	return ""
+}
+
+func (x *AssociationService) GetWta() string {
+	if x != nil {
+		return x.Wta
+	}
+	return ""
+}
+
+func (x *AssociationService) GetWtd() string {
+	if x != nil {
+		return x.Wtd
+	}
+	return ""
+}
+
+func (x *AssociationService) GetWtp() string {
+	if x != nil {
+		return x.Wtp
+	}
+	return ""
+}
+
+func (x *AssociationService) GetPta() string {
+	if x != nil {
+		return x.Pta
+	}
+	return ""
+}
+
+func (x *AssociationService) GetPtd() string {
+	if x != nil {
+		return x.Ptd
+	}
+	return ""
+}
+
+type Association struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Tiploc string                 `protobuf:"bytes,1,opt,name=tiploc,proto3" json:"tiploc,omitempty"`
+	// JJ (join), VV (divide), NP (next working) or LK (linked)
+	Category      string              `protobuf:"bytes,2,opt,name=category,proto3" json:"category,omitempty"`
+	IsCancelled   bool                `protobuf:"varint,3,opt,name=is_cancelled,json=isCancelled,proto3" json:"is_cancelled,omitempty"`
+	IsDeleted     bool                `protobuf:"varint,4,opt,name=is_deleted,json=isDeleted,proto3" json:"is_deleted,omitempty"`
+	Main          *AssociationService `protobuf:"bytes,5,opt,name=main,proto3" json:"main,omitempty"`
+	Assoc         *AssociationService `protobuf:"bytes,6,opt,name=assoc,proto3" json:"assoc,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Association) Reset() {
+	*x = Association{}
+	mi := &file_pushport_v1_pushport_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Association) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Association) ProtoMessage() {}
+
+func (x *Association) ProtoReflect() protoreflect.Message {
+	mi := &file_pushport_v1_pushport_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Association.ProtoReflect.Descriptor instead.
+func (*Association) Descriptor() ([]byte, []int) {
+	return file_pushport_v1_pushport_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Association) GetTiploc() string {
+	if x != nil {
+		return x.Tiploc
+	}
+	return ""
+}
+
+func (x *Association) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *Association) GetIsCancelled() bool {
+	if x != nil {
+		return x.IsCancelled
+	}
+	return false
+}
+
+func (x *Association) GetIsDeleted() bool {
+	if x != nil {
+		return x.IsDeleted
+	}
+	return false
+}
+
+func (x *Association) GetMain() *AssociationService {
+	if x != nil {
+		return x.Main
+	}
+	return nil
+}
+
+func (x *Association) GetAssoc() *AssociationService {
+	if x != nil {
+		return x.Assoc
+	}
+	return nil
+}
+
+type TrainStatus struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Rid                string                 `protobuf:"bytes,1,opt,name=rid,proto3" json:"rid,omitempty"`
+	Uid                string                 `protobuf:"bytes,2,opt,name=uid,proto3" json:"uid,omitempty"`
+	Ssd                string                 `protobuf:"bytes,3,opt,name=ssd,proto3" json:"ssd,omitempty"`
+	IsReverseFormation bool                   `protobuf:"varint,4,opt,name=is_reverse_formation,json=isReverseFormation,proto3" json:"is_reverse_formation,omitempty"`
+	LateReason         *Reason                `protobuf:"bytes,5,opt,name=late_reason,json=lateReason,proto3" json:"late_reason,omitempty"`
+	Locations          []*TSLocation          `protobuf:"bytes,6,rep,name=locations,proto3" json:"locations,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *TrainStatus) Reset() {
+	*x = TrainStatus{}
+	mi := &file_pushport_v1_pushport_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrainStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrainStatus) ProtoMessage() {}
+
+func (x *TrainStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_pushport_v1_pushport_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrainStatus.ProtoReflect.Descriptor instead.
+func (*TrainStatus) Descriptor() ([]byte, []int) {
+	return file_pushport_v1_pushport_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *TrainStatus) GetRid() string {
+	if x != nil {
+		return x.Rid
+	}
+	return ""
+}
+
+func (x *TrainStatus) GetUid() string {
+	if x != nil {
+		return x.Uid
+	}
+	return ""
+}
+
+func (x *TrainStatus) GetSsd() string {
+	if x != nil {
+		return x.Ssd
+	}
+	return ""
+}
+
+func (x *TrainStatus) GetIsReverseFormation() bool {
+	if x != nil {
+		return x.IsReverseFormation
+	}
+	return false
+}
+
+func (x *TrainStatus) GetLateReason() *Reason {
+	if x != nil {
+		return x.LateReason
+	}
+	return nil
+}
+
+func (x *TrainStatus) GetLocations() []*TSLocation {
+	if x != nil {
+		return x.Locations
+	}
+	return nil
+}
+
+type TSLocation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tiploc        string                 `protobuf:"bytes,1,opt,name=tiploc,proto3" json:"tiploc,omitempty"`
+	Pta           string                 `protobuf:"bytes,2,opt,name=pta,proto3" json:"pta,omitempty"`
+	Ptd           string                 `protobuf:"bytes,3,opt,name=ptd,proto3" json:"ptd,omitempty"`
+	Wta           string                 `protobuf:"bytes,4,opt,name=wta,proto3" json:"wta,omitempty"`
+	Wtd           string                 `protobuf:"bytes,5,opt,name=wtd,proto3" json:"wtd,omitempty"`
+	Wtp           string                 `protobuf:"bytes,6,opt,name=wtp,proto3" json:"wtp,omitempty"`
+	Arrival       *TimeData              `protobuf:"bytes,7,opt,name=arrival,proto3" json:"arrival,omitempty"`
+	Departure     *TimeData              `protobuf:"bytes,8,opt,name=departure,proto3" json:"departure,omitempty"`
+	Pass          *TimeData              `protobuf:"bytes,9,opt,name=pass,proto3" json:"pass,omitempty"`
+	Platform      *Platform              `protobuf:"bytes,10,opt,name=platform,proto3" json:"platform,omitempty"`
+	Suppressed    bool                   `protobuf:"varint,11,opt,name=suppressed,proto3" json:"suppressed,omitempty"`
+	Length        string                 `protobuf:"bytes,12,opt,name=length,proto3" json:"length,omitempty"`
+	DetachFront   bool                   `protobuf:"varint,13,opt,name=detach_front,json=detachFront,proto3" json:"detach_front,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TSLocation) Reset() {
+	*x = TSLocation{}
+	mi := &file_pushport_v1_pushport_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TSLocation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TSLocation) ProtoMessage() {}
+
+func (x *TSLocation) ProtoReflect() protoreflect.Message {
+	mi := &file_pushport_v1_pushport_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TSLocation.ProtoReflect.Descriptor instead.
+func (*TSLocation) Descriptor() ([]byte, []int) {
+	return file_pushport_v1_pushport_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *TSLocation) GetTiploc() string {
+	if x != nil {
+		return x.Tiploc
+	}
+	return ""
+}
+
+func (x *TSLocation) GetPta() string {
+	if x != nil {
+		return x.Pta
+	}
+	return ""
+}
+
+func (x *TSLocation) GetPtd() string {
+	if x != nil {
+		return x.Ptd
+	}
+	return ""
+}
+
+func (x *TSLocation) GetWta() string {
+	if x != nil {
+		return x.Wta
+	}
+	return ""
+}
+
+func (x *TSLocation) GetWtd() string {
+	if x != nil {
+		return x.Wtd
+	}
+	return ""
+}
+
+func (x *TSLocation) GetWtp() string {
+	if x != nil {
+		return x.Wtp
+	}
+	return ""
+}
+
+func (x *TSLocation) GetArrival() *TimeData {
+	if x != nil {
+		return x.Arrival
+	}
+	return nil
+}
+
+func (x *TSLocation) GetDeparture() *TimeData {
+	if x != nil {
+		return x.Departure
+	}
+	return nil
+}
+
+func (x *TSLocation) GetPass() *TimeData {
+	if x != nil {
+		return x.Pass
+	}
+	return nil
+}
+
+func (x *TSLocation) GetPlatform() *Platform {
+	if x != nil {
+		return x.Platform
+	}
+	return nil
+}
+
+func (x *TSLocation) GetSuppressed() bool {
+	if x != nil {
+		return x.Suppressed
+	}
+	return false
+}
+
+func (x *TSLocation) GetLength() string {
+	if x != nil {
+		return x.Length
+	}
+	return ""
+}
+
+func (x *TSLocation) GetDetachFront() bool {
+	if x != nil {
+		return x.DetachFront
+	}
+	return false
+}
+
+type TimeData struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Estimated        string                 `protobuf:"bytes,1,opt,name=estimated,proto3" json:"estimated,omitempty"`
+	WorkingEstimated string                 `protobuf:"bytes,2,opt,name=working_estimated,json=workingEstimated,proto3" json:"working_estimated,omitempty"`
+	Actual           string                 `protobuf:"bytes,3,opt,name=actual,proto3" json:"actual,omitempty"`
+	ActualRemoved    bool                   `protobuf:"varint,4,opt,name=actual_removed,json=actualRemoved,proto3" json:"actual_removed,omitempty"`
+	ActualClass      string                 `protobuf:"bytes,5,opt,name=actual_class,json=actualClass,proto3" json:"actual_class,omitempty"`
+	EstimatedMinimum string                 `protobuf:"bytes,6,opt,name=estimated_minimum,json=estimatedMinimum,proto3" json:"estimated_minimum,omitempty"`
+	EstimateUnknown  bool                   `protobuf:"varint,7,opt,name=estimate_unknown,json=estimateUnknown,proto3" json:"estimate_unknown,omitempty"`
+	Delayed          bool                   `protobuf:"varint,8,opt,name=delayed,proto3" json:"delayed,omitempty"`
+	Source           string                 `protobuf:"bytes,9,opt,name=source,proto3" json:"source,omitempty"`
+	SourceInstance   string                 `protobuf:"bytes,10,opt,name=source_instance,json=sourceInstance,proto3" json:"source_instance,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *TimeData) Reset() {
+	*x = TimeData{}
+	mi := &file_pushport_v1_pushport_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TimeData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TimeData) ProtoMessage() {}
+
+func (x *TimeData) ProtoReflect() protoreflect.Message {
+	mi := &file_pushport_v1_pushport_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TimeData.ProtoReflect.Descriptor instead.
+func (*TimeData) Descriptor() ([]byte, []int) {
+	return file_pushport_v1_pushport_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *TimeData) GetEstimated() string {
+	if x != nil {
+		return x.Estimated
+	}
+	return ""
+}
+
+func (x *TimeData) GetWorkingEstimated() string {
+	if x != nil {
+		return x.WorkingEstimated
+	}
+	return ""
+}
+
+func (x *TimeData) GetActual() string {
+	if x != nil {
+		return x.Actual
+	}
+	return ""
+}
+
+func (x *TimeData) GetActualRemoved() bool {
+	if x != nil {
+		return x.ActualRemoved
+	}
+	return false
+}
+
+func (x *TimeData) GetActualClass() string {
+	if x != nil {
+		return x.ActualClass
+	}
+	return ""
+}
+
+func (x *TimeData) GetEstimatedMinimum() string {
+	if x != nil {
+		return x.EstimatedMinimum
+	}
+	return ""
+}
+
+func (x *TimeData) GetEstimateUnknown() bool {
+	if x != nil {
+		return x.EstimateUnknown
+	}
+	return false
+}
+
+func (x *TimeData) GetDelayed() bool {
+	if x != nil {
+		return x.Delayed
+	}
+	return false
+}
+
+func (x *TimeData) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *TimeData) GetSourceInstance() string {
+	if x != nil {
+		return x.SourceInstance
+	}
+	return ""
+}
+
+type Platform struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Number        string                 `protobuf:"bytes,1,opt,name=number,proto3" json:"number,omitempty"`
+	Suppressed    bool                   `protobuf:"varint,2,opt,name=suppressed,proto3" json:"suppressed,omitempty"`
+	CisSuppressed bool                   `protobuf:"varint,3,opt,name=cis_suppressed,json=cisSuppressed,proto3" json:"cis_suppressed,omitempty"`
+	Source        string                 `protobuf:"bytes,4,opt,name=source,proto3" json:"source,omitempty"`
+	Confirmed     bool                   `protobuf:"varint,5,opt,name=confirmed,proto3" json:"confirmed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Platform) Reset() {
+	*x = Platform{}
+	mi := &file_pushport_v1_pushport_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Platform) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Platform) ProtoMessage() {}
+
+func (x *Platform) ProtoReflect() protoreflect.Message {
+	mi := &file_pushport_v1_pushport_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Platform.ProtoReflect.Descriptor instead.
+func (*Platform) Descriptor() ([]byte, []int) {
+	return file_pushport_v1_pushport_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Platform) GetNumber() string {
+	if x != nil {
+		return x.Number
+	}
+	return ""
+}
+
+func (x *Platform) GetSuppressed() bool {
+	if x != nil {
+		return x.Suppressed
+	}
+	return false
+}
+
+func (x *Platform) GetCisSuppressed() bool {
+	if x != nil {
+		return x.CisSuppressed
+	}
+	return false
+}
+
+func (x *Platform) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Platform) GetConfirmed() bool {
+	if x != nil {
+		return x.Confirmed
+	}
+	return false
+}
+
+type StationMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Category      string                 `protobuf:"bytes,2,opt,name=category,proto3" json:"category,omitempty"`
+	Severity      string                 `protobuf:"bytes,3,opt,name=severity,proto3" json:"severity,omitempty"`
+	Suppress      bool                   `protobuf:"varint,4,opt,name=suppress,proto3" json:"suppress,omitempty"`
+	Stations      []string               `protobuf:"bytes,5,rep,name=stations,proto3" json:"stations,omitempty"`
+	Text          string                 `protobuf:"bytes,6,opt,name=text,proto3" json:"text,omitempty"`
+	Html          string                 `protobuf:"bytes,7,opt,name=html,proto3" json:"html,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StationMessage) Reset() {
+	*x = StationMessage{}
+	mi := &file_pushport_v1_pushport_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StationMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StationMessage) ProtoMessage() {}
+
+func (x *StationMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_pushport_v1_pushport_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StationMessage.ProtoReflect.Descriptor instead.
+func (*StationMessage) Descriptor() ([]byte, []int) {
+	return file_pushport_v1_pushport_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *StationMessage) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *StationMessage) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *StationMessage) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *StationMessage) GetSuppress() bool {
+	if x != nil {
+		return x.Suppress
+	}
+	return false
+}
+
+func (x *StationMessage) GetStations() []string {
+	if x != nil {
+		return x.Stations
+	}
+	return nil
+}
+
+func (x *StationMessage) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *StationMessage) GetHtml() string {
+	if x != nil {
+		return x.Html
+	}
+	return ""
+}
+
+type Service struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Rid            string                 `protobuf:"bytes,1,opt,name=rid,proto3" json:"rid,omitempty"`
+	Uid            string                 `protobuf:"bytes,2,opt,name=uid,proto3" json:"uid,omitempty"`
+	TrainId        string                 `protobuf:"bytes,3,opt,name=train_id,json=trainId,proto3" json:"train_id,omitempty"`
+	Rsid           string                 `protobuf:"bytes,4,opt,name=rsid,proto3" json:"rsid,omitempty"`
+	Ssd            string                 `protobuf:"bytes,5,opt,name=ssd,proto3" json:"ssd,omitempty"`
+	Toc            string                 `protobuf:"bytes,6,opt,name=toc,proto3" json:"toc,omitempty"`
+	Status         string                 `protobuf:"bytes,7,opt,name=status,proto3" json:"status,omitempty"`
+	TrainCat       string                 `protobuf:"bytes,8,opt,name=train_cat,json=trainCat,proto3" json:"train_cat,omitempty"`
+	IsPassengerSvc bool                   `protobuf:"varint,9,opt,name=is_passenger_svc,json=isPassengerSvc,proto3" json:"is_passenger_svc,omitempty"`
+	IsActive       bool                   `protobuf:"varint,10,opt,name=is_active,json=isActive,proto3" json:"is_active,omitempty"`
+	IsCharter      bool                   `protobuf:"varint,11,opt,name=is_charter,json=isCharter,proto3" json:"is_charter,omitempty"`
+	IsDeleted      bool                   `protobuf:"varint,12,opt,name=is_deleted,json=isDeleted,proto3" json:"is_deleted,omitempty"`
+	IsCancelled    bool                   `protobuf:"varint,13,opt,name=is_cancelled,json=isCancelled,proto3" json:"is_cancelled,omitempty"`
+	Deactivated    bool                   `protobuf:"varint,14,opt,name=deactivated,proto3" json:"deactivated,omitempty"`
+	HasSchedule    bool                   `protobuf:"varint,15,opt,name=has_schedule,json=hasSchedule,proto3" json:"has_schedule,omitempty"`
+	// timetable or live
+	ScheduleSource     string                 `protobuf:"bytes,16,opt,name=schedule_source,json=scheduleSource,proto3" json:"schedule_source,omitempty"`
+	IsReverseFormation bool                   `protobuf:"varint,17,opt,name=is_reverse_formation,json=isReverseFormation,proto3" json:"is_reverse_formation,omitempty"`
+	CancelReason       *Reason                `protobuf:"bytes,18,opt,name=cancel_reason,json=cancelReason,proto3" json:"cancel_reason,omitempty"`
+	LateReason         *Reason                `protobuf:"bytes,19,opt,name=late_reason,json=lateReason,proto3" json:"late_reason,omitempty"`
+	DivertedVia        string                 `protobuf:"bytes,20,opt,name=diverted_via,json=divertedVia,proto3" json:"diverted_via,omitempty"`
+	Locations          []*ServiceLocation     `protobuf:"bytes,21,rep,name=locations,proto3" json:"locations,omitempty"`
+	Associations       []*ServiceAssociation  `protobuf:"bytes,22,rep,name=associations,proto3" json:"associations,omitempty"`
+	UpdatedAt          *timestamppb.Timestamp `protobuf:"bytes,23,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *Service) Reset() {
+	*x = Service{}
+	mi := &file_pushport_v1_pushport_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Service) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Service) ProtoMessage() {}
+
+func (x *Service) ProtoReflect() protoreflect.Message {
+	mi := &file_pushport_v1_pushport_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Service.ProtoReflect.Descriptor instead.
+func (*Service) Descriptor() ([]byte, []int) {
+	return file_pushport_v1_pushport_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *Service) GetRid() string {
+	if x != nil {
+		return x.Rid
+	}
+	return ""
+}
+
+func (x *Service) GetUid() string {
+	if x != nil {
+		return x.Uid
+	}
+	return ""
+}
+
+func (x *Service) GetTrainId() string {
+	if x != nil {
+		return x.TrainId
+	}
+	return ""
+}
+
+func (x *Service) GetRsid() string {
+	if x != nil {
+		return x.Rsid
+	}
+	return ""
+}
+
+func (x *Service) GetSsd() string {
+	if x != nil {
+		return x.Ssd
+	}
+	return ""
+}
+
+func (x *Service) GetToc() string {
+	if x != nil {
+		return x.Toc
+	}
+	return ""
+}
+
+func (x *Service) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Service) GetTrainCat() string {
+	if x != nil {
+		return x.TrainCat
+	}
+	return ""
+}
+
+func (x *Service) GetIsPassengerSvc() bool {
+	if x != nil {
+		return x.IsPassengerSvc
+	}
+	return false
+}
+
+func (x *Service) GetIsActive() bool {
+	if x != nil {
+		return x.IsActive
+	}
+	return false
+}
+
+func (x *Service) GetIsCharter() bool {
+	if x != nil {
+		return x.IsCharter
+	}
+	return false
+}
+
+func (x *Service) GetIsDeleted() bool {
+	if x != nil {
+		return x.IsDeleted
+	}
+	return false
+}
+
+func (x *Service) GetIsCancelled() bool {
+	if x != nil {
+		return x.IsCancelled
+	}
+	return false
+}
+
+func (x *Service) GetDeactivated() bool {
+	if x != nil {
+		return x.Deactivated
+	}
+	return false
+}
+
+func (x *Service) GetHasSchedule() bool {
+	if x != nil {
+		return x.HasSchedule
+	}
+	return false
+}
+
+func (x *Service) GetScheduleSource() string {
+	if x != nil {
+		return x.ScheduleSource
+	}
+	return ""
+}
+
+func (x *Service) GetIsReverseFormation() bool {
+	if x != nil {
+		return x.IsReverseFormation
+	}
+	return false
+}
+
+func (x *Service) GetCancelReason() *Reason {
+	if x != nil {
+		return x.CancelReason
+	}
+	return nil
+}
+
+func (x *Service) GetLateReason() *Reason {
+	if x != nil {
+		return x.LateReason
+	}
+	return nil
+}
+
+func (x *Service) GetDivertedVia() string {
+	if x != nil {
+		return x.DivertedVia
+	}
+	return ""
+}
+
+func (x *Service) GetLocations() []*ServiceLocation {
+	if x != nil {
+		return x.Locations
+	}
+	return nil
+}
+
+func (x *Service) GetAssociations() []*ServiceAssociation {
+	if x != nil {
+		return x.Associations
+	}
+	return nil
+}
+
+func (x *Service) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type ServiceLocation struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Tiploc             string                 `protobuf:"bytes,1,opt,name=tiploc,proto3" json:"tiploc,omitempty"`
+	Type               string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Activities         string                 `protobuf:"bytes,3,opt,name=activities,proto3" json:"activities,omitempty"`
+	PlannedActivities  string                 `protobuf:"bytes,4,opt,name=planned_activities,json=plannedActivities,proto3" json:"planned_activities,omitempty"`
+	Cancelled          bool                   `protobuf:"varint,5,opt,name=cancelled,proto3" json:"cancelled,omitempty"`
+	FalseDestination   string                 `protobuf:"bytes,6,opt,name=false_destination,json=falseDestination,proto3" json:"false_destination,omitempty"`
+	Pta                string                 `protobuf:"bytes,7,opt,name=pta,proto3" json:"pta,omitempty"`
+	Ptd                string                 `protobuf:"bytes,8,opt,name=ptd,proto3" json:"ptd,omitempty"`
+	Wta                string                 `protobuf:"bytes,9,opt,name=wta,proto3" json:"wta,omitempty"`
+	Wtd                string                 `protobuf:"bytes,10,opt,name=wtd,proto3" json:"wtd,omitempty"`
+	Wtp                string                 `protobuf:"bytes,11,opt,name=wtp,proto3" json:"wtp,omitempty"`
+	Arrival            *TimeEstimate          `protobuf:"bytes,12,opt,name=arrival,proto3" json:"arrival,omitempty"`
+	Departure          *TimeEstimate          `protobuf:"bytes,13,opt,name=departure,proto3" json:"departure,omitempty"`
+	Pass               *TimeEstimate          `protobuf:"bytes,14,opt,name=pass,proto3" json:"pass,omitempty"`
+	Platform           string                 `protobuf:"bytes,15,opt,name=platform,proto3" json:"platform,omitempty"`
+	PlatformSuppressed bool                   `protobuf:"varint,16,opt,name=platform_suppressed,json=platformSuppressed,proto3" json:"platform_suppressed,omitempty"`
+	PlatformConfirmed  bool                   `protobuf:"varint,17,opt,name=platform_confirmed,json=platformConfirmed,proto3" json:"platform_confirmed,omitempty"`
+	PlatformSource     string                 `protobuf:"bytes,18,opt,name=platform_source,json=platformSource,proto3" json:"platform_source,omitempty"`
+	Suppressed         bool                   `protobuf:"varint,19,opt,name=suppressed,proto3" json:"suppressed,omitempty"`
+	Length             string                 `protobuf:"bytes,20,opt,name=length,proto3" json:"length,omitempty"`
+	DetachFront        bool                   `protobuf:"varint,21,opt,name=detach_front,json=detachFront,proto3" json:"detach_front,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *ServiceLocation) Reset() {
+	*x = ServiceLocation{}
+	mi := &file_pushport_v1_pushport_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServiceLocation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServiceLocation) ProtoMessage() {}
+
+func (x *ServiceLocation) ProtoReflect() protoreflect.Message {
+	mi := &file_pushport_v1_pushport_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServiceLocation.ProtoReflect.Descriptor instead.
+func (*ServiceLocation) Descriptor() ([]byte, []int) {
+	return file_pushport_v1_pushport_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ServiceLocation) GetTiploc() string {
+	if x != nil {
+		return x.Tiploc
+	}
+	return ""
+}
+
+func (x *ServiceLocation) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *ServiceLocation) GetActivities() string {
+	if x != nil {
+		return x.Activities
+	}
+	return ""
+}
+
+func (x *ServiceLocation) GetPlannedActivities() string {
+	if x != nil {
+		return x.PlannedActivities
+	}
+	return ""
+}
+
+func (x *ServiceLocation) GetCancelled() bool {
+	if x != nil {
+		return x.Cancelled
+	}
+	return false
+}
+
+func (x *ServiceLocation) GetFalseDestination() string {
+	if x != nil {
+		return x.FalseDestination
+	}
+	return ""
+}
+
+func (x *ServiceLocation) GetPta() string {
+	if x != nil {
+		return x.Pta
+	}
+	return ""
+}
+
+func (x *ServiceLocation) GetPtd() string {
+	if x != nil {
+		return x.Ptd
+	}
+	return ""
+}
+
+func (x *ServiceLocation) GetWta() string {
+	if x != nil {
+		return x.Wta
+	}
+	return ""
+}
+
+func (x *ServiceLocation) GetWtd() string {
+	if x != nil {
+		return x.Wtd
+	}
+	return ""
+}
+
+func (x *ServiceLocation) GetWtp() string {
+	if x != nil {
+		return x.Wtp
+	}
+	return ""
+}
+
+func (x *ServiceLocation) GetArrival() *TimeEstimate {
+	if x != nil {
+		return x.Arrival
+	}
+	return nil
+}
+
+func (x *ServiceLocation) GetDeparture() *TimeEstimate {
+	if x != nil {
+		return x.Departure
+	}
+	return nil
+}
+
+func (x *ServiceLocation) GetPass() *TimeEstimate {
+	if x != nil {
+		return x.Pass
+	}
+	return nil
+}
+
+func (x *ServiceLocation) GetPlatform() string {
+	if x != nil {
+		return x.Platform
+	}
+	return ""
+}
+
+func (x *ServiceLocation) GetPlatformSuppressed() bool {
+	if x != nil {
+		return x.PlatformSuppressed
+	}
+	return false
+}
+
+func (x *ServiceLocation) GetPlatformConfirmed() bool {
+	if x != nil {
+		return x.PlatformConfirmed
+	}
+	return false
+}
+
+func (x *ServiceLocation) GetPlatformSource() string {
+	if x != nil {
+		return x.PlatformSource
+	}
+	return ""
+}
+
+func (x *ServiceLocation) GetSuppressed() bool {
+	if x != nil {
+		return x.Suppressed
+	}
+	return false
+}
+
+func (x *ServiceLocation) GetLength() string {
+	if x != nil {
+		return x.Length
+	}
+	return ""
+}
+
+func (x *ServiceLocation) GetDetachFront() bool {
+	if x != nil {
+		return x.DetachFront
+	}
+	return false
+}
+
+type TimeEstimate struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Estimated        string                 `protobuf:"bytes,1,opt,name=estimated,proto3" json:"estimated,omitempty"`
+	WorkingEstimated string                 `protobuf:"bytes,2,opt,name=working_estimated,json=workingEstimated,proto3" json:"working_estimated,omitempty"`
+	Actual           string                 `protobuf:"bytes,3,opt,name=actual,proto3" json:"actual,omitempty"`
+	Delayed          bool                   `protobuf:"varint,4,opt,name=delayed,proto3" json:"delayed,omitempty"`
+	EstimateUnknown  bool                   `protobuf:"varint,5,opt,name=estimate_unknown,json=estimateUnknown,proto3" json:"estimate_unknown,omitempty"`
+	Source           string                 `protobuf:"bytes,6,opt,name=source,proto3" json:"source,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *TimeEstimate) Reset() {
+	*x = TimeEstimate{}
+	mi := &file_pushport_v1_pushport_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TimeEstimate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TimeEstimate) ProtoMessage() {}
+
+func (x *TimeEstimate) ProtoReflect() protoreflect.Message {
+	mi := &file_pushport_v1_pushport_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TimeEstimate.ProtoReflect.Descriptor instead.
+func (*TimeEstimate) Descriptor() ([]byte, []int) {
+	return file_pushport_v1_pushport_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *TimeEstimate) GetEstimated() string {
+	if x != nil {
+		return x.Estimated
+	}
+	return ""
+}
+
+func (x *TimeEstimate) GetWorkingEstimated() string {
+	if x != nil {
+		return x.WorkingEstimated
+	}
+	return ""
+}
+
+func (x *TimeEstimate) GetActual() string {
+	if x != nil {
+		return x.Actual
+	}
+	return ""
+}
+
+func (x *TimeEstimate) GetDelayed() bool {
+	if x != nil {
+		return x.Delayed
+	}
+	return false
+}
+
+func (x *TimeEstimate) GetEstimateUnknown() bool {
+	if x != nil {
+		return x.EstimateUnknown
+	}
+	return false
+}
+
+func (x *TimeEstimate) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+type ServiceAssociation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Category      string                 `protobuf:"bytes,1,opt,name=category,proto3" json:"category,omitempty"`
+	Tiploc        string                 `protobuf:"bytes,2,opt,name=tiploc,proto3" json:"tiploc,omitempty"`
+	MainRid       string                 `protobuf:"bytes,3,opt,name=main_rid,json=mainRid,proto3" json:"main_rid,omitempty"`
+	AssocRid      string                 `protobuf:"bytes,4,opt,name=assoc_rid,json=assocRid,proto3" json:"assoc_rid,omitempty"`
+	IsCancelled   bool                   `protobuf:"varint,5,opt,name=is_cancelled,json=isCancelled,proto3" json:"is_cancelled,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServiceAssociation) Reset() {
+	*x = ServiceAssociation{}
+	mi := &file_pushport_v1_pushport_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServiceAssociation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServiceAssociation) ProtoMessage() {}
+
+func (x *ServiceAssociation) ProtoReflect() protoreflect.Message {
+	mi := &file_pushport_v1_pushport_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServiceAssociation.ProtoReflect.Descriptor instead.
+func (*ServiceAssociation) Descriptor() ([]byte, []int) {
+	return file_pushport_v1_pushport_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ServiceAssociation) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *ServiceAssociation) GetTiploc() string {
+	if x != nil {
+		return x.Tiploc
+	}
+	return ""
+}
+
+func (x *ServiceAssociation) GetMainRid() string {
+	if x != nil {
+		return x.MainRid
+	}
+	return ""
+}
+
+func (x *ServiceAssociation) GetAssocRid() string {
+	if x != nil {
+		return x.AssocRid
+	}
+	return ""
+}
+
+func (x *ServiceAssociation) GetIsCancelled() bool {
+	if x != nil {
+		return x.IsCancelled
+	}
+	return false
+}
+
+var File_pushport_v1_pushport_proto protoreflect.FileDescriptor
+
+const file_pushport_v1_pushport_proto_rawDesc = "" +
+	"\n" +
+	"\x1apushport/v1/pushport.proto\x12\vpushport.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x93\x01\n" +
+	"\x06Filter\x12\x14\n" +
+	"\x05types\x18\x01 \x03(\tR\x05types\x12\x12\n" +
+	"\x04tocs\x18\x02 \x03(\tR\x04tocs\x12\x10\n" +
+	"\x03crs\x18\x03 \x03(\tR\x03crs\x12\x18\n" +
+	"\atiplocs\x18\x04 \x03(\tR\atiplocs\x12\x12\n" +
+	"\x04rids\x18\x05 \x03(\tR\x04rids\x12\x1f\n" +
+	"\vinclude_xml\x18\x06 \x01(\bR\n" +
+	"includeXml\"?\n" +
+	"\x10SubscribeRequest\x12+\n" +
+	"\x06filter\x18\x01 \x01(\v2\x13.pushport.v1.FilterR\x06filter\"%\n" +
+	"\x11GetServiceRequest\x12\x10\n" +
+	"\x03rid\x18\x01 \x01(\tR\x03rid\"\x9d\x01\n" +
+	"\x12ReplayRangeRequest\x12.\n" +
+	"\x04from\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x12+\n" +
+	"\x06filter\x18\x03 \x01(\v2\x13.pushport.v1.FilterR\x06filter\"\xec\x04\n" +
+	"\aMessage\x12.\n" +
+	"\x04time\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12;\n" +
+	"\vreceived_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"receivedAt\x12\x14\n" +
+	"\x05types\x18\x03 \x03(\tR\x05types\x12\x12\n" +
+	"\x04rids\x18\x04 \x03(\tR\x04rids\x12#\n" +
+	"\rupdate_origin\x18\x05 \x01(\tR\fupdateOrigin\x12%\n" +
+	"\x0erequest_source\x18\x06 \x01(\tR\rrequestSource\x12\x1a\n" +
+	"\bsnapshot\x18\a \x01(\bR\bsnapshot\x123\n" +
+	"\tschedules\x18\b \x03(\v2\x15.pushport.v1.ScheduleR\tschedules\x12:\n" +
+	"\vdeactivated\x18\t \x03(\v2\x18.pushport.v1.DeactivatedR\vdeactivated\x12<\n" +
+	"\fassociations\x18\n" +
+	" \x03(\v2\x18.pushport.v1.AssociationR\fassociations\x12?\n" +
+	"\x0etrain_statuses\x18\v \x03(\v2\x18.pushport.v1.TrainStatusR\rtrainStatuses\x12F\n" +
+	"\x10station_messages\x18\f \x03(\v2\x1b.pushport.v1.StationMessageR\x0fstationMessages\x12\x10\n" +
+	"\x03xml\x18\r \x01(\tR\x03xml\x12\x18\n" +
+	"\adropped\x18\x0e \x01(\x05R\adropped\"H\n" +
+	"\x06Reason\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x16\n" +
+	"\x06tiploc\x18\x02 \x01(\tR\x06tiploc\x12\x12\n" +
+	"\x04near\x18\x03 \x01(\bR\x04near\"\x90\x04\n" +
+	"\bSchedule\x12\x10\n" +
+	"\x03rid\x18\x01 \x01(\tR\x03rid\x12\x10\n" +
+	"\x03uid\x18\x02 \x01(\tR\x03uid\x12\x19\n" +
+	"\btrain_id\x18\x03 \x01(\tR\atrainId\x12\x12\n" +
+	"\x04rsid\x18\x04 \x01(\tR\x04rsid\x12\x10\n" +
+	"\x03ssd\x18\x05 \x01(\tR\x03ssd\x12\x10\n" +
+	"\x03toc\x18\x06 \x01(\tR\x03toc\x12\x16\n" +
+	"\x06status\x18\a \x01(\tR\x06status\x12\x1b\n" +
+	"\ttrain_cat\x18\b \x01(\tR\btrainCat\x12(\n" +
+	"\x10is_passenger_svc\x18\t \x01(\bR\x0eisPassengerSvc\x12\x1b\n" +
+	"\tis_active\x18\n" +
+	" \x01(\bR\bisActive\x12\x18\n" +
+	"\adeleted\x18\v \x01(\bR\adeleted\x12\x1d\n" +
+	"\n" +
+	"is_charter\x18\f \x01(\bR\tisCharter\x12;\n" +
+	"\tlocations\x18\r \x03(\v2\x1d.pushport.v1.ScheduleLocationR\tlocations\x128\n" +
+	"\rcancel_reason\x18\x0e \x01(\v2\x13.pushport.v1.ReasonR\fcancelReason\x12!\n" +
+	"\fdiverted_via\x18\x0f \x01(\tR\vdivertedVia\x12>\n" +
+	"\x10diversion_reason\x18\x10 \x01(\v2\x13.pushport.v1.ReasonR\x0fdiversionReason\"\xb3\x03\n" +
+	"\x10ScheduleLocation\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x16\n" +
+	"\x06tiploc\x18\x02 \x01(\tR\x06tiploc\x12\x1e\n" +
+	"\n" +
+	"activities\x18\x03 \x01(\tR\n" +
+	"activities\x12-\n" +
+	"\x12planned_activities\x18\x04 \x01(\tR\x11plannedActivities\x12\x1c\n" +
+	"\tcancelled\x18\x05 \x01(\bR\tcancelled\x12!\n" +
+	"\fformation_id\x18\x06 \x01(\tR\vformationId\x12\x1f\n" +
+	"\vaffected_by\x18\a \x01(\tR\n" +
+	"affectedBy\x12\x1a\n" +
+	"\bplatform\x18\b \x01(\tR\bplatform\x12\x10\n" +
+	"\x03pta\x18\t \x01(\tR\x03pta\x12\x10\n" +
+	"\x03ptd\x18\n" +
+	" \x01(\tR\x03ptd\x12\x10\n" +
+	"\x03wta\x18\v \x01(\tR\x03wta\x12\x10\n" +
+	"\x03wtd\x18\f \x01(\tR\x03wtd\x12\x10\n" +
+	"\x03wtp\x18\r \x01(\tR\x03wtp\x12+\n" +
+	"\x11false_destination\x18\x0e \x01(\tR\x10falseDestination\x12\x1f\n" +
+	"\vroute_delay\x18\x0f \x01(\tR\n" +
+	"routeDelay\"\x1f\n" +
+	"\vDeactivated\x12\x10\n" +
+	"\x03rid\x18\x01 \x01(\tR\x03rid\"\x80\x01\n" +
+	"\x12AssociationService\x12\x10\n" +
+	"\x03rid\x18\x01 \x01(\tR\x03rid\x12\x10\n" +
+	"\x03wta\x18\x02 \x01(\tR\x03wta\x12\x10\n" +
+	"\x03wtd\x18\x03 \x01(\tR\x03wtd\x12\x10\n" +
+	"\x03wtp\x18\x04 \x01(\tR\x03wtp\x12\x10\n" +
+	"\x03pta\x18\x05 \x01(\tR\x03pta\x12\x10\n" +
+	"\x03ptd\x18\x06 \x01(\tR\x03ptd\"\xef\x01\n" +
+	"\vAssociation\x12\x16\n" +
+	"\x06tiploc\x18\x01 \x01(\tR\x06tiploc\x12\x1a\n" +
+	"\bcategory\x18\x02 \x01(\tR\bcategory\x12!\n" +
+	"\fis_cancelled\x18\x03 \x01(\bR\visCancelled\x12\x1d\n" +
+	"\n" +
+	"is_deleted\x18\x04 \x01(\bR\tisDeleted\x123\n" +
+	"\x04main\x18\x05 \x01(\v2\x1f.pushport.v1.AssociationServiceR\x04main\x125\n" +
+	"\x05assoc\x18\x06 \x01(\v2\x1f.pushport.v1.AssociationServiceR\x05assoc\"\xe2\x01\n" +
+	"\vTrainStatus\x12\x10\n" +
+	"\x03rid\x18\x01 \x01(\tR\x03rid\x12\x10\n" +
+	"\x03uid\x18\x02 \x01(\tR\x03uid\x12\x10\n" +
+	"\x03ssd\x18\x03 \x01(\tR\x03ssd\x120\n" +
+	"\x14is_reverse_formation\x18\x04 \x01(\bR\x12isReverseFormation\x124\n" +
+	"\vlate_reason\x18\x05 \x01(\v2\x13.pushport.v1.ReasonR\n" +
+	"lateReason\x125\n" +
+	"\tlocations\x18\x06 \x03(\v2\x17.pushport.v1.TSLocationR\tlocations\"\x9d\x03\n" +
+	"\n" +
+	"TSLocation\x12\x16\n" +
+	"\x06tiploc\x18\x01 \x01(\tR\x06tiploc\x12\x10\n" +
+	"\x03pta\x18\x02 \x01(\tR\x03pta\x12\x10\n" +
+	"\x03ptd\x18\x03 \x01(\tR\x03ptd\x12\x10\n" +
+	"\x03wta\x18\x04 \x01(\tR\x03wta\x12\x10\n" +
+	"\x03wtd\x18\x05 \x01(\tR\x03wtd\x12\x10\n" +
+	"\x03wtp\x18\x06 \x01(\tR\x03wtp\x12/\n" +
+	"\aarrival\x18\a \x01(\v2\x15.pushport.v1.TimeDataR\aarrival\x123\n" +
+	"\tdeparture\x18\b \x01(\v2\x15.pushport.v1.TimeDataR\tdeparture\x12)\n" +
+	"\x04pass\x18\t \x01(\v2\x15.pushport.v1.TimeDataR\x04pass\x121\n" +
+	"\bplatform\x18\n" +
+	" \x01(\v2\x15.pushport.v1.PlatformR\bplatform\x12\x1e\n" +
+	"\n" +
+	"suppressed\x18\v \x01(\bR\n" +
+	"suppressed\x12\x16\n" +
+	"\x06length\x18\f \x01(\tR\x06length\x12!\n" +
+	"\fdetach_front\x18\r \x01(\bR\vdetachFront\"\xea\x02\n" +
+	"\bTimeData\x12\x1c\n" +
+	"\testimated\x18\x01 \x01(\tR\testimated\x12+\n" +
+	"\x11working_estimated\x18\x02 \x01(\tR\x10workingEstimated\x12\x16\n" +
+	"\x06actual\x18\x03 \x01(\tR\x06actual\x12%\n" +
+	"\x0eactual_removed\x18\x04 \x01(\bR\ractualRemoved\x12!\n" +
+	"\factual_class\x18\x05 \x01(\tR\vactualClass\x12+\n" +
+	"\x11estimated_minimum\x18\x06 \x01(\tR\x10estimatedMinimum\x12)\n" +
+	"\x10estimate_unknown\x18\a \x01(\bR\x0festimateUnknown\x12\x18\n" +
+	"\adelayed\x18\b \x01(\bR\adelayed\x12\x16\n" +
+	"\x06source\x18\t \x01(\tR\x06source\x12'\n" +
+	"\x0fsource_instance\x18\n" +
+	" \x01(\tR\x0esourceInstance\"\x9f\x01\n" +
+	"\bPlatform\x12\x16\n" +
+	"\x06number\x18\x01 \x01(\tR\x06number\x12\x1e\n" +
+	"\n" +
+	"suppressed\x18\x02 \x01(\bR\n" +
+	"suppressed\x12%\n" +
+	"\x0ecis_suppressed\x18\x03 \x01(\bR\rcisSuppressed\x12\x16\n" +
+	"\x06source\x18\x04 \x01(\tR\x06source\x12\x1c\n" +
+	"\tconfirmed\x18\x05 \x01(\bR\tconfirmed\"\xb8\x01\n" +
+	"\x0eStationMessage\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\bcategory\x18\x02 \x01(\tR\bcategory\x12\x1a\n" +
+	"\bseverity\x18\x03 \x01(\tR\bseverity\x12\x1a\n" +
+	"\bsuppress\x18\x04 \x01(\bR\bsuppress\x12\x1a\n" +
+	"\bstations\x18\x05 \x03(\tR\bstations\x12\x12\n" +
+	"\x04text\x18\x06 \x01(\tR\x04text\x12\x12\n" +
+	"\x04html\x18\a \x01(\tR\x04html\"\xcc\x06\n" +
+	"\aService\x12\x10\n" +
+	"\x03rid\x18\x01 \x01(\tR\x03rid\x12\x10\n" +
+	"\x03uid\x18\x02 \x01(\tR\x03uid\x12\x19\n" +
+	"\btrain_id\x18\x03 \x01(\tR\atrainId\x12\x12\n" +
+	"\x04rsid\x18\x04 \x01(\tR\x04rsid\x12\x10\n" +
+	"\x03ssd\x18\x05 \x01(\tR\x03ssd\x12\x10\n" +
+	"\x03toc\x18\x06 \x01(\tR\x03toc\x12\x16\n" +
+	"\x06status\x18\a \x01(\tR\x06status\x12\x1b\n" +
+	"\ttrain_cat\x18\b \x01(\tR\btrainCat\x12(\n" +
+	"\x10is_passenger_svc\x18\t \x01(\bR\x0eisPassengerSvc\x12\x1b\n" +
+	"\tis_active\x18\n" +
+	" \x01(\bR\bisActive\x12\x1d\n" +
+	"\n" +
+	"is_charter\x18\v \x01(\bR\tisCharter\x12\x1d\n" +
+	"\n" +
+	"is_deleted\x18\f \x01(\bR\tisDeleted\x12!\n" +
+	"\fis_cancelled\x18\r \x01(\bR\visCancelled\x12 \n" +
+	"\vdeactivated\x18\x0e \x01(\bR\vdeactivated\x12!\n" +
+	"\fhas_schedule\x18\x0f \x01(\bR\vhasSchedule\x12'\n" +
+	"\x0fschedule_source\x18\x10 \x01(\tR\x0escheduleSource\x120\n" +
+	"\x14is_reverse_formation\x18\x11 \x01(\bR\x12isReverseFormation\x128\n" +
+	"\rcancel_reason\x18\x12 \x01(\v2\x13.pushport.v1.ReasonR\fcancelReason\x124\n" +
+	"\vlate_reason\x18\x13 \x01(\v2\x13.pushport.v1.ReasonR\n" +
+	"lateReason\x12!\n" +
+	"\fdiverted_via\x18\x14 \x01(\tR\vdivertedVia\x12:\n" +
+	"\tlocations\x18\x15 \x03(\v2\x1c.pushport.v1.ServiceLocationR\tlocations\x12C\n" +
+	"\fassociations\x18\x16 \x03(\v2\x1f.pushport.v1.ServiceAssociationR\fassociations\x129\n" +
+	"\n" +
+	"updated_at\x18\x17 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\xce\x05\n" +
+	"\x0fServiceLocation\x12\x16\n" +
+	"\x06tiploc\x18\x01 \x01(\tR\x06tiploc\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x1e\n" +
+	"\n" +
+	"activities\x18\x03 \x01(\tR\n" +
+	"activities\x12-\n" +
+	"\x12planned_activities\x18\x04 \x01(\tR\x11plannedActivities\x12\x1c\n" +
+	"\tcancelled\x18\x05 \x01(\bR\tcancelled\x12+\n" +
+	"\x11false_destination\x18\x06 \x01(\tR\x10falseDestination\x12\x10\n" +
+	"\x03pta\x18\a \x01(\tR\x03pta\x12\x10\n" +
+	"\x03ptd\x18\b \x01(\tR\x03ptd\x12\x10\n" +
+	"\x03wta\x18\t \x01(\tR\x03wta\x12\x10\n" +
+	"\x03wtd\x18\n" +
+	" \x01(\tR\x03wtd\x12\x10\n" +
+	"\x03wtp\x18\v \x01(\tR\x03wtp\x123\n" +
+	"\aarrival\x18\f \x01(\v2\x19.pushport.v1.TimeEstimateR\aarrival\x127\n" +
+	"\tdeparture\x18\r \x01(\v2\x19.pushport.v1.TimeEstimateR\tdeparture\x12-\n" +
+	"\x04pass\x18\x0e \x01(\v2\x19.pushport.v1.TimeEstimateR\x04pass\x12\x1a\n" +
+	"\bplatform\x18\x0f \x01(\tR\bplatform\x12/\n" +
+	"\x13platform_suppressed\x18\x10 \x01(\bR\x12platformSuppressed\x12-\n" +
+	"\x12platform_confirmed\x18\x11 \x01(\bR\x11platformConfirmed\x12'\n" +
+	"\x0fplatform_source\x18\x12 \x01(\tR\x0eplatformSource\x12\x1e\n" +
+	"\n" +
+	"suppressed\x18\x13 \x01(\bR\n" +
+	"suppressed\x12\x16\n" +
+	"\x06length\x18\x14 \x01(\tR\x06length\x12!\n" +
+	"\fdetach_front\x18\x15 \x01(\bR\vdetachFront\"\xce\x01\n" +
+	"\fTimeEstimate\x12\x1c\n" +
+	"\testimated\x18\x01 \x01(\tR\testimated\x12+\n" +
+	"\x11working_estimated\x18\x02 \x01(\tR\x10workingEstimated\x12\x16\n" +
+	"\x06actual\x18\x03 \x01(\tR\x06actual\x12\x18\n" +
+	"\adelayed\x18\x04 \x01(\bR\adelayed\x12)\n" +
+	"\x10estimate_unknown\x18\x05 \x01(\bR\x0festimateUnknown\x12\x16\n" +
+	"\x06source\x18\x06 \x01(\tR\x06source\"\xa3\x01\n" +
+	"\x12ServiceAssociation\x12\x1a\n" +
+	"\bcategory\x18\x01 \x01(\tR\bcategory\x12\x16\n" +
+	"\x06tiploc\x18\x02 \x01(\tR\x06tiploc\x12\x19\n" +
+	"\bmain_rid\x18\x03 \x01(\tR\amainRid\x12\x1b\n" +
+	"\tassoc_rid\x18\x04 \x01(\tR\bassocRid\x12!\n" +
+	"\fis_cancelled\x18\x05 \x01(\bR\visCancelled2\xda\x01\n" +
+	"\bPushPort\x12B\n" +
+	"\tSubscribe\x12\x1d.pushport.v1.SubscribeRequest\x1a\x14.pushport.v1.Message0\x01\x12B\n" +
+	"\n" +
+	"GetService\x12\x1e.pushport.v1.GetServiceRequest\x1a\x14.pushport.v1.Service\x12F\n" +
+	"\vReplayRange\x12\x1f.pushport.v1.ReplayRangeRequest\x1a\x14.pushport.v1.Message0\x01B0Z.gemini-push-port/grpcapi/pushportpb;pushportpbb\x06proto3"
+
+var (
+	file_pushport_v1_pushport_proto_rawDescOnce sync.Once
+	file_pushport_v1_pushport_proto_rawDescData []byte
+)
+
+func file_pushport_v1_pushport_proto_rawDescGZIP() []byte {
+	file_pushport_v1_pushport_proto_rawDescOnce.Do(func() {
+		file_pushport_v1_pushport_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pushport_v1_pushport_proto_rawDesc), len(file_pushport_v1_pushport_proto_rawDesc)))
+	})
+	return file_pushport_v1_pushport_proto_rawDescData
+}
+
+var file_pushport_v1_pushport_proto_msgTypes = make([]protoimpl.MessageInfo, 20)
+var file_pushport_v1_pushport_proto_goTypes = []any{
+	(*Filter)(nil),                // 0: pushport.v1.Filter
+	(*SubscribeRequest)(nil),      // 1: pushport.v1.SubscribeRequest
+	(*GetServiceRequest)(nil),     // 2: pushport.v1.GetServiceRequest
+	(*ReplayRangeRequest)(nil),    // 3: pushport.v1.ReplayRangeRequest
+	(*Message)(nil),               // 4: pushport.v1.Message
+	(*Reason)(nil),                // 5: pushport.v1.Reason
+	(*Schedule)(nil),              // 6: pushport.v1.Schedule
+	(*ScheduleLocation)(nil),      // 7: pushport.v1.ScheduleLocation
+	(*Deactivated)(nil),           // 8: pushport.v1.Deactivated
+	(*AssociationService)(nil),    // 9: pushport.v1.AssociationService
+	(*Association)(nil),           // 10: pushport.v1.Association
+	(*TrainStatus)(nil),           // 11: pushport.v1.TrainStatus
+	(*TSLocation)(nil),            // 12: pushport.v1.TSLocation
+	(*TimeData)(nil),              // 13: pushport.v1.TimeData
+	(*Platform)(nil),              // 14: pushport.v1.Platform
+	(*StationMessage)(nil),        // 15: pushport.v1.StationMessage
+	(*Service)(nil),               // 16: pushport.v1.Service
+	(*ServiceLocation)(nil),       // 17: pushport.v1.ServiceLocation
+	(*TimeEstimate)(nil),          // 18: pushport.v1.TimeEstimate
+	(*ServiceAssociation)(nil),    // 19: pushport.v1.ServiceAssociation
+	(*timestamppb.Timestamp)(nil), // 20: google.protobuf.Timestamp
+}
+var file_pushport_v1_pushport_proto_depIdxs = []int32{
+	0,  // 0: pushport.v1.SubscribeRequest.filter:type_name -> pushport.v1.Filter
+	20, // 1: pushport.v1.ReplayRangeRequest.from:type_name -> google.protobuf.Timestamp
+	20, // 2: pushport.v1.ReplayRangeRequest.to:type_name -> google.protobuf.Timestamp
+	0,  // 3: pushport.v1.ReplayRangeRequest.filter:type_name -> pushport.v1.Filter
+	20, // 4: pushport.v1.Message.time:type_name -> google.protobuf.Timestamp
+	20, // 5: pushport.v1.Message.received_at:type_name -> google.protobuf.Timestamp
+	6,  // 6: pushport.v1.Message.schedules:type_name -> pushport.v1.Schedule
+	8,  // 7: pushport.v1.Message.deactivated:type_name -> pushport.v1.Deactivated
+	10, // 8: pushport.v1.Message.associations:type_name -> pushport.v1.Association
+	11, // 9: pushport.v1.Message.train_statuses:type_name -> pushport.v1.TrainStatus
+	15, // 10: pushport.v1.Message.station_messages:type_name -> pushport.v1.StationMessage
+	7,  // 11: pushport.v1.Schedule.locations:type_name -> pushport.v1.ScheduleLocation
+	5,  // 12: pushport.v1.Schedule.cancel_reason:type_name -> pushport.v1.Reason
+	5,  // 13: pushport.v1.Schedule.diversion_reason:type_name -> pushport.v1.Reason
+	9,  // 14: pushport.v1.Association.main:type_name -> pushport.v1.AssociationService
+	9,  // 15: pushport.v1.Association.assoc:type_name -> pushport.v1.AssociationService
+	5,  // 16: pushport.v1.TrainStatus.late_reason:type_name -> pushport.v1.Reason
+	12, // 17: pushport.v1.TrainStatus.locations:type_name -> pushport.v1.TSLocation
+	13, // 18: pushport.v1.TSLocation.arrival:type_name -> pushport.v1.TimeData
+	13, // 19: pushport.v1.TSLocation.departure:type_name -> pushport.v1.TimeData
+	13, // 20: pushport.v1.TSLocation.pass:type_name -> pushport.v1.TimeData
+	14, // 21: pushport.v1.TSLocation.platform:type_name -> pushport.v1.Platform
+	5,  // 22: pushport.v1.Service.cancel_reason:type_name -> pushport.v1.Reason
+	5,  // 23: pushport.v1.Service.late_reason:type_name -> pushport.v1.Reason
+	17, // 24: pushport.v1.Service.locations:type_name -> pushport.v1.ServiceLocation
+	19, // 25: pushport.v1.Service.associations:type_name -> pushport.v1.ServiceAssociation
+	20, // 26: pushport.v1.Service.updated_at:type_name -> google.protobuf.Timestamp
+	18, // 27: pushport.v1.ServiceLocation.arrival:type_name -> pushport.v1.TimeEstimate
+	18, // 28: pushport.v1.ServiceLocation.departure:type_name -> pushport.v1.TimeEstimate
+	18, // 29: pushport.v1.ServiceLocation.pass:type_name -> pushport.v1.TimeEstimate
+	1,  // 30: pushport.v1.PushPort.Subscribe:input_type -> pushport.v1.SubscribeRequest
+	2,  // 31: pushport.v1.PushPort.GetService:input_type -> pushport.v1.GetServiceRequest
+	3,  // 32: pushport.v1.PushPort.ReplayRange:input_type -> pushport.v1.ReplayRangeRequest
+	4,  // 33: pushport.v1.PushPort.Subscribe:output_type -> pushport.v1.Message
+	16, // 34: pushport.v1.PushPort.GetService:output_type -> pushport.v1.Service
+	4,  // 35: pushport.v1.PushPort.ReplayRange:output_type -> pushport.v1.Message
+	33, // [33:36] is the sub-list for method output_type
+	30, // [30:33] is the sub-list for method input_type
+	30, // [30:30] is the sub-list for extension type_name
+	30, // [30:30] is the sub-list for extension extendee
+	0,  // [0:30] is the sub-list for field type_name
+}
+
+func init() { file_pushport_v1_pushport_proto_init() }
+func file_pushport_v1_pushport_proto_init() {
+	if File_pushport_v1_pushport_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pushport_v1_pushport_proto_rawDesc), len(file_pushport_v1_pushport_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   20,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pushport_v1_pushport_proto_goTypes,
+		DependencyIndexes: file_pushport_v1_pushport_proto_depIdxs,
+		MessageInfos:      file_pushport_v1_pushport_proto_msgTypes,
+	}.Build()
+	File_pushport_v1_pushport_proto = out.File
+	file_pushport_v1_pushport_proto_goTypes = nil
+	file_pushport_v1_pushport_proto_depIdxs = nil
+}
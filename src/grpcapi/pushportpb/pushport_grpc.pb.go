@@ -0,0 +1,215 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v6.32.1
+// source: pushport/v1/pushport.proto
+
+// Parsed Darwin Push Port messages, and the live state of services built from them. Field names follow the Push Port
+// schema's, and times are kept as Darwin's HH:MM strings unless they are timestamps.
+
+package pushportpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	PushPort_Subscribe_FullMethodName   = "/pushport.v1.PushPort/Subscribe"
+	PushPort_GetService_FullMethodName  = "/pushport.v1.PushPort/GetService"
+	PushPort_ReplayRange_FullMethodName = "/pushport.v1.PushPort/ReplayRange"
+)
+
+// PushPortClient is the client API for PushPort service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type PushPortClient interface {
+	// Subscribe streams every message consumed from now on which matches the filter. Messages are dropped for a
+	// subscriber which doesn't keep up, and the next message sent says how many.
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Message], error)
+	// GetService returns the current state of a service, from the live train state or rebuilt from the archives
+	GetService(ctx context.Context, in *GetServiceRequest, opts ...grpc.CallOption) (*Service, error)
+	// ReplayRange streams every archived message between two times which matches the filter, in order
+	ReplayRange(ctx context.Context, in *ReplayRangeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Message], error)
+}
+
+type pushPortClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPushPortClient(cc grpc.ClientConnInterface) PushPortClient {
+	return &pushPortClient{cc}
+}
+
+func (c *pushPortClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Message], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &PushPort_ServiceDesc.Streams[0], PushPort_Subscribe_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeRequest, Message]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PushPort_SubscribeClient = grpc.ServerStreamingClient[Message]
+
+func (c *pushPortClient) GetService(ctx context.Context, in *GetServiceRequest, opts ...grpc.CallOption) (*Service, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Service)
+	err := c.cc.Invoke(ctx, PushPort_GetService_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pushPortClient) ReplayRange(ctx context.Context, in *ReplayRangeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Message], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &PushPort_ServiceDesc.Streams[1], PushPort_ReplayRange_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ReplayRangeRequest, Message]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PushPort_ReplayRangeClient = grpc.ServerStreamingClient[Message]
+
+// PushPortServer is the server API for PushPort service.
+// All implementations must embed UnimplementedPushPortServer
+// for forward compatibility.
+type PushPortServer interface {
+	// Subscribe streams every message consumed from now on which matches the filter. Messages are dropped for a
+	// subscriber which doesn't keep up, and the next message sent says how many.
+	Subscribe(*SubscribeRequest, grpc.ServerStreamingServer[Message]) error
+	// GetService returns the current state of a service, from the live train state or rebuilt from the archives
+	GetService(context.Context, *GetServiceRequest) (*Service, error)
+	// ReplayRange streams every archived message between two times which matches the filter, in order
+	ReplayRange(*ReplayRangeRequest, grpc.ServerStreamingServer[Message]) error
+	mustEmbedUnimplementedPushPortServer()
+}
+
+// UnimplementedPushPortServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPushPortServer struct{}
+
+func (UnimplementedPushPortServer) Subscribe(*SubscribeRequest, grpc.ServerStreamingServer[Message]) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedPushPortServer) GetService(context.Context, *GetServiceRequest) (*Service, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetService not implemented")
+}
+func (UnimplementedPushPortServer) ReplayRange(*ReplayRangeRequest, grpc.ServerStreamingServer[Message]) error {
+	return status.Errorf(codes.Unimplemented, "method ReplayRange not implemented")
+}
+func (UnimplementedPushPortServer) mustEmbedUnimplementedPushPortServer() {}
+func (UnimplementedPushPortServer) testEmbeddedByValue()                  {}
+
+// UnsafePushPortServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PushPortServer will
+// result in compilation errors.
+type UnsafePushPortServer interface {
+	mustEmbedUnimplementedPushPortServer()
+}
+
+func RegisterPushPortServer(s grpc.ServiceRegistrar, srv PushPortServer) {
+	// If the following call pancis, it indicates UnimplementedPushPortServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&PushPort_ServiceDesc, srv)
+}
+
+func _PushPort_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PushPortServer).Subscribe(m, &grpc.GenericServerStream[SubscribeRequest, Message]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PushPort_SubscribeServer = grpc.ServerStreamingServer[Message]
+
+func _PushPort_GetService_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetServiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PushPortServer).GetService(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PushPort_GetService_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PushPortServer).GetService(ctx, req.(*GetServiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PushPort_ReplayRange_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReplayRangeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PushPortServer).ReplayRange(m, &grpc.GenericServerStream[ReplayRangeRequest, Message]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PushPort_ReplayRangeServer = grpc.ServerStreamingServer[Message]
+
+// PushPort_ServiceDesc is the grpc.ServiceDesc for PushPort service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PushPort_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pushport.v1.PushPort",
+	HandlerType: (*PushPortServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetService",
+			Handler:    _PushPort_GetService_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _PushPort_Subscribe_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ReplayRange",
+			Handler:       _PushPort_ReplayRange_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pushport/v1/pushport.proto",
+}
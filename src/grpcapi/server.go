@@ -0,0 +1,300 @@
+// Package grpcapi serves parsed Push Port messages and live train state over gRPC, for consumers which would rather
+// have a typed schema than the JSON and XML the HTTP API gives them.
+package grpcapi
+
+//go:generate protoc --proto_path=../proto --go_out=. --go_opt=module=gemini-push-port/grpcapi --go-grpc_out=. --go-grpc_opt=module=gemini-push-port/grpcapi pushport/v1/pushport.proto
+
+import (
+	"context"
+	"errors"
+	"gemini-push-port/boards"
+	"gemini-push-port/grpcapi/pushportpb"
+	"gemini-push-port/livefeed"
+	"gemini-push-port/logging"
+	"gemini-push-port/pushport"
+	"gemini-push-port/rawstore"
+	"gemini-push-port/servicehistory"
+	"gemini-push-port/trainstate"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultSubscriberBuffer = 256
+	defaultMaxReplayRange   = 24 * time.Hour
+	// messages are archived in the hour they were received, which isn't always the hour of their Darwin timestamp, so
+	// replays also read the hours either side of the range
+	replayPadding = time.Hour
+)
+
+// delivery is a message queued for a subscriber, along with how many were dropped for it before this one
+type delivery struct {
+	msg     *pushport.Message
+	dropped int
+}
+
+type subscriber struct {
+	filter     livefeed.Filter
+	includeXML bool
+	deliveries chan delivery
+	// dropped is only touched while holding the server's lock
+	dropped int
+}
+
+// Server implements the PushPort gRPC service. Like the live feed, each subscriber has a bounded buffer, and messages
+// are dropped for a subscriber whose buffer is full.
+type Server struct {
+	pushportpb.UnimplementedPushPortServer
+
+	engine   *trainstate.Engine
+	lookup   *servicehistory.Lookup
+	archive  *rawstore.ArchiveReader
+	resolver boards.Resolver
+
+	mu               sync.Mutex
+	subscribers      map[*subscriber]struct{}
+	subscriberBuffer int
+	closed           bool
+	maxReplayRange   time.Duration
+
+	addr       string
+	grpcServer *grpc.Server
+}
+
+// NewServerFromEnv creates a server which listens on GRPC_LISTEN_ADDR, buffers GRPC_SUBSCRIBER_BUFFER messages per
+// subscriber (256 by default), and replays at most GRPC_REPLAY_MAX_RANGE at a time (24h by default). The gRPC API is
+// disabled if GRPC_LISTEN_ADDR isn't set.
+func NewServerFromEnv(engine *trainstate.Engine, lookup *servicehistory.Lookup, archive *rawstore.ArchiveReader, resolver boards.Resolver) *Server {
+	subscriberBuffer, err := strconv.Atoi(os.Getenv("GRPC_SUBSCRIBER_BUFFER"))
+	if err != nil || subscriberBuffer < 1 {
+		subscriberBuffer = defaultSubscriberBuffer
+	}
+	maxReplayRange, err := time.ParseDuration(os.Getenv("GRPC_REPLAY_MAX_RANGE"))
+	if err != nil || maxReplayRange <= 0 {
+		maxReplayRange = defaultMaxReplayRange
+	}
+
+	s := &Server{
+		engine:           engine,
+		lookup:           lookup,
+		archive:          archive,
+		resolver:         resolver,
+		subscribers:      make(map[*subscriber]struct{}),
+		subscriberBuffer: subscriberBuffer,
+		maxReplayRange:   maxReplayRange,
+		addr:             os.Getenv("GRPC_LISTEN_ADDR"),
+		grpcServer:       grpc.NewServer(),
+	}
+	pushportpb.RegisterPushPortServer(s.grpcServer, s)
+	return s
+}
+
+// Enabled returns whether GRPC_LISTEN_ADDR is set
+func (s *Server) Enabled() bool {
+	return s.addr != ""
+}
+
+func (s *Server) Thread() {
+	logging.Logger.Infof("Starting gRPC server on %s", s.addr)
+
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		logging.Logger.FatalE("failed to listen for gRPC", err)
+	}
+	err = s.Serve(listener)
+	if err != nil {
+		logging.Logger.FatalE("gRPC server failed", err)
+	}
+}
+
+// Serve serves gRPC requests on the listener until Stop is called
+func (s *Server) Serve(listener net.Listener) error {
+	err := s.grpcServer.Serve(listener)
+	if errors.Is(err, grpc.ErrServerStopped) {
+		return nil
+	}
+	return err
+}
+
+// Stop ends every subscription, then stops the server once in-flight requests have completed, or cancels them when ctx
+// is done
+func (s *Server) Stop(ctx context.Context) {
+	s.mu.Lock()
+	s.closed = true
+	for sub := range s.subscribers {
+		delete(s.subscribers, sub)
+		close(sub.deliveries)
+	}
+	s.mu.Unlock()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+	}
+}
+
+func (s *Server) HandleMessage(msg *pushport.Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.subscribers) == 0 {
+		return
+	}
+
+	attrs := livefeed.MessageAttributes(msg, s.engine)
+	for sub := range s.subscribers {
+		if !sub.filter.Matches(attrs) {
+			continue
+		}
+
+		select {
+		case sub.deliveries <- delivery{msg: msg, dropped: sub.dropped}:
+			sub.dropped = 0
+		default:
+			sub.dropped++
+		}
+	}
+}
+
+func (s *Server) newFilter(f *pushportpb.Filter) livefeed.Filter {
+	return livefeed.NewFilter(f.GetTypes(), f.GetTocs(), f.GetCrs(), f.GetTiplocs(), f.GetRids(), s.resolver)
+}
+
+func (s *Server) subscribe(req *pushportpb.SubscribeRequest) (*subscriber, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, false
+	}
+
+	sub := &subscriber{
+		filter:     s.newFilter(req.GetFilter()),
+		includeXML: req.GetFilter().GetIncludeXml(),
+		deliveries: make(chan delivery, s.subscriberBuffer),
+	}
+	s.subscribers[sub] = struct{}{}
+	logging.Logger.Infof("gRPC subscriber connected, %d connected", len(s.subscribers))
+	return sub, true
+}
+
+func (s *Server) unsubscribe(sub *subscriber) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subscribers[sub]; !ok {
+		return
+	}
+	delete(s.subscribers, sub)
+	close(sub.deliveries)
+	logging.Logger.Infof("gRPC subscriber disconnected, %d connected", len(s.subscribers))
+}
+
+// Subscribe streams live messages matching the filter until the client goes away or the server stops
+func (s *Server) Subscribe(req *pushportpb.SubscribeRequest, stream grpc.ServerStreamingServer[pushportpb.Message]) error {
+	sub, ok := s.subscribe(req)
+	if !ok {
+		return status.Error(codes.Unavailable, "shutting down")
+	}
+	defer s.unsubscribe(sub)
+
+	for {
+		select {
+		case d, ok := <-sub.deliveries:
+			if !ok {
+				return status.Error(codes.Unavailable, "shutting down")
+			}
+			err := stream.Send(toProtoMessage(d.msg, sub.includeXML, d.dropped))
+			if err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// GetService returns the current state of a service, rebuilt from the archive if it's no longer live
+func (s *Server) GetService(ctx context.Context, req *pushportpb.GetServiceRequest) (*pushportpb.Service, error) {
+	detail, err := s.lookup.Get(ctx, req.GetRid(), false)
+	if errors.Is(err, servicehistory.ErrInvalidRID) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if errors.Is(err, servicehistory.ErrServiceNotFound) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		logging.Logger.ErrorE("failed to get service for gRPC", err)
+		return nil, status.Error(codes.Internal, "failed to get service")
+	}
+	return toProtoService(&detail.Service), nil
+}
+
+// ReplayRange streams archived messages matching the filter whose Darwin timestamps are between from and to, in the
+// order they were archived. Hours which were never archived are skipped, and ranges longer than the server's maximum
+// are rejected.
+func (s *Server) ReplayRange(req *pushportpb.ReplayRangeRequest, stream grpc.ServerStreamingServer[pushportpb.Message]) error {
+	if req.GetFrom() == nil || req.GetTo() == nil {
+		return status.Error(codes.InvalidArgument, "from and to are required")
+	}
+	from := req.GetFrom().AsTime()
+	to := req.GetTo().AsTime()
+	if to.Before(from) {
+		return status.Error(codes.InvalidArgument, "to must not be before from")
+	}
+	if to.Sub(from) > s.maxReplayRange {
+		return status.Errorf(codes.InvalidArgument, "range must not be longer than %s", s.maxReplayRange)
+	}
+
+	ctx := stream.Context()
+	filter := s.newFilter(req.GetFilter())
+	includeXML := req.GetFilter().GetIncludeXml()
+
+	for _, hour := range rawstore.HoursBetween(from.Add(-replayPadding), to.Add(replayPadding)) {
+		err := s.archive.ReadHour(ctx, hour, func(raw *rawstore.XmlMessageWithTime) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			msg, err := pushport.NewMessage(raw)
+			if err != nil {
+				return nil
+			}
+			if msg.Time.Before(from) || msg.Time.After(to) {
+				return nil
+			}
+			// archived services may have long left the train state, so only filter on what's in the message itself
+			if !filter.Matches(livefeed.MessageAttributes(msg, nil)) {
+				return nil
+			}
+			return stream.Send(toProtoMessage(msg, includeXML, 0))
+		})
+		if errors.Is(err, rawstore.ErrArchiveNotFound) {
+			continue
+		}
+		if ctx.Err() != nil {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		if err != nil {
+			if _, ok := status.FromError(err); ok {
+				return err
+			}
+			logging.Logger.ErrorE("failed to replay archive for gRPC", err)
+			return status.Error(codes.Internal, "failed to read archive")
+		}
+	}
+	return nil
+}
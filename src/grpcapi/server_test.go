@@ -0,0 +1,280 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"gemini-push-port/boards"
+	"gemini-push-port/grpcapi/pushportpb"
+	"gemini-push-port/internal/testfixtures"
+	"gemini-push-port/refdata"
+	"gemini-push-port/servicehistory"
+	"gemini-push-port/trainstate"
+	"io"
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestMain(m *testing.M) {
+	testfixtures.Main(m, "grpcapi-test")
+}
+
+func newTestClient(t *testing.T) (*Server, pushportpb.PushPortClient) {
+	t.Helper()
+	archive := testfixtures.UseArchive(t)
+	testfixtures.ClearEnv(t, "REFDATA_PATH", "REFDATA_S3_PREFIX", "CRS_TIPLOC_MAP_PATH")
+	t.Setenv("GRPC_REPLAY_MAX_RANGE", "6h")
+
+	// without live state, services are always rebuilt from the archive
+	lookup := servicehistory.NewLookup(nil, nil, archive, refdata.NewStoreFromEnv(nil))
+	s := NewServerFromEnv(trainstate.NewEngine(time.Hour), lookup, archive, boards.NewStaticResolverFromEnv())
+
+	listener := bufconn.Listen(1 << 20)
+	go func() {
+		err := s.Serve(listener)
+		if err != nil {
+			t.Errorf("Serve() error = %v", err)
+		}
+	}()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		s.Stop(ctx)
+	})
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return s, pushportpb.NewPushPortClient(conn)
+}
+
+func utc(hour int, minute int, second int) *timestamppb.Timestamp {
+	return timestamppb.New(time.Date(2025, 9, 19, hour, minute, second, 0, time.UTC))
+}
+
+func receiveAll(t *testing.T, stream grpc.ServerStreamingClient[pushportpb.Message]) ([]*pushportpb.Message, error) {
+	t.Helper()
+	var messages []*pushportpb.Message
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return messages, nil
+		}
+		if err != nil {
+			return messages, err
+		}
+		messages = append(messages, msg)
+	}
+}
+
+func TestGetService(t *testing.T) {
+	_, client := newTestClient(t)
+	ctx := context.Background()
+
+	svc, err := client.GetService(ctx, &pushportpb.GetServiceRequest{Rid: testfixtures.RID1A02})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if svc.GetTrainId() != "1A02" || svc.GetToc() != "SW" || svc.GetSsd() != "2025-09-19" {
+		t.Errorf("service %s %s %s, want 1A02 SW 2025-09-19", svc.GetTrainId(), svc.GetToc(), svc.GetSsd())
+	}
+
+	locations := make(map[string]*pushportpb.ServiceLocation)
+	for _, l := range svc.GetLocations() {
+		locations[l.GetTiploc()] = l
+	}
+	if len(locations) != 5 {
+		t.Fatalf("%d locations, want 5", len(locations))
+	}
+	if got := locations["WOKING"].GetArrival().GetActual(); got != "10:59" {
+		t.Errorf("WOKING actual arrival %q, want 10:59", got)
+	}
+	if got := locations["WOKING"].GetDeparture().GetActual(); got != "11:00" {
+		t.Errorf("WOKING actual departure %q, want 11:00", got)
+	}
+	if got := locations["SOTON"].GetArrival().GetEstimated(); got != "11:48" {
+		t.Errorf("SOTON estimated arrival %q, want 11:48", got)
+	}
+
+	_, err = client.GetService(ctx, &pushportpb.GetServiceRequest{Rid: "202509190000000"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("unknown RID error = %v, want NotFound", err)
+	}
+	_, err = client.GetService(ctx, &pushportpb.GetServiceRequest{Rid: "not a rid"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("invalid RID error = %v, want InvalidArgument", err)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	s, client := newTestClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := client.Subscribe(ctx, &pushportpb.SubscribeRequest{
+		Filter: &pushportpb.Filter{Rids: []string{testfixtures.RID1C10}, Types: []string{"schedule"}, IncludeXml: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the subscription is registered once the server has handled the call
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.mu.Lock()
+		subscribed := len(s.subscribers) == 1
+		s.mu.Unlock()
+		if subscribed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the subscription")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	messages := testfixtures.AllMessages(t)
+	for _, msg := range messages {
+		s.HandleMessage(msg)
+	}
+
+	msg, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(msg.GetRids(), []string{testfixtures.RID1C10}) || !slices.Equal(msg.GetTypes(), []string{"schedule"}) {
+		t.Errorf("message for %v of types %v, want the 1C10 schedule", msg.GetRids(), msg.GetTypes())
+	}
+	if msg.GetXml() != messages[1].Raw.Message {
+		t.Errorf("message XML %q, want %q", msg.GetXml(), messages[1].Raw.Message)
+	}
+	if msg.GetDropped() != 0 {
+		t.Errorf("%d dropped, want 0", msg.GetDropped())
+	}
+	if len(msg.GetSchedules()) != 1 || msg.GetSchedules()[0].GetTrainId() != "1C10" {
+		t.Errorf("schedules %v", msg.GetSchedules())
+	}
+
+	// nothing else matches, so the next message is the one sent after the stream ends
+	cancel()
+	_, err = stream.Recv()
+	if status.Code(err) != codes.Canceled {
+		t.Errorf("after cancelling, error = %v, want Canceled", err)
+	}
+}
+
+func TestReplayRange(t *testing.T) {
+	_, client := newTestClient(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		from      *timestamppb.Timestamp
+		to        *timestamppb.Timestamp
+		filter    *pushportpb.Filter
+		wantTimes []*timestamppb.Timestamp
+	}{
+		{
+			// the last message is archived in the next hour's file
+			name:      "one service within an hour",
+			from:      utc(9, 0, 0),
+			to:        utc(9, 59, 59),
+			filter:    &pushportpb.Filter{Rids: []string{testfixtures.RID1A02}},
+			wantTimes: []*timestamppb.Timestamp{utc(9, 0, 0), utc(9, 31, 10), utc(9, 59, 5)},
+		},
+		{
+			name:      "every message",
+			from:      utc(8, 0, 0),
+			to:        utc(12, 0, 0),
+			wantTimes: []*timestamppb.Timestamp{utc(9, 0, 0), utc(9, 1, 0), utc(9, 2, 0), utc(9, 3, 0), utc(9, 31, 10), utc(9, 45, 0), utc(9, 59, 5)},
+		},
+		{
+			name:      "by type",
+			from:      utc(9, 0, 0),
+			to:        utc(11, 0, 0),
+			filter:    &pushportpb.Filter{Types: []string{"TS"}},
+			wantTimes: []*timestamppb.Timestamp{utc(9, 31, 10), utc(9, 45, 0), utc(9, 59, 5)},
+		},
+		{
+			name:      "bounds are inclusive",
+			from:      utc(9, 31, 10),
+			to:        utc(9, 45, 0),
+			wantTimes: []*timestamppb.Timestamp{utc(9, 31, 10), utc(9, 45, 0)},
+		},
+		{
+			name: "hours which were never archived",
+			from: utc(12, 0, 0),
+			to:   utc(14, 0, 0),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stream, err := client.ReplayRange(ctx, &pushportpb.ReplayRangeRequest{From: test.from, To: test.to, Filter: test.filter})
+			if err != nil {
+				t.Fatal(err)
+			}
+			messages, err := receiveAll(t, stream)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []time.Time
+			for _, msg := range messages {
+				got = append(got, msg.GetTime().AsTime())
+				if msg.GetXml() != "" {
+					t.Errorf("XML included without include_xml")
+				}
+			}
+			var want []time.Time
+			for _, ts := range test.wantTimes {
+				want = append(want, ts.AsTime())
+			}
+			if !slices.EqualFunc(got, want, time.Time.Equal) {
+				t.Errorf("replayed messages at %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestReplayRangeRejectsInvalidRanges(t *testing.T) {
+	_, client := newTestClient(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		from *timestamppb.Timestamp
+		to   *timestamppb.Timestamp
+	}{
+		{name: "missing from", to: utc(10, 0, 0)},
+		{name: "backwards", from: utc(10, 0, 0), to: utc(9, 0, 0)},
+		{name: "longer than the maximum", from: utc(0, 0, 0), to: utc(6, 0, 1)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stream, err := client.ReplayRange(ctx, &pushportpb.ReplayRangeRequest{From: test.from, To: test.to})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = receiveAll(t, stream)
+			if status.Code(err) != codes.InvalidArgument {
+				t.Errorf("error = %v, want InvalidArgument", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package gtfsrt
+
+import (
+	"fmt"
+	"gemini-push-port/darwintime"
+	"gemini-push-port/logging"
+	"gemini-push-port/pushport"
+	"gemini-push-port/refdata"
+	"gemini-push-port/trainstate"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/MobilityData/gtfs-realtime-bindings/golang/gtfs"
+	"google.golang.org/protobuf/proto"
+)
+
+// How Darwin services and locations are matched to the static feed
+const (
+	TripKeyUID = "uid"
+	TripKeyRID = "rid"
+
+	StopKeyTiploc = "tiploc"
+	StopKeyCRS    = "crs"
+)
+
+const (
+	defaultCacheTTL = 30 * time.Second
+	// services whose last call was longer ago than this are left out of the feed
+	finishedServiceGrace = time.Hour
+	// and so are services which don't start within this long
+	upcomingServiceWindow = 12 * time.Hour
+)
+
+// Feed builds GTFS-RT TripUpdates from the live train state, for services which can be matched to a static GTFS feed
+type Feed struct {
+	engine   *trainstate.Engine
+	names    *refdata.Store
+	static   atomic.Pointer[Static]
+	path     string
+	modTime  time.Time
+	tripKey  string
+	stopKey  string
+	tripCol  string
+	stopCol  string
+	cacheTTL time.Duration
+
+	mu      sync.Mutex
+	cached  *gtfs.FeedMessage
+	encoded []byte
+	builtAt time.Time
+}
+
+// NewFeedFromEnv loads the static GTFS zip at GTFS_STATIC_PATH. The feed is disabled (and nil is returned) if it isn't
+// set. Trips are matched by RID or UID (GTFS_TRIP_KEY, defaults to uid) against the trips.txt column in
+// GTFS_TRIP_FIELD (defaults to trip_id), and locations by TIPLOC or CRS (GTFS_STOP_KEY, defaults to tiploc) against
+// the stops.txt column in GTFS_STOP_FIELD (defaults to stop_id). Matching stops by CRS needs reference data.
+func NewFeedFromEnv(engine *trainstate.Engine, names *refdata.Store) (*Feed, error) {
+	path := os.Getenv("GTFS_STATIC_PATH")
+	if path == "" {
+		return nil, nil
+	}
+
+	f := &Feed{
+		engine:   engine,
+		names:    names,
+		path:     path,
+		tripKey:  envOrDefault("GTFS_TRIP_KEY", TripKeyUID),
+		stopKey:  envOrDefault("GTFS_STOP_KEY", StopKeyTiploc),
+		tripCol:  envOrDefault("GTFS_TRIP_FIELD", "trip_id"),
+		stopCol:  envOrDefault("GTFS_STOP_FIELD", "stop_id"),
+		cacheTTL: defaultCacheTTL,
+	}
+
+	if f.tripKey != TripKeyUID && f.tripKey != TripKeyRID {
+		return nil, fmt.Errorf("GTFS_TRIP_KEY must be %s or %s, got %q", TripKeyUID, TripKeyRID, f.tripKey)
+	}
+	if f.stopKey != StopKeyTiploc && f.stopKey != StopKeyCRS {
+		return nil, fmt.Errorf("GTFS_STOP_KEY must be %s or %s, got %q", StopKeyTiploc, StopKeyCRS, f.stopKey)
+	}
+	if f.stopKey == StopKeyCRS && !names.Configured() {
+		return nil, fmt.Errorf("GTFS_STOP_KEY=%s needs reference data to find the CRS code of each TIPLOC", StopKeyCRS)
+	}
+
+	seconds, err := strconv.Atoi(os.Getenv("GTFS_RT_CACHE_SECONDS"))
+	if err == nil && seconds >= 0 {
+		f.cacheTTL = time.Duration(seconds) * time.Second
+	}
+
+	err = f.Reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func envOrDefault(key string, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// Reload loads the static GTFS zip again if it has changed since it was last loaded
+func (f *Feed) Reload() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(f.modTime) {
+		return nil
+	}
+
+	static, err := LoadStatic(f.path, f.tripCol, f.stopCol)
+	if err != nil {
+		return err
+	}
+	f.static.Store(static)
+	f.modTime = info.ModTime()
+
+	trips, stops := static.Counts()
+	logging.Logger.Infof("Loaded static GTFS feed from %s with %d trips and %d stops", f.path, trips, stops)
+	return nil
+}
+
+func ReloadJob(f *Feed) {
+	err := f.Reload()
+	if err != nil {
+		logging.Logger.ErrorE("failed to reload static GTFS feed", err)
+	}
+}
+
+// Current returns the latest feed message and its encoding, building it again once the cached one is older than the
+// cache TTL
+func (f *Feed) Current(now time.Time) (*gtfs.FeedMessage, []byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.cached != nil && now.Sub(f.builtAt) < f.cacheTTL {
+		return f.cached, f.encoded, nil
+	}
+
+	msg := f.Build(now)
+	encoded, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode GTFS-RT feed: %v", err)
+	}
+
+	f.cached = msg
+	f.encoded = encoded
+	f.builtAt = now
+	return msg, encoded, nil
+}
+
+// Build creates a full dataset of TripUpdates for every passenger service running around now which matches a trip in
+// the static feed. Services cancelled outright are CANCELED trips, and cancelled calls are SKIPPED stops.
+func (f *Feed) Build(now time.Time) *gtfs.FeedMessage {
+	static := f.static.Load()
+
+	services := f.engine.Services(func(svc *trainstate.Service) bool {
+		return svc.HasSchedule && svc.IsPassengerSvc && !svc.IsDeleted
+	})
+
+	msg := &gtfs.FeedMessage{
+		Header: &gtfs.FeedHeader{
+			GtfsRealtimeVersion: proto.String("2.0"),
+			Incrementality:      gtfs.FeedHeader_FULL_DATASET.Enum(),
+			Timestamp:           proto.Uint64(uint64(now.Unix())),
+		},
+	}
+
+	for i := range services {
+		entity := f.tripUpdate(static, &services[i], now)
+		if entity != nil {
+			msg.Entity = append(msg.Entity, entity)
+		}
+	}
+
+	return msg
+}
+
+func (f *Feed) tripUpdate(static *Static, svc *trainstate.Service, now time.Time) *gtfs.FeedEntity {
+	ssd, err := darwintime.ParseSSD(svc.SSD)
+	if err != nil {
+		return nil
+	}
+
+	tripKey := svc.UID
+	if f.tripKey == TripKeyRID {
+		tripKey = svc.RID
+	}
+	tripID, ok := static.TripID(tripKey, ssd)
+	if !ok {
+		return nil
+	}
+
+	times := svc.Times()
+	if !inWindow(times, now) {
+		return nil
+	}
+
+	trip := &gtfs.TripDescriptor{
+		TripId:    proto.String(tripID),
+		StartDate: proto.String(ssd.Format(gtfsDateLayout)),
+	}
+	update := &gtfs.TripUpdate{
+		Trip:      trip,
+		Timestamp: proto.Uint64(uint64(svc.UpdatedAt.Unix())),
+	}
+
+	if svc.IsCancelled {
+		trip.ScheduleRelationship = gtfs.TripDescriptor_CANCELED.Enum()
+	} else {
+		trip.ScheduleRelationship = gtfs.TripDescriptor_SCHEDULED.Enum()
+		update.StopTimeUpdate = f.stopTimeUpdates(static, svc, times)
+		if len(update.StopTimeUpdate) == 0 {
+			// nothing to tell consumers beyond what's in the static timetable
+			return nil
+		}
+	}
+
+	return &gtfs.FeedEntity{
+		Id:         proto.String(svc.RID),
+		TripUpdate: update,
+	}
+}
+
+func (f *Feed) stopTimeUpdates(static *Static, svc *trainstate.Service, times []trainstate.LocationTimes) []*gtfs.TripUpdate_StopTimeUpdate {
+	var updates []*gtfs.TripUpdate_StopTimeUpdate
+	for i, loc := range svc.Locations {
+		// GTFS only has the stops passengers can use
+		if loc.Type == pushport.LocationTypePassing || loc.Pta == "" && loc.Ptd == "" {
+			continue
+		}
+
+		stopKey := loc.Tiploc
+		if f.stopKey == StopKeyCRS {
+			stopKey = f.names.CRS(loc.Tiploc)
+		}
+		stopID, ok := static.StopID(stopKey)
+		if !ok {
+			continue
+		}
+
+		update := &gtfs.TripUpdate_StopTimeUpdate{StopId: proto.String(stopID)}
+		if loc.Cancelled {
+			update.ScheduleRelationship = gtfs.TripUpdate_StopTimeUpdate_SKIPPED.Enum()
+			updates = append(updates, update)
+			continue
+		}
+
+		t := times[i]
+		if loc.Pta != "" {
+			update.Arrival = stopTimeEvent(t.ScheduledArrival, t.ExpectedArrival)
+		}
+		if loc.Ptd != "" {
+			update.Departure = stopTimeEvent(t.ScheduledDeparture, t.ExpectedDeparture)
+		}
+		if update.Arrival == nil && update.Departure == nil {
+			continue
+		}
+		update.ScheduleRelationship = gtfs.TripUpdate_StopTimeUpdate_SCHEDULED.Enum()
+		updates = append(updates, update)
+	}
+	return updates
+}
+
+// stopTimeEvent returns the expected (or actual) time of an arrival or departure and its delay, or nil if there's no
+// forecast for it
+func stopTimeEvent(scheduled time.Time, expected time.Time) *gtfs.TripUpdate_StopTimeEvent {
+	if expected.IsZero() {
+		return nil
+	}
+
+	event := &gtfs.TripUpdate_StopTimeEvent{Time: proto.Int64(expected.Unix())}
+	if !scheduled.IsZero() {
+		event.Delay = proto.Int32(int32(expected.Sub(scheduled).Seconds()))
+	}
+	return event
+}
+
+// inWindow returns whether a service is running, about to run, or finished recently
+func inWindow(times []trainstate.LocationTimes, now time.Time) bool {
+	var first, last time.Time
+	for _, t := range times {
+		for _, v := range []time.Time{t.ScheduledArrival, t.ScheduledDeparture, t.ScheduledPass, t.ActualArrival, t.ActualDeparture, t.ExpectedArrival, t.ExpectedDeparture} {
+			if v.IsZero() {
+				continue
+			}
+			if first.IsZero() || v.Before(first) {
+				first = v
+			}
+			if v.After(last) {
+				last = v
+			}
+		}
+	}
+
+	if first.IsZero() {
+		return false
+	}
+	return last.After(now.Add(-finishedServiceGrace)) && first.Before(now.Add(upcomingServiceWindow))
+}
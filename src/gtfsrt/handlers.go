@@ -0,0 +1,45 @@
+package gtfsrt
+
+import (
+	"gemini-push-port/httpapi"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func (f *Feed) RegisterRoutes(server *httpapi.Server) {
+	server.HandleFunc("GET /gtfs-rt/trip-updates", f.tripUpdatesHandler)
+}
+
+// tripUpdatesHandler serves the feed as protobuf, or as JSON for debugging with ?format=json
+func (f *Feed) tripUpdatesHandler(w http.ResponseWriter, r *http.Request) {
+	msg, encoded, err := f.Current(time.Now())
+	if err != nil {
+		httpapi.Logger(r).ErrorE("failed to build GTFS-RT feed", err)
+		httpapi.WriteError(w, r, http.StatusInternalServerError, "failed to build feed")
+		return
+	}
+
+	switch r.URL.Query().Get("format") {
+	case "", "protobuf":
+	case "json":
+		body, err := protojson.MarshalOptions{Indent: "  "}.Marshal(msg)
+		if err != nil {
+			httpapi.Logger(r).ErrorE("failed to encode GTFS-RT feed as JSON", err)
+			httpapi.WriteError(w, r, http.StatusInternalServerError, "failed to build feed")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+		return
+	default:
+		httpapi.WriteError(w, r, http.StatusBadRequest, "format must be protobuf or json")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.Header().Set("Content-Length", strconv.Itoa(len(encoded)))
+	_, _ = w.Write(encoded)
+}
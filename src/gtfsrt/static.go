@@ -0,0 +1,196 @@
+package gtfsrt
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"time"
+)
+
+// Static is the part of a static GTFS feed needed to map Darwin services onto it: trips indexed by the column which
+// holds the Darwin identifier, their calendars, and stops indexed by the column which holds the TIPLOC or CRS
+type Static struct {
+	trips         map[string][]trip
+	calendars     map[string]calendar
+	calendarDates map[string]map[string]bool
+	stops         map[string]string
+}
+
+type trip struct {
+	id        string
+	serviceID string
+}
+
+type calendar struct {
+	days      [7]bool // indexed by time.Weekday
+	startDate string
+	endDate   string
+}
+
+const gtfsDateLayout = "20060102"
+
+// LoadStatic reads a static GTFS zip. tripField is the trips.txt column matched against RIDs or UIDs, and stopField
+// the stops.txt column matched against TIPLOCs or CRS codes.
+func LoadStatic(path string, tripField string, stopField string) (*Static, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer archive.Close()
+
+	s := &Static{
+		trips:         make(map[string][]trip),
+		calendars:     make(map[string]calendar),
+		calendarDates: make(map[string]map[string]bool),
+		stops:         make(map[string]string),
+	}
+
+	err = readTable(&archive.Reader, "trips.txt", true, func(row map[string]string) error {
+		key, ok := row[tripField]
+		if !ok {
+			return fmt.Errorf("trips.txt has no %s column", tripField)
+		}
+		if key != "" {
+			s.trips[key] = append(s.trips[key], trip{id: row["trip_id"], serviceID: row["service_id"]})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = readTable(&archive.Reader, "stops.txt", true, func(row map[string]string) error {
+		key, ok := row[stopField]
+		if !ok {
+			return fmt.Errorf("stops.txt has no %s column", stopField)
+		}
+		key = strings.ToUpper(key)
+		// the first stop wins, so a station's parent stop is used over its platforms when both carry the same code
+		if _, exists := s.stops[key]; key != "" && !exists {
+			s.stops[key] = row["stop_id"]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// a feed needs at least one of calendar.txt or calendar_dates.txt
+	err = readTable(&archive.Reader, "calendar.txt", false, func(row map[string]string) error {
+		c := calendar{startDate: row["start_date"], endDate: row["end_date"]}
+		for day, column := range []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"} {
+			c.days[day] = row[column] == "1"
+		}
+		s.calendars[row["service_id"]] = c
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = readTable(&archive.Reader, "calendar_dates.txt", false, func(row map[string]string) error {
+		dates, ok := s.calendarDates[row["service_id"]]
+		if !ok {
+			dates = make(map[string]bool)
+			s.calendarDates[row["service_id"]] = dates
+		}
+		// exception type 1 adds the service on the date, 2 removes it
+		dates[row["date"]] = row["exception_type"] == "1"
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// readTable calls fn with every row of a CSV file in the zip, keyed by column name
+func readTable(archive *zip.Reader, name string, required bool, fn func(row map[string]string) error) error {
+	f, err := archive.Open(name)
+	if err != nil {
+		if !required && errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to open %s: %v", name, err)
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read header of %s: %v", name, err)
+	}
+	columns := make([]string, len(header))
+	for i, column := range header {
+		columns[i] = strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))
+	}
+
+	row := make(map[string]string, len(columns))
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", name, err)
+		}
+
+		clear(row)
+		for i, value := range record {
+			if i < len(columns) {
+				row[columns[i]] = strings.TrimSpace(value)
+			}
+		}
+
+		err = fn(row)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// TripID returns the GTFS trip for a RID or UID which runs on the service's start date. Where the feed has no
+// calendar for a trip it's assumed to run.
+func (s *Static) TripID(key string, date time.Time) (string, bool) {
+	for _, t := range s.trips[key] {
+		if s.runsOn(t.serviceID, date) {
+			return t.id, true
+		}
+	}
+	return "", false
+}
+
+func (s *Static) runsOn(serviceID string, date time.Time) bool {
+	day := date.Format(gtfsDateLayout)
+
+	if runs, ok := s.calendarDates[serviceID][day]; ok {
+		return runs
+	}
+
+	c, ok := s.calendars[serviceID]
+	if !ok {
+		// services only defined by calendar_dates.txt don't run on dates which aren't listed
+		_, hasDates := s.calendarDates[serviceID]
+		return !hasDates
+	}
+	return c.days[date.Weekday()] && day >= c.startDate && day <= c.endDate
+}
+
+// StopID returns the GTFS stop for a TIPLOC or CRS code
+func (s *Static) StopID(key string) (string, bool) {
+	id, ok := s.stops[strings.ToUpper(key)]
+	return id, ok
+}
+
+// Counts returns the number of trip keys and stops in the feed, for logging
+func (s *Static) Counts() (trips int, stops int) {
+	return len(s.trips), len(s.stops)
+}
@@ -0,0 +1,199 @@
+package health
+
+import (
+	"fmt"
+	"gemini-push-port/pubsub"
+	"gemini-push-port/rawstore"
+	"os"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Checker decides whether the consumer is healthy and ready from the state of the feed, the channels between the
+// consumer and everything downstream of it, the uploads and the workdir's disk
+type Checker struct {
+	startedAt time.Time
+
+	liveMessageAge   time.Duration
+	readyMessageAge  time.Duration
+	uploadAge        time.Duration
+	maxSaturation    float64
+	minFreeDiskBytes uint64
+
+	workdir         string
+	checkUploads    bool
+	mu              sync.Mutex
+	channels        []channel
+	connectionState func() pubsub.ConnectionState
+	lastUpload      func() time.Time
+}
+
+type channel struct {
+	name  string
+	level func() (depth int, capacity int)
+}
+
+// Check is the outcome of one of the checks
+type Check struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail"`
+}
+
+// NewCheckerFromEnv creates a checker with its staleness thresholds from the environment. The consumer isn't ready
+// once no message has been received for HEALTH_READY_MESSAGE_AGE (5m by default), and isn't healthy after
+// HEALTH_LIVE_MESSAGE_AGE (20m). Uploads are stale after HEALTH_MAX_UPLOAD_AGE (10m), channels are saturated above
+// HEALTH_MAX_CHANNEL_SATURATION (0.9) of their capacity, and the disk is full below HEALTH_MIN_FREE_DISK_MB (1024).
+func NewCheckerFromEnv() *Checker {
+	return &Checker{
+		startedAt:        time.Now(),
+		liveMessageAge:   getDurationFromEnv("HEALTH_LIVE_MESSAGE_AGE", 20*time.Minute),
+		readyMessageAge:  getDurationFromEnv("HEALTH_READY_MESSAGE_AGE", 5*time.Minute),
+		uploadAge:        getDurationFromEnv("HEALTH_MAX_UPLOAD_AGE", 10*time.Minute),
+		maxSaturation:    getFloatFromEnv("HEALTH_MAX_CHANNEL_SATURATION", 0.9),
+		minFreeDiskBytes: uint64(getFloatFromEnv("HEALTH_MIN_FREE_DISK_MB", 1024) * 1024 * 1024),
+		workdir:          os.Getenv("PUSH_PORT_DUMP_WORKDIR"),
+		checkUploads:     os.Getenv("S3_COMPATIBLE_BUCKET_NAME") != "",
+		connectionState:  pubsub.State,
+		lastUpload:       rawstore.LastUpload,
+	}
+}
+
+func getDurationFromEnv(name string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(name))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+func getFloatFromEnv(name string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(name), 64)
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// WatchChannel adds a channel whose saturation is checked
+func WatchChannel[T any](c *Checker, name string, ch chan T) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.channels = append(c.channels, channel{
+		name: name,
+		level: func() (int, int) {
+			return len(ch), cap(ch)
+		},
+	})
+}
+
+// Live runs the checks which a restart could fix: that messages are still arriving, and that the channels aren't
+// backed up
+func (c *Checker) Live(now time.Time) []Check {
+	state := c.connectionState()
+	return append([]Check{c.messageAge(state, now, c.liveMessageAge)}, c.channelSaturation()...)
+}
+
+// Ready runs every check, including the ones a restart wouldn't fix, and with a tighter threshold on message age
+func (c *Checker) Ready(now time.Time) []Check {
+	state := c.connectionState()
+	checks := []Check{c.kafka(state, now), c.messageAge(state, now, c.readyMessageAge)}
+	checks = append(checks, c.channelSaturation()...)
+	if c.checkUploads {
+		checks = append(checks, c.upload(now))
+	}
+	if c.workdir != "" {
+		checks = append(checks, c.disk())
+	}
+	return checks
+}
+
+func (c *Checker) kafka(state pubsub.ConnectionState, now time.Time) Check {
+	check := Check{Name: "kafka", OK: state.Connected, Detail: "connected"}
+	switch {
+	case state.Connected:
+	case state.BackoffUntil.After(now):
+		check.Detail = fmt.Sprintf("waiting %v to reconnect after %d failed attempts",
+			state.BackoffUntil.Sub(now).Round(time.Second), state.FailedAttempts)
+	default:
+		check.Detail = "not connected"
+	}
+	return check
+}
+
+// messageAge checks the time since the last message, or since starting up if there hasn't been one yet
+func (c *Checker) messageAge(state pubsub.ConnectionState, now time.Time, threshold time.Duration) Check {
+	if state.LastMessage.IsZero() {
+		age := now.Sub(c.startedAt)
+		return Check{
+			Name:   "lastMessage",
+			OK:     age <= threshold,
+			Detail: fmt.Sprintf("no messages since starting %v ago", age.Round(time.Second)),
+		}
+	}
+
+	age := now.Sub(state.LastMessage)
+	return Check{
+		Name:   "lastMessage",
+		OK:     age <= threshold,
+		Detail: fmt.Sprintf("last message %v ago, at %s", age.Round(time.Second), state.LastMessage.UTC().Format(time.RFC3339)),
+	}
+}
+
+func (c *Checker) channelSaturation() []Check {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	checks := make([]Check, 0, len(c.channels))
+	for _, ch := range c.channels {
+		depth, capacity := ch.level()
+		saturation := 0.0
+		if capacity > 0 {
+			saturation = float64(depth) / float64(capacity)
+		}
+		checks = append(checks, Check{
+			Name:   ch.name + "Channel",
+			OK:     saturation <= c.maxSaturation,
+			Detail: fmt.Sprintf("%d of %d (%.0f%%) full", depth, capacity, saturation*100),
+		})
+	}
+	return checks
+}
+
+// upload checks the time since the last successful upload, or since starting up if there hasn't been one yet
+func (c *Checker) upload(now time.Time) Check {
+	last := c.lastUpload()
+	if last.IsZero() {
+		age := now.Sub(c.startedAt)
+		return Check{
+			Name:   "lastUpload",
+			OK:     age <= c.uploadAge,
+			Detail: fmt.Sprintf("no uploads since starting %v ago", age.Round(time.Second)),
+		}
+	}
+
+	age := now.Sub(last)
+	return Check{
+		Name:   "lastUpload",
+		OK:     age <= c.uploadAge,
+		Detail: fmt.Sprintf("last upload %v ago, at %s", age.Round(time.Second), last.UTC().Format(time.RFC3339)),
+	}
+}
+
+func (c *Checker) disk() Check {
+	var stat syscall.Statfs_t
+	err := syscall.Statfs(c.workdir, &stat)
+	if err != nil {
+		return Check{Name: "disk", Detail: fmt.Sprintf("failed to check free space: %v", err)}
+	}
+
+	free := stat.Bavail * uint64(stat.Bsize)
+	return Check{
+		Name:   "disk",
+		OK:     free >= c.minFreeDiskBytes,
+		Detail: fmt.Sprintf("%d MB free in the workdir", free/1024/1024),
+	}
+}
@@ -0,0 +1,32 @@
+package health
+
+import (
+	"gemini-push-port/httpapi"
+	"net/http"
+	"time"
+)
+
+type response struct {
+	Status string  `json:"status"`
+	Checks []Check `json:"checks"`
+}
+
+func (c *Checker) RegisterRoutes(server *httpapi.Server) {
+	server.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeChecks(w, r, c.Live(time.Now()))
+	})
+	server.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		writeChecks(w, r, c.Ready(time.Now()))
+	})
+}
+
+// writeChecks responds with every check, and a 503 if any of them failed
+func writeChecks(w http.ResponseWriter, r *http.Request, checks []Check) {
+	for _, check := range checks {
+		if !check.OK {
+			httpapi.WriteJSON(w, r, http.StatusServiceUnavailable, response{Status: "fail", Checks: checks})
+			return
+		}
+	}
+	httpapi.WriteJSON(w, r, http.StatusOK, response{Status: "ok", Checks: checks})
+}
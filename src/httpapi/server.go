@@ -0,0 +1,105 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"gemini-push-port/logging"
+	"net/http"
+	"os"
+	"time"
+)
+
+const defaultListenAddr = ":8080"
+
+// Server is the HTTP server shared by every API the service exposes. Components register their routes on it before
+// it is started.
+type Server struct {
+	mux    *http.ServeMux
+	server *http.Server
+}
+
+func NewFromEnv() *Server {
+	addr := os.Getenv("HTTP_LISTEN_ADDR")
+	if addr == "" {
+		addr = defaultListenAddr
+	}
+
+	mux := http.NewServeMux()
+	return &Server{
+		mux: mux,
+		server: &http.Server{
+			Addr:              addr,
+			Handler:           withRequestLogging(mux),
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+	}
+}
+
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+func (s *Server) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	s.mux.HandleFunc(pattern, handler)
+}
+
+func (s *Server) Thread() {
+	logging.Logger.Infof("Starting HTTP server on %s", s.server.Addr)
+
+	err := s.server.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logging.Logger.FatalE("HTTP server failed", err)
+	}
+}
+
+// Shutdown stops accepting new connections, and waits for in-flight requests to complete until ctx is done
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+type loggerKey struct{}
+
+func withRequestLogging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idBytes := make([]byte, 8)
+		_, _ = rand.Read(idBytes)
+		requestID := hex.EncodeToString(idBytes)
+
+		logger := logging.Logger.CloneForID(requestID, r)
+		w.Header().Set("X-Request-ID", requestID)
+
+		start := time.Now()
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), loggerKey{}, logger)))
+		logger.Debugf("%s %s handled in %v", r.Method, r.URL.Path, time.Since(start))
+	})
+}
+
+// Logger returns the logger for a request, tagged with its request ID
+func Logger(r *http.Request) logging.LogInterface {
+	logger, ok := r.Context().Value(loggerKey{}).(logging.LogInterface)
+	if !ok {
+		return logging.Logger
+	}
+	return logger
+}
+
+func WriteJSON(w http.ResponseWriter, r *http.Request, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	err := json.NewEncoder(w).Encode(body)
+	if err != nil {
+		Logger(r).WarnE("failed to write JSON response", err)
+	}
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func WriteError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	WriteJSON(w, r, status, errorResponse{Error: message})
+}
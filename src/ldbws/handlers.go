@@ -0,0 +1,112 @@
+package ldbws
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"gemini-push-port/httpapi"
+	"io"
+	"net/http"
+	"time"
+)
+
+const maxRequestSize = 1 << 20
+
+func (s *Service) RegisterRoutes(server *httpapi.Server) {
+	server.HandleFunc("POST /OpenLDBWS/ldb11.asmx", s.handleSOAP)
+}
+
+// handleSOAP answers a SOAP 1.1 or 1.2 request for one of the supported OpenLDBWS operations
+func (s *Service) handleSOAP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestSize))
+	if err != nil {
+		httpapi.WriteError(w, r, http.StatusBadRequest, "failed to read request")
+		return
+	}
+
+	var envelope requestEnvelope
+	err = xml.Unmarshal(body, &envelope)
+	if err != nil || envelope.XMLName.Space != soap11Namespace && envelope.XMLName.Space != soap12Namespace {
+		httpapi.WriteError(w, r, http.StatusBadRequest, "request must be a SOAP envelope")
+		return
+	}
+	namespace := envelope.XMLName.Space
+
+	writeFault := func(status int, f fault) {
+		err := writeEnvelope(w, status, namespace, f.content(namespace))
+		if err != nil {
+			httpapi.Logger(r).WarnE("failed to write SOAP fault", err)
+		}
+	}
+
+	if !s.authorised(envelope.Header.AccessToken.TokenValue) {
+		writeFault(http.StatusUnauthorized, fault{Client: true, Message: "Unauthorized"})
+		return
+	}
+
+	operation, decoder, err := operationOf(envelope.Body.Content)
+	if err != nil {
+		writeFault(http.StatusBadRequest, fault{Client: true, Message: err.Error()})
+		return
+	}
+
+	now := time.Now()
+	var response any
+	switch operation.Name.Local {
+	case "GetDepartureBoardRequest":
+		var req boardRequest
+		err = decoder.DecodeElement(&req, &operation)
+		if err == nil {
+			var board StationBoard
+			board, err = s.DepartureBoard(&req, now)
+			response = getDepartureBoardResponse{Result: board}
+		}
+	case "GetArrDepBoardWithDetailsRequest":
+		var req boardRequest
+		err = decoder.DecodeElement(&req, &operation)
+		if err == nil {
+			var board StationBoard
+			board, err = s.ArrDepBoardWithDetails(&req, now)
+			response = getArrDepBoardWithDetailsResponse{Result: board}
+		}
+	case "GetServiceDetailsRequest":
+		var req serviceDetailsRequest
+		err = decoder.DecodeElement(&req, &operation)
+		if err == nil {
+			var details ServiceDetails
+			details, err = s.ServiceDetails(&req, now)
+			response = getServiceDetailsResponse{Result: details}
+		}
+	default:
+		writeFault(http.StatusBadRequest, fault{Client: true, Message: "unsupported operation " + operation.Name.Local})
+		return
+	}
+
+	if err != nil {
+		// like OpenLDBWS, unknown service IDs are a fault rather than an empty result
+		writeFault(http.StatusInternalServerError, fault{Client: true, Message: err.Error()})
+		return
+	}
+
+	err = writeEnvelope(w, http.StatusOK, namespace, response)
+	if err != nil {
+		httpapi.Logger(r).WarnE("failed to write SOAP response", err)
+	}
+}
+
+// operationOf returns the first element in a SOAP body, which names the operation, and a decoder positioned inside it
+func operationOf(body []byte) (xml.StartElement, *xml.Decoder, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		tok, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return xml.StartElement{}, nil, errors.New("SOAP body is empty")
+		}
+		if err != nil {
+			return xml.StartElement{}, nil, err
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start, decoder, nil
+		}
+	}
+}
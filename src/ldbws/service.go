@@ -0,0 +1,434 @@
+package ldbws
+
+import (
+	"encoding/base64"
+	"errors"
+	"gemini-push-port/boards"
+	"gemini-push-port/darwintime"
+	"gemini-push-port/pushport"
+	"gemini-push-port/refdata"
+	"gemini-push-port/stationmessages"
+	"gemini-push-port/trainstate"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRows   = 10
+	maxRows       = 150
+	defaultWindow = 120
+	serviceType   = "train"
+)
+
+// errNotFound is returned for service IDs which don't match a service we know about
+var errNotFound = errors.New("unknown service ID")
+
+// Service answers OpenLDBWS operations from the live train state, for clients holding one of the configured tokens
+type Service struct {
+	builder  *boards.Builder
+	engine   *trainstate.Engine
+	names    *refdata.Store
+	messages *stationmessages.Store
+	tokens   map[string]bool
+}
+
+// NewServiceFromEnv creates a service which accepts the comma-separated tokens in LDBWS_TOKENS. The SOAP endpoint is
+// disabled (and nil is returned) if no tokens are set.
+func NewServiceFromEnv(builder *boards.Builder, engine *trainstate.Engine, names *refdata.Store, messages *stationmessages.Store) *Service {
+	tokens := make(map[string]bool)
+	for _, token := range strings.Split(os.Getenv("LDBWS_TOKENS"), ",") {
+		if token = strings.TrimSpace(token); token != "" {
+			tokens[strings.ToLower(token)] = true
+		}
+	}
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	return &Service{
+		builder:  builder,
+		engine:   engine,
+		names:    names,
+		messages: messages,
+		tokens:   tokens,
+	}
+}
+
+// authorised returns whether a token is allowed. Tokens are GUIDs, so are compared case-insensitively.
+func (s *Service) authorised(token string) bool {
+	return s.tokens[strings.ToLower(strings.TrimSpace(token))]
+}
+
+// boardQuery checks a board request's parameters against the limits OpenLDBWS enforces
+func boardQuery(req *boardRequest, boardType boards.BoardType, now time.Time) (boards.Query, error) {
+	if len(req.CRS) != 3 {
+		return boards.Query{}, errors.New("crs must be a 3 letter station code")
+	}
+	if req.NumRows < 0 || req.NumRows > maxRows {
+		return boards.Query{}, errors.New("numRows must be between 0 and 150")
+	}
+	if req.TimeOffset < -120 || req.TimeOffset > 119 {
+		return boards.Query{}, errors.New("timeOffset must be between -120 and 119")
+	}
+	window := defaultWindow
+	if req.TimeWindow != nil {
+		window = *req.TimeWindow
+	}
+	if window < 1 || window > 120 {
+		return boards.Query{}, errors.New("timeWindow must be between 1 and 120")
+	}
+
+	q := boards.Query{
+		CRS:    strings.ToUpper(req.CRS),
+		Type:   boardType,
+		From:   now.Add(time.Duration(req.TimeOffset) * time.Minute).UTC(),
+		Window: time.Duration(window) * time.Minute,
+		Limit:  req.NumRows,
+	}
+	if q.Limit == 0 {
+		q.Limit = defaultRows
+	}
+
+	if req.FilterCRS != "" {
+		switch strings.ToLower(req.FilterType) {
+		case "", "to":
+			q.Destination = req.FilterCRS
+		case "from":
+			q.Origin = req.FilterCRS
+		default:
+			return boards.Query{}, errors.New("filterType must be to or from")
+		}
+	}
+
+	return q, nil
+}
+
+// DepartureBoard answers GetDepartureBoard
+func (s *Service) DepartureBoard(req *boardRequest, now time.Time) (StationBoard, error) {
+	q, err := boardQuery(req, boards.Departures, now)
+	if err != nil {
+		return StationBoard{}, err
+	}
+
+	board := s.builder.Build(q)
+	result := s.stationBoard(req, q, now)
+	for _, entry := range board.Services {
+		item := s.serviceItem(&entry)
+		item.Std = clock(entry.Scheduled)
+		item.Etd = estimateText(&entry)
+		result.add(item)
+	}
+	return result, nil
+}
+
+// ArrDepBoardWithDetails answers GetArrDepBoardWithDetails. A service which both arrives and departs in the window
+// appears once, with its arrival and departure times, and the calling points before and after the station.
+func (s *Service) ArrDepBoardWithDetails(req *boardRequest, now time.Time) (StationBoard, error) {
+	q, err := boardQuery(req, boards.Departures, now)
+	if err != nil {
+		return StationBoard{}, err
+	}
+	q.ExpandCallingPoints = true
+	departures := s.builder.Build(q)
+	q.Type = boards.Arrivals
+	arrivals := s.builder.Build(q)
+
+	type key struct{ rid, tiploc string }
+	items := make(map[key]*ServiceItem)
+	sortTimes := make(map[key]time.Time)
+	var order []key
+
+	for _, entry := range arrivals.Services {
+		k := key{entry.RID, entry.Tiploc}
+		item := s.serviceItem(&entry)
+		item.Sta = clock(entry.Scheduled)
+		item.Eta = estimateText(&entry)
+		item.PreviousCallingPoints = callingPointLists(entry.CallingPoints)
+		items[k] = &item
+		sortTimes[k] = entry.Scheduled
+		order = append(order, k)
+	}
+	for _, entry := range departures.Services {
+		k := key{entry.RID, entry.Tiploc}
+		item, ok := items[k]
+		if !ok {
+			created := s.serviceItem(&entry)
+			item = &created
+			items[k] = item
+			order = append(order, k)
+		}
+		item.Std = clock(entry.Scheduled)
+		item.Etd = estimateText(&entry)
+		item.SubsequentCallingPoints = callingPointLists(entry.CallingPoints)
+		// services are listed by when they leave, or arrive if they terminate here
+		sortTimes[k] = entry.Scheduled
+	}
+
+	slices.SortStableFunc(order, func(a, b key) int {
+		return sortTimes[a].Compare(sortTimes[b])
+	})
+	if len(order) > q.Limit {
+		order = order[:q.Limit]
+	}
+
+	result := s.stationBoard(req, q, now)
+	for _, k := range order {
+		result.add(*items[k])
+	}
+	return result, nil
+}
+
+func (s *Service) stationBoard(req *boardRequest, q boards.Query, now time.Time) StationBoard {
+	board := StationBoard{
+		GeneratedAt:  now.In(darwintime.UK).Format(time.RFC3339Nano),
+		LocationName: s.names.StationName(q.CRS),
+		CRS:          q.CRS,
+	}
+
+	if req.FilterCRS != "" {
+		board.FilterLocationName = s.names.StationName(req.FilterCRS)
+		board.FilterCRS = strings.ToUpper(req.FilterCRS)
+		board.FilterType = "to"
+		if q.Origin != "" {
+			board.FilterType = "from"
+		}
+	}
+
+	var texts []string
+	for _, msg := range s.messages.ForStation(q.CRS) {
+		if !msg.Suppress {
+			texts = append(texts, msg.Text)
+		}
+	}
+	if len(texts) > 0 {
+		board.NRCCMessages = &NRCCMessages{Messages: texts}
+	}
+
+	return board
+}
+
+func (b *StationBoard) add(item ServiceItem) {
+	if b.TrainServices == nil {
+		b.TrainServices = &TrainServices{}
+	}
+	b.TrainServices.Services = append(b.TrainServices.Services, item)
+	if item.Platform != "" {
+		b.PlatformAvailable = true
+	}
+}
+
+func (s *Service) serviceItem(entry *boards.BoardService) ServiceItem {
+	item := ServiceItem{
+		Platform:     entry.Platform,
+		Operator:     entry.OperatorName,
+		OperatorCode: entry.TOC,
+		IsCancelled:  entry.IsCancelled,
+		ServiceType:  serviceType,
+		Length:       lengthText(entry.Length),
+		ServiceID:    encodeServiceID(entry.RID, entry.Tiploc),
+		RSID:         entry.RSID,
+		Origin: &ServiceLocations{Locations: []ServiceLocation{{
+			LocationName: entry.OriginName,
+			CRS:          s.names.CRS(entry.Origin),
+		}}},
+		Destination: &ServiceLocations{Locations: []ServiceLocation{{
+			LocationName: entry.DestinationName,
+			CRS:          s.names.CRS(entry.Destination),
+			Via:          entry.Via,
+		}}},
+	}
+	if entry.IsCancelled {
+		item.CancelReason = entry.CancelReasonText
+	} else {
+		item.DelayReason = entry.LateReasonText
+	}
+	return item
+}
+
+func callingPointLists(points []boards.CallingPoint) *CallingPointLists {
+	if len(points) == 0 {
+		return nil
+	}
+
+	list := CallingPointList{ServiceType: serviceType}
+	for _, p := range points {
+		cp := CallingPoint{
+			LocationName: p.Name,
+			CRS:          p.CRS,
+			St:           clock(p.Scheduled),
+			IsCancelled:  p.IsCancelled,
+		}
+		switch {
+		case p.IsCancelled:
+			cp.Et = "Cancelled"
+		case p.Actual != nil:
+			cp.At = relativeText(p.Scheduled, *p.Actual)
+		case p.Expected != nil:
+			cp.Et = relativeText(p.Scheduled, *p.Expected)
+		default:
+			cp.Et = "On time"
+		}
+		list.CallingPoints = append(list.CallingPoints, cp)
+	}
+	return &CallingPointLists{Lists: []CallingPointList{list}}
+}
+
+// estimateText is what OpenLDBWS shows in place of an expected time: "Cancelled", "Delayed" when Darwin doesn't know
+// how late the train will be, "On time", or the expected (or actual) time
+func estimateText(entry *boards.BoardService) string {
+	switch {
+	case entry.IsCancelled:
+		return "Cancelled"
+	case entry.Actual != nil:
+		return relativeText(entry.Scheduled, *entry.Actual)
+	case entry.Status == boards.StatusDelayed:
+		return "Delayed"
+	case entry.Expected != nil:
+		return relativeText(entry.Scheduled, *entry.Expected)
+	default:
+		return "On time"
+	}
+}
+
+func relativeText(scheduled time.Time, t time.Time) string {
+	if clock(t) == clock(scheduled) {
+		return "On time"
+	}
+	return clock(t)
+}
+
+func clock(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(darwintime.UK).Format("15:04")
+}
+
+func lengthText(length string) string {
+	if length == "0" {
+		return ""
+	}
+	return length
+}
+
+// Service IDs identify a service at the station it was listed for, so GetServiceDetails knows which of its calls the
+// times are for
+func encodeServiceID(rid string, tiploc string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(rid + "/" + tiploc))
+}
+
+func decodeServiceID(id string) (rid string, tiploc string) {
+	decoded, err := base64.RawURLEncoding.DecodeString(id)
+	if err != nil {
+		return id, ""
+	}
+	rid, tiploc, _ = strings.Cut(string(decoded), "/")
+	return rid, tiploc
+}
+
+// ServiceDetails answers GetServiceDetails. A bare RID is also accepted as a service ID, and gives the details at the
+// service's origin.
+func (s *Service) ServiceDetails(req *serviceDetailsRequest, now time.Time) (ServiceDetails, error) {
+	rid, tiploc := decodeServiceID(strings.TrimSpace(req.ServiceID))
+	svc, ok := s.engine.Get(rid)
+	if !ok || !svc.HasSchedule {
+		return ServiceDetails{}, errNotFound
+	}
+
+	var calls []int
+	at := -1
+	for i, loc := range svc.Locations {
+		if loc.Type == pushport.LocationTypePassing || loc.Pta == "" && loc.Ptd == "" {
+			continue
+		}
+		calls = append(calls, i)
+		if at == -1 && (tiploc == "" || loc.Tiploc == tiploc) {
+			at = i
+		}
+	}
+	if at == -1 {
+		return ServiceDetails{}, errNotFound
+	}
+
+	times := svc.Times()
+	loc := svc.Locations[at]
+	t := times[at]
+	cancelled := loc.Cancelled || svc.IsCancelled
+
+	details := ServiceDetails{
+		GeneratedAt:  now.In(darwintime.UK).Format(time.RFC3339Nano),
+		RSID:         svc.RSID,
+		ServiceType:  serviceType,
+		LocationName: s.names.LocationName(loc.Tiploc),
+		CRS:          s.names.CRS(loc.Tiploc),
+		Operator:     s.names.OperatorName(svc.TOC),
+		OperatorCode: svc.TOC,
+		IsCancelled:  cancelled,
+		Length:       lengthText(loc.Length),
+	}
+	if cancelled {
+		details.CancelReason = s.names.CancelReasonText(svc.CancelReason)
+	} else {
+		details.DelayReason = s.names.LateReasonText(svc.LateReason)
+	}
+	if !loc.PlatformSuppressed {
+		details.Platform = loc.Platform
+	}
+
+	if loc.Pta != "" {
+		details.Sta = clock(t.ScheduledArrival)
+		details.Eta, details.Ata = detailTimes(cancelled, loc.Arrival, t.ScheduledArrival, t.ExpectedArrival, t.ActualArrival)
+	}
+	if loc.Ptd != "" {
+		details.Std = clock(t.ScheduledDeparture)
+		details.Etd, details.Atd = detailTimes(cancelled, loc.Departure, t.ScheduledDeparture, t.ExpectedDeparture, t.ActualDeparture)
+	}
+
+	var previous, subsequent []boards.CallingPoint
+	for _, i := range calls {
+		l := svc.Locations[i]
+		point := boards.CallingPoint{
+			CRS:         s.names.CRS(l.Tiploc),
+			Name:        s.names.LocationName(l.Tiploc),
+			IsCancelled: l.Cancelled || svc.IsCancelled,
+		}
+		switch {
+		case i < at && l.Ptd != "":
+			point.Scheduled = times[i].ScheduledDeparture
+			point.Expected, point.Actual = optional(times[i].ExpectedDeparture), optional(times[i].ActualDeparture)
+			previous = append(previous, point)
+		case i > at && l.Pta != "":
+			point.Scheduled = times[i].ScheduledArrival
+			point.Expected, point.Actual = optional(times[i].ExpectedArrival), optional(times[i].ActualArrival)
+			subsequent = append(subsequent, point)
+		}
+	}
+	details.PreviousCallingPoints = callingPointLists(previous)
+	details.SubsequentCallingPoints = callingPointLists(subsequent)
+
+	return details, nil
+}
+
+func detailTimes(cancelled bool, estimate *trainstate.TimeEstimate, scheduled time.Time, expected time.Time, actual time.Time) (string, string) {
+	switch {
+	case cancelled:
+		return "Cancelled", ""
+	case !actual.IsZero():
+		return "", relativeText(scheduled, actual)
+	case estimate != nil && (estimate.Delayed || estimate.EstimateUnknown):
+		return "Delayed", ""
+	case !expected.IsZero():
+		return relativeText(scheduled, expected), ""
+	default:
+		return "On time", ""
+	}
+}
+
+func optional(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
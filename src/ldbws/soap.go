@@ -0,0 +1,111 @@
+package ldbws
+
+import (
+	"encoding/xml"
+	"net/http"
+)
+
+const (
+	soap11Namespace = "http://schemas.xmlsoap.org/soap/envelope/"
+	soap12Namespace = "http://www.w3.org/2003/05/soap-envelope"
+)
+
+// requestEnvelope is a SOAP 1.1 or 1.2 request. The body's operation is decoded separately once its name is known.
+type requestEnvelope struct {
+	XMLName xml.Name `xml:"Envelope"`
+	Header  struct {
+		AccessToken struct {
+			TokenValue string `xml:"TokenValue"`
+		} `xml:"AccessToken"`
+	} `xml:"Header"`
+	Body struct {
+		Content []byte `xml:",innerxml"`
+	} `xml:"Body"`
+}
+
+// responseEnvelope is written with a soap prefix rather than as the default namespace, so the unqualified children of
+// SOAP 1.1 faults stay unqualified
+type responseEnvelope struct {
+	XMLName xml.Name
+	Prefix  string `xml:"xmlns:soap,attr"`
+	Body    responseBody
+}
+
+type responseBody struct {
+	XMLName xml.Name
+	Content any
+}
+
+// fault is written as a SOAP 1.1 or 1.2 fault, whichever the request used
+type fault struct {
+	// Client faults are the caller's fault; server faults are ours
+	Client  bool
+	Message string
+}
+
+type fault11 struct {
+	XMLName     xml.Name `xml:"soap:Fault"`
+	FaultCode   string   `xml:"faultcode"`
+	FaultString string   `xml:"faultstring"`
+}
+
+type fault12 struct {
+	XMLName xml.Name `xml:"soap:Fault"`
+	Code    struct {
+		Value string `xml:"soap:Value"`
+	} `xml:"soap:Code"`
+	Reason struct {
+		Text struct {
+			Lang  string `xml:"xml:lang,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"soap:Text"`
+	} `xml:"soap:Reason"`
+}
+
+func (f *fault) content(namespace string) any {
+	if namespace == soap12Namespace {
+		var c fault12
+		c.Code.Value = "soap:Receiver"
+		if f.Client {
+			c.Code.Value = "soap:Sender"
+		}
+		c.Reason.Text.Lang = "en"
+		c.Reason.Text.Value = f.Message
+		return c
+	}
+
+	c := fault11{FaultCode: "soap:Server", FaultString: f.Message}
+	if f.Client {
+		c.FaultCode = "soap:Client"
+	}
+	return c
+}
+
+func contentType(namespace string) string {
+	if namespace == soap12Namespace {
+		return "application/soap+xml; charset=utf-8"
+	}
+	return "text/xml; charset=utf-8"
+}
+
+// writeEnvelope writes content as the body of a SOAP envelope in the given namespace
+func writeEnvelope(w http.ResponseWriter, status int, namespace string, content any) error {
+	envelope := responseEnvelope{
+		XMLName: xml.Name{Local: "soap:Envelope"},
+		Prefix:  namespace,
+		Body: responseBody{
+			XMLName: xml.Name{Local: "soap:Body"},
+			Content: content,
+		},
+	}
+
+	encoded, err := xml.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", contentType(namespace))
+	w.WriteHeader(status)
+	_, err = w.Write(append([]byte(xml.Header), encoded...))
+	return err
+}
@@ -0,0 +1,129 @@
+package ldbws
+
+import "encoding/xml"
+
+// Responses follow the 2017-10-01 (ldb11) version of OpenLDBWS. Each element is in the namespace of the schema version
+// which introduced it, as in National Rail's own responses.
+
+// boardRequest is the body of GetDepartureBoard, GetArrivalBoard, GetArrDepBoardWithDetails and the other board
+// operations, which all take the same parameters
+type boardRequest struct {
+	NumRows    int    `xml:"numRows"`
+	CRS        string `xml:"crs"`
+	FilterCRS  string `xml:"filterCrs"`
+	FilterType string `xml:"filterType"`
+	TimeOffset int    `xml:"timeOffset"`
+	TimeWindow *int   `xml:"timeWindow"`
+}
+
+type serviceDetailsRequest struct {
+	ServiceID string `xml:"serviceID"`
+}
+
+type getDepartureBoardResponse struct {
+	XMLName xml.Name     `xml:"http://thalesgroup.com/RTTI/2017-10-01/ldb/ GetDepartureBoardResponse"`
+	Result  StationBoard `xml:"http://thalesgroup.com/RTTI/2017-10-01/ldb/ GetStationBoardResult"`
+}
+
+type getArrDepBoardWithDetailsResponse struct {
+	XMLName xml.Name     `xml:"http://thalesgroup.com/RTTI/2017-10-01/ldb/ GetArrDepBoardWithDetailsResponse"`
+	Result  StationBoard `xml:"http://thalesgroup.com/RTTI/2017-10-01/ldb/ GetStationBoardResult"`
+}
+
+type getServiceDetailsResponse struct {
+	XMLName xml.Name       `xml:"http://thalesgroup.com/RTTI/2017-10-01/ldb/ GetServiceDetailsResponse"`
+	Result  ServiceDetails `xml:"http://thalesgroup.com/RTTI/2017-10-01/ldb/ GetServiceDetailsResult"`
+}
+
+type StationBoard struct {
+	GeneratedAt        string         `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types generatedAt"`
+	LocationName       string         `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types locationName"`
+	CRS                string         `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types crs,omitempty"`
+	FilterLocationName string         `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types filterLocationName,omitempty"`
+	FilterCRS          string         `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types filtercrs,omitempty"`
+	FilterType         string         `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types filterType,omitempty"`
+	NRCCMessages       *NRCCMessages  `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types nrccMessages,omitempty"`
+	PlatformAvailable  bool           `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types platformAvailable,omitempty"`
+	TrainServices      *TrainServices `xml:"http://thalesgroup.com/RTTI/2017-10-01/ldb/types trainServices,omitempty"`
+}
+
+type NRCCMessages struct {
+	Messages []string `xml:"http://thalesgroup.com/RTTI/2007-10-10/ldb/commontypes message"`
+}
+
+type TrainServices struct {
+	Services []ServiceItem `xml:"http://thalesgroup.com/RTTI/2017-10-01/ldb/types service"`
+}
+
+type ServiceItem struct {
+	Sta                     string             `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types sta,omitempty"`
+	Eta                     string             `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types eta,omitempty"`
+	Std                     string             `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types std,omitempty"`
+	Etd                     string             `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types etd,omitempty"`
+	Platform                string             `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types platform,omitempty"`
+	Operator                string             `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types operator"`
+	OperatorCode            string             `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types operatorCode"`
+	IsCancelled             bool               `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types isCancelled,omitempty"`
+	ServiceType             string             `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types serviceType"`
+	Length                  string             `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types length,omitempty"`
+	CancelReason            string             `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types cancelReason,omitempty"`
+	DelayReason             string             `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types delayReason,omitempty"`
+	ServiceID               string             `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types serviceID"`
+	RSID                    string             `xml:"http://thalesgroup.com/RTTI/2016-02-16/ldb/types rsid,omitempty"`
+	Origin                  *ServiceLocations  `xml:"http://thalesgroup.com/RTTI/2016-02-16/ldb/types origin,omitempty"`
+	Destination             *ServiceLocations  `xml:"http://thalesgroup.com/RTTI/2016-02-16/ldb/types destination,omitempty"`
+	PreviousCallingPoints   *CallingPointLists `xml:"http://thalesgroup.com/RTTI/2017-10-01/ldb/types previousCallingPoints,omitempty"`
+	SubsequentCallingPoints *CallingPointLists `xml:"http://thalesgroup.com/RTTI/2017-10-01/ldb/types subsequentCallingPoints,omitempty"`
+}
+
+type ServiceLocations struct {
+	Locations []ServiceLocation `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types location"`
+}
+
+type ServiceLocation struct {
+	LocationName string `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types locationName"`
+	CRS          string `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types crs,omitempty"`
+	Via          string `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types via,omitempty"`
+}
+
+type CallingPointLists struct {
+	Lists []CallingPointList `xml:"http://thalesgroup.com/RTTI/2017-10-01/ldb/types callingPointList"`
+}
+
+type CallingPointList struct {
+	ServiceType   string         `xml:"serviceType,attr"`
+	CallingPoints []CallingPoint `xml:"http://thalesgroup.com/RTTI/2017-10-01/ldb/types callingPoint"`
+}
+
+type CallingPoint struct {
+	LocationName string `xml:"http://thalesgroup.com/RTTI/2017-10-01/ldb/types locationName"`
+	CRS          string `xml:"http://thalesgroup.com/RTTI/2017-10-01/ldb/types crs,omitempty"`
+	St           string `xml:"http://thalesgroup.com/RTTI/2017-10-01/ldb/types st"`
+	Et           string `xml:"http://thalesgroup.com/RTTI/2017-10-01/ldb/types et,omitempty"`
+	At           string `xml:"http://thalesgroup.com/RTTI/2017-10-01/ldb/types at,omitempty"`
+	IsCancelled  bool   `xml:"http://thalesgroup.com/RTTI/2017-10-01/ldb/types isCancelled,omitempty"`
+	Length       string `xml:"http://thalesgroup.com/RTTI/2017-10-01/ldb/types length,omitempty"`
+}
+
+type ServiceDetails struct {
+	GeneratedAt             string             `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types generatedAt"`
+	RSID                    string             `xml:"http://thalesgroup.com/RTTI/2016-02-16/ldb/types rsid,omitempty"`
+	ServiceType             string             `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types serviceType"`
+	LocationName            string             `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types locationName"`
+	CRS                     string             `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types crs,omitempty"`
+	Operator                string             `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types operator"`
+	OperatorCode            string             `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types operatorCode"`
+	IsCancelled             bool               `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types isCancelled,omitempty"`
+	CancelReason            string             `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types cancelReason,omitempty"`
+	DelayReason             string             `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types delayReason,omitempty"`
+	Length                  string             `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types length,omitempty"`
+	Platform                string             `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types platform,omitempty"`
+	Sta                     string             `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types sta,omitempty"`
+	Eta                     string             `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types eta,omitempty"`
+	Ata                     string             `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types ata,omitempty"`
+	Std                     string             `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types std,omitempty"`
+	Etd                     string             `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types etd,omitempty"`
+	Atd                     string             `xml:"http://thalesgroup.com/RTTI/2015-11-27/ldb/types atd,omitempty"`
+	PreviousCallingPoints   *CallingPointLists `xml:"http://thalesgroup.com/RTTI/2017-10-01/ldb/types previousCallingPoints,omitempty"`
+	SubsequentCallingPoints *CallingPointLists `xml:"http://thalesgroup.com/RTTI/2017-10-01/ldb/types subsequentCallingPoints,omitempty"`
+}
@@ -0,0 +1,105 @@
+package livefeed
+
+import (
+	"gemini-push-port/boards"
+	"net/url"
+	"strings"
+)
+
+// Filter selects which messages a client receives. Each field matches if it's empty or any of its values match, and
+// a message is sent if every field matches.
+type Filter struct {
+	Types   map[string]bool
+	TOCs    map[string]bool
+	Tiplocs map[string]bool
+	// CRSs holds the requested station codes, which match station messages directly
+	CRSs map[string]bool
+	// crsTiplocs holds the TIPLOCs of the requested stations, which match services calling at them
+	crsTiplocs map[string]bool
+	RIDs       map[string]bool
+}
+
+// ParseFilter reads a filter from the type, toc, crs, tiploc and rid query parameters. Each can be repeated, or given
+// as a comma-separated list.
+func ParseFilter(query url.Values, resolver boards.Resolver) Filter {
+	return NewFilter(
+		splitValues(query["type"]),
+		splitValues(query["toc"]),
+		splitValues(query["crs"]),
+		splitValues(query["tiploc"]),
+		splitValues(query["rid"]),
+		resolver,
+	)
+}
+
+func splitValues(params []string) []string {
+	var values []string
+	for _, param := range params {
+		values = append(values, strings.Split(param, ",")...)
+	}
+	return values
+}
+
+// NewFilter creates a filter for messages matching any of each of the given values. The resolver is used to find the
+// TIPLOCs of the stations.
+func NewFilter(types []string, tocs []string, crs []string, tiplocs []string, rids []string, resolver boards.Resolver) Filter {
+	f := Filter{
+		Types:   toSet(types, false),
+		TOCs:    toSet(tocs, true),
+		Tiplocs: toSet(tiplocs, true),
+		CRSs:    toSet(crs, true),
+		RIDs:    toSet(rids, false),
+	}
+
+	if len(f.CRSs) > 0 {
+		f.crsTiplocs = make(map[string]bool)
+		for station := range f.CRSs {
+			for _, tiploc := range resolver.TiplocsForCRS(station) {
+				f.crsTiplocs[tiploc] = true
+			}
+		}
+	}
+	return f
+}
+
+func toSet(values []string, upper bool) map[string]bool {
+	var set map[string]bool
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if upper {
+			v = strings.ToUpper(v)
+		}
+		if set == nil {
+			set = make(map[string]bool)
+		}
+		set[v] = true
+	}
+	return set
+}
+
+// Matches returns whether a message with the given attributes should be sent to the client
+func (f *Filter) Matches(attrs *Attributes) bool {
+	if len(f.CRSs) > 0 && !anyIn(attrs.Stations, f.CRSs) && !anyIn(attrs.Tiplocs, f.crsTiplocs) {
+		return false
+	}
+	return matchesAny(f.Types, attrs.Types) &&
+		matchesAny(f.TOCs, attrs.TOCs) &&
+		matchesAny(f.Tiplocs, attrs.Tiplocs) &&
+		matchesAny(f.RIDs, attrs.RIDs)
+}
+
+func matchesAny(wanted map[string]bool, values []string) bool {
+	return len(wanted) == 0 || anyIn(values, wanted)
+}
+
+func anyIn(values []string, set map[string]bool) bool {
+	for _, v := range values {
+		if set[v] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package livefeed
+
+import (
+	"context"
+	"fmt"
+	"gemini-push-port/httpapi"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+const (
+	writeTimeout      = 10 * time.Second
+	keepAliveInterval = 30 * time.Second
+)
+
+func (h *Hub) RegisterRoutes(server *httpapi.Server) {
+	server.HandleFunc("GET /live/ws", h.handleWebSocket)
+	server.HandleFunc("GET /live/sse", h.handleSSE)
+}
+
+func parseFormat(r *http.Request) (Format, bool) {
+	switch Format(r.URL.Query().Get("format")) {
+	case "", FormatParsed:
+		return FormatParsed, true
+	case FormatRaw:
+		return FormatRaw, true
+	}
+	return "", false
+}
+
+func (h *Hub) connect(w http.ResponseWriter, r *http.Request) (*client, bool) {
+	format, ok := parseFormat(r)
+	if !ok {
+		httpapi.WriteError(w, r, http.StatusBadRequest, "format must be parsed or raw")
+		return nil, false
+	}
+
+	c, ok := h.subscribe(ParseFilter(r.URL.Query(), h.resolver), format)
+	if !ok {
+		httpapi.WriteError(w, r, http.StatusServiceUnavailable, "shutting down")
+		return nil, false
+	}
+	return c, true
+}
+
+// handleWebSocket sends each matching message as a text frame
+func (h *Hub) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.connect(w, r)
+	if !ok {
+		return
+	}
+	defer h.unsubscribe(c)
+
+	var originPatterns []string
+	if origins := os.Getenv("LIVEFEED_ALLOWED_ORIGINS"); origins != "" {
+		originPatterns = strings.Split(origins, ",")
+	}
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{OriginPatterns: originPatterns})
+	if err != nil {
+		httpapi.Logger(r).WarnE("failed to accept live feed WebSocket", err)
+		return
+	}
+	defer conn.CloseNow()
+
+	// clients don't send anything, but reading is needed to notice them closing the connection
+	ctx := conn.CloseRead(r.Context())
+
+	for {
+		select {
+		case data, ok := <-c.frames:
+			if !ok {
+				_ = conn.Close(websocket.StatusGoingAway, "server shutting down")
+				return
+			}
+
+			writeCtx, cancel := context.WithTimeout(ctx, writeTimeout)
+			err = conn.Write(writeCtx, websocket.MessageText, data)
+			cancel()
+			if err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// handleSSE sends each matching message as a Server-Sent Event
+func (h *Hub) handleSSE(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		httpapi.WriteError(w, r, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	c, ok := h.connect(w, r)
+	if !ok {
+		return
+	}
+	defer h.unsubscribe(c)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	rc := http.NewResponseController(w)
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		var err error
+		select {
+		case data, ok := <-c.frames:
+			if !ok {
+				return
+			}
+			_ = rc.SetWriteDeadline(time.Now().Add(writeTimeout))
+			_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+		case <-keepAlive.C:
+			_ = rc.SetWriteDeadline(time.Now().Add(writeTimeout))
+			_, err = fmt.Fprint(w, ": keep-alive\n\n")
+		case <-r.Context().Done():
+			return
+		}
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,224 @@
+package livefeed
+
+import (
+	"encoding/json"
+	"gemini-push-port/boards"
+	"gemini-push-port/logging"
+	"gemini-push-port/pushport"
+	"gemini-push-port/trainstate"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultClientBuffer = 256
+
+// Format is how messages are sent to a client
+type Format string
+
+const (
+	FormatParsed Format = "parsed"
+	FormatRaw    Format = "raw"
+)
+
+// frame is what's sent to clients for each message
+type frame struct {
+	Time  time.Time `json:"time"`
+	Types []string  `json:"types"`
+	RIDs  []string  `json:"rids"`
+	// Dropped is the number of messages dropped for this client since the last one it was sent, because it wasn't
+	// keeping up
+	Dropped int             `json:"dropped,omitempty"`
+	XML     string          `json:"xml,omitempty"`
+	Message *pushport.Pport `json:"message,omitempty"`
+}
+
+// Attributes of a message which clients can filter on
+type Attributes struct {
+	Types    []string
+	RIDs     []string
+	TOCs     []string
+	Tiplocs  []string
+	Stations []string
+}
+
+type client struct {
+	filter Filter
+	format Format
+	frames chan []byte
+	// dropped is only touched by the hub while holding its lock
+	dropped int
+}
+
+// Hub fans out every Push Port message to the connected live feed clients. Each client has a bounded buffer, and
+// messages are dropped for a client whose buffer is full, so a stalled client never holds up the other handlers.
+type Hub struct {
+	mu           sync.Mutex
+	clients      map[*client]struct{}
+	engine       *trainstate.Engine
+	resolver     boards.Resolver
+	clientBuffer int
+	closed       bool
+}
+
+// NewHubFromEnv creates a hub which buffers LIVEFEED_CLIENT_BUFFER messages per client (256 by default). The engine is
+// used to find the operator and calling points of services in TS messages, which don't include them.
+func NewHubFromEnv(engine *trainstate.Engine, resolver boards.Resolver) *Hub {
+	clientBuffer, err := strconv.Atoi(os.Getenv("LIVEFEED_CLIENT_BUFFER"))
+	if err != nil || clientBuffer < 1 {
+		clientBuffer = defaultClientBuffer
+	}
+
+	return &Hub{
+		clients:      make(map[*client]struct{}),
+		engine:       engine,
+		resolver:     resolver,
+		clientBuffer: clientBuffer,
+	}
+}
+
+func (h *Hub) subscribe(filter Filter, format Format) (*client, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, false
+	}
+
+	c := &client{
+		filter: filter,
+		format: format,
+		frames: make(chan []byte, h.clientBuffer),
+	}
+	h.clients[c] = struct{}{}
+	logging.Logger.Infof("Live feed client connected, %d connected", len(h.clients))
+	return c, true
+}
+
+func (h *Hub) unsubscribe(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.clients[c]; !ok {
+		return
+	}
+	delete(h.clients, c)
+	close(c.frames)
+	logging.Logger.Infof("Live feed client disconnected, %d connected", len(h.clients))
+}
+
+// Close disconnects every client, and stops new ones connecting
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for c := range h.clients {
+		delete(h.clients, c)
+		close(c.frames)
+	}
+}
+
+func (h *Hub) HandleMessage(msg *pushport.Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.clients) == 0 {
+		return
+	}
+
+	attrs := MessageAttributes(msg, h.engine)
+	base := frame{
+		Time:  msg.Time,
+		Types: attrs.Types,
+		RIDs:  attrs.RIDs,
+	}
+
+	// most clients keep up, so share their frames rather than marshalling the message again for each one
+	shared := make(map[Format][]byte)
+
+	for c := range h.clients {
+		if !c.filter.Matches(attrs) {
+			continue
+		}
+
+		data, ok := shared[c.format]
+		if !ok || c.dropped > 0 {
+			f := base
+			f.Dropped = c.dropped
+			if c.format == FormatRaw {
+				f.XML = msg.Raw.Message
+			} else {
+				f.Message = msg.Pport
+			}
+
+			var err error
+			data, err = json.Marshal(f)
+			if err != nil {
+				logging.Logger.WarnE("failed to marshal live feed message", err)
+				return
+			}
+			if c.dropped == 0 {
+				shared[c.format] = data
+			}
+		}
+
+		select {
+		case c.frames <- data:
+			c.dropped = 0
+		default:
+			c.dropped++
+		}
+	}
+}
+
+// MessageAttributes returns the attributes of a message to filter on. The engine, if given, is used to find the
+// operator and calling points of services in messages which don't include them.
+func MessageAttributes(msg *pushport.Message, engine *trainstate.Engine) *Attributes {
+	response := msg.Pport.Response()
+	attrs := &Attributes{
+		Types: response.MessageTypes(),
+		RIDs:  response.RIDs(),
+	}
+
+	for _, schedule := range response.Schedules {
+		attrs.TOCs = append(attrs.TOCs, schedule.TOC)
+		for _, loc := range schedule.Locations {
+			attrs.Tiplocs = append(attrs.Tiplocs, loc.Tiploc)
+		}
+	}
+	for _, ts := range response.TrainStatuses {
+		for _, loc := range ts.Locations {
+			attrs.Tiplocs = append(attrs.Tiplocs, loc.Tiploc)
+		}
+	}
+	for _, assoc := range response.Associations {
+		attrs.Tiplocs = append(attrs.Tiplocs, assoc.Tiploc)
+	}
+	for _, sm := range response.StationMessages {
+		for _, station := range sm.Stations {
+			attrs.Stations = append(attrs.Stations, strings.ToUpper(station.CRS))
+		}
+	}
+
+	// TS, deactivated and association messages don't say who operates the service, or every place it calls at
+	if engine != nil {
+		for _, rid := range attrs.RIDs {
+			if svc, ok := engine.Get(rid); ok {
+				attrs.TOCs = append(attrs.TOCs, svc.TOC)
+				for _, loc := range svc.Locations {
+					attrs.Tiplocs = append(attrs.Tiplocs, loc.Tiploc)
+				}
+			}
+		}
+	}
+
+	slices.Sort(attrs.TOCs)
+	attrs.TOCs = slices.Compact(attrs.TOCs)
+	slices.Sort(attrs.Tiplocs)
+	attrs.Tiplocs = slices.Compact(attrs.Tiplocs)
+	return attrs
+}
@@ -2,9 +2,34 @@ package main
 
 import (
 	"context"
+	"gemini-push-port/analytics"
+	"gemini-push-port/boards"
+	"gemini-push-port/cli"
+	"gemini-push-port/events"
+	"gemini-push-port/grpcapi"
+	"gemini-push-port/gtfsrt"
+	"gemini-push-port/health"
+	"gemini-push-port/httpapi"
+	"gemini-push-port/ldbws"
+	"gemini-push-port/livefeed"
 	"gemini-push-port/logging"
+	"gemini-push-port/metrics"
+	"gemini-push-port/ndjson"
+	"gemini-push-port/parquetexport"
+	"gemini-push-port/postgres"
+	"gemini-push-port/ptac"
 	"gemini-push-port/pubsub"
+	"gemini-push-port/pushport"
 	"gemini-push-port/rawstore"
+	"gemini-push-port/refdata"
+	"gemini-push-port/servicehistory"
+	"gemini-push-port/siri"
+	"gemini-push-port/stationmessages"
+	"gemini-push-port/timetable"
+	"gemini-push-port/trainstate"
+	"gemini-push-port/validation"
+	"gemini-push-port/warmstart"
+	"gemini-push-port/webhooks"
 	"os"
 	"os/signal"
 	"syscall"
@@ -15,7 +40,6 @@ import (
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/go-co-op/gocron/v2"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "github.com/joho/godotenv/autoload"
 )
 
@@ -32,6 +56,12 @@ func main() {
 	logging.InitialiseLogging(serviceName, true, sentryConfig)
 	logger := logging.Logger
 
+	r2s3client := newS3Client()
+
+	if len(os.Args) > 1 {
+		os.Exit(cli.Run(os.Args[1:], r2s3client))
+	}
+
 	logger.Infof("Starting consumer...")
 
 	s, err := gocron.NewScheduler()
@@ -39,17 +69,6 @@ func main() {
 		logger.FatalE("failed to create scheduler", err)
 	}
 
-	r2s3config, err := config.LoadDefaultConfig(context.TODO(),
-		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(os.Getenv("S3_COMPATIBLE_ACCESS_KEY_ID"), os.Getenv("S3_COMPATIBLE_SECRET_ACCESS_KEY"), "")),
-		config.WithRegion(os.Getenv("S3_COMPATIBLE_REGION")),
-	)
-	if err != nil {
-		logger.FatalE("failed to load s3 config", err)
-	}
-	r2s3client := s3.NewFromConfig(r2s3config, func(opt *s3.Options) {
-		opt.BaseEndpoint = aws.String(os.Getenv("S3_COMPATIBLE_ENDPOINT"))
-	})
-
 	_, err = s.NewJob(
 		gocron.DurationJob(
 			1*time.Minute,
@@ -78,14 +97,330 @@ func main() {
 		logger.FatalE("failed to create dump to bucket job", err)
 	}
 
+	consistStore := ptac.NewStore()
+	_, err = s.NewJob(
+		gocron.DurationJob(
+			1*time.Hour,
+		),
+		gocron.NewTask(
+			ptac.PruneJob,
+			consistStore,
+		),
+		gocron.WithContext(context.Background()),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+	)
+	if err != nil {
+		logger.FatalE("failed to create consist prune job", err)
+	}
+
+	trainState := trainstate.NewEngine(trainstate.GetExpiryFromEnv())
+	trainStateSnapshotPath := trainstate.GetSnapshotPathFromEnv()
+	if trainStateSnapshotPath != "" {
+		err = trainState.LoadSnapshot(trainStateSnapshotPath)
+		if err != nil {
+			logger.ErrorE("failed to load train state snapshot", err)
+		}
+
+		_, err = s.NewJob(
+			gocron.DurationJob(
+				5*time.Minute,
+			),
+			gocron.NewTask(
+				trainstate.SnapshotJob,
+				trainState,
+				trainStateSnapshotPath,
+			),
+			gocron.WithContext(context.Background()),
+			gocron.WithSingletonMode(gocron.LimitModeReschedule),
+		)
+		if err != nil {
+			logger.FatalE("failed to create train state snapshot job", err)
+		}
+	}
+	_, err = s.NewJob(
+		gocron.DurationJob(
+			10*time.Minute,
+		),
+		gocron.NewTask(
+			trainstate.ExpireJob,
+			trainState,
+		),
+		gocron.WithContext(context.Background()),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+	)
+	if err != nil {
+		logger.FatalE("failed to create train state expiry job", err)
+	}
+
+	timetableLoader := timetable.NewLoaderFromEnv(r2s3client, trainState)
+	if timetableLoader.Configured() {
+		// seed before consuming, so the first forecasts already have a schedule to attach to
+		timetable.LoadJob(timetableLoader)
+
+		_, err = s.NewJob(
+			gocron.DurationJob(
+				30*time.Minute,
+			),
+			gocron.NewTask(
+				timetable.LoadJob,
+				timetableLoader,
+			),
+			gocron.WithContext(context.Background()),
+			gocron.WithSingletonMode(gocron.LimitModeReschedule),
+		)
+		if err != nil {
+			logger.FatalE("failed to create timetable load job", err)
+		}
+	}
+
+	updateHistory := servicehistory.NewRecorder(trainstate.GetExpiryFromEnv())
+	_, err = s.NewJob(
+		gocron.DurationJob(
+			10*time.Minute,
+		),
+		gocron.NewTask(
+			servicehistory.ExpireJob,
+			updateHistory,
+		),
+		gocron.WithContext(context.Background()),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+	)
+	if err != nil {
+		logger.FatalE("failed to create update history expiry job", err)
+	}
+
+	refData := refdata.NewStoreFromEnv(r2s3client)
+	if refData.Configured() {
+		_, err = s.NewJob(
+			gocron.DurationJob(
+				15*time.Minute,
+			),
+			gocron.NewTask(
+				refdata.ReloadJob,
+				refData,
+			),
+			gocron.WithContext(context.Background()),
+			gocron.WithSingletonMode(gocron.LimitModeReschedule),
+		)
+		if err != nil {
+			logger.FatalE("failed to create reference data reload job", err)
+		}
+	}
+
+	var crsResolver boards.Resolver = refData
+	if !refData.Configured() {
+		crsResolver = boards.NewStaticResolverFromEnv()
+	}
+
+	stationMessages := stationmessages.NewStore(stationmessages.NewAuditLogFromEnv(), refData)
+
+	liveHandlers := []pushport.Handler{trainState, updateHistory, stationMessages}
+	replayHandlers := []pushport.Handler{trainState, updateHistory, stationMessages.Replay()}
+
+	eventSink, err := events.NewSinkFromEnv()
+	if err != nil {
+		logger.FatalE("failed to set up event sinks", err)
+	}
+	if eventSink != nil {
+		eventChan := make(chan events.Event, 10_000)
+		eventDetector, err := events.NewDetectorFromEnv(trainState, eventChan, trainstate.GetExpiryFromEnv())
+		if err != nil {
+			logger.FatalE("failed to set up event detection", err)
+		}
+		_, err = s.NewJob(
+			gocron.DurationJob(
+				10*time.Minute,
+			),
+			gocron.NewTask(
+				events.ExpireJob,
+				eventDetector,
+			),
+			gocron.WithContext(context.Background()),
+			gocron.WithSingletonMode(gocron.LimitModeReschedule),
+		)
+		if err != nil {
+			logger.FatalE("failed to create event detector expiry job", err)
+		}
+
+		// replayed messages have already had their events published, so only build up the detector's state from them
+		liveHandlers = append(liveHandlers, eventDetector)
+		replayHandlers = append(replayHandlers, eventDetector.Baseline())
+		go events.Thread(eventChan, eventSink)
+	}
+
+	liveFeed := livefeed.NewHubFromEnv(trainState, crsResolver)
+	liveHandlers = append(liveHandlers, liveFeed)
+
+	var webhookDispatcher *webhooks.Dispatcher
+	if webhooks.EnabledFromEnv() {
+		webhookStore, err := webhooks.OpenStoreFromEnv()
+		if err != nil {
+			logger.FatalE("failed to open webhook subscriptions", err)
+		}
+		webhookDispatcher, err = webhooks.NewDispatcherFromEnv(webhookStore, trainState, crsResolver)
+		if err != nil {
+			logger.FatalE("failed to load webhook subscriptions", err)
+		}
+
+		liveHandlers = append(liveHandlers, webhookDispatcher)
+		go webhookDispatcher.Thread()
+	}
+
+	var siriProducer *siri.Producer
+	if siri.EnabledFromEnv() {
+		siriProducer, err = siri.NewProducerFromEnv(trainState, refData)
+		if err != nil {
+			logger.FatalE("failed to set up the SIRI producer", err)
+		}
+		liveHandlers = append(liveHandlers, siriProducer)
+		go siriProducer.Thread()
+	}
+
+	postgresSink, err := postgres.NewSinkFromEnv(context.Background())
+	if err != nil {
+		logger.FatalE("failed to set up the Postgres sink", err)
+	}
+	if postgresSink != nil {
+		liveHandlers = append(liveHandlers, postgresSink)
+		go postgresSink.Thread()
+	}
+
+	var archiveObservers []rawstore.Observer
+	var analyticsSink *analytics.Sink
+	if analytics.EnabledFromEnv() {
+		analyticsSink, err = analytics.OpenSinkFromEnv(trainState)
+		if err != nil {
+			logger.FatalE("failed to open analytics database", err)
+		}
+		_, err = s.NewJob(
+			gocron.DurationJob(
+				1*time.Hour,
+			),
+			gocron.NewTask(
+				analytics.PruneJob,
+				analyticsSink,
+				analytics.RetentionFromEnv(),
+			),
+			gocron.WithContext(context.Background()),
+			gocron.WithSingletonMode(gocron.LimitModeReschedule),
+		)
+		if err != nil {
+			logger.FatalE("failed to create analytics prune job", err)
+		}
+
+		archiveObservers = append(archiveObservers, analyticsSink)
+		go analyticsSink.Thread()
+	}
+
+	var ndjsonWriter *ndjson.Writer
+	if ndjson.EnabledFromEnv() {
+		ndjsonWriter = ndjson.NewWriterFromEnv()
+		archiveObservers = append(archiveObservers, ndjsonWriter)
+		go ndjsonWriter.Thread()
+	}
+
+	archiveReader := rawstore.NewArchiveReaderFromEnv(r2s3client)
+	serviceLookup := servicehistory.NewLookup(trainState, updateHistory, archiveReader, refData)
+
+	grpcServer := grpcapi.NewServerFromEnv(trainState, serviceLookup, archiveReader, crsResolver)
+	if grpcServer.Enabled() {
+		liveHandlers = append(liveHandlers, grpcServer)
+	}
+
+	parquetExporter := parquetexport.NewExporterFromEnv(r2s3client, archiveReader)
+	if parquetExporter.Enabled() {
+		_, err = s.NewJob(
+			gocron.DurationJob(
+				15*time.Minute,
+			),
+			gocron.NewTask(
+				parquetexport.ExportJob,
+				parquetExporter,
+			),
+			gocron.WithContext(context.Background()),
+			gocron.WithSingletonMode(gocron.LimitModeReschedule),
+		)
+		if err != nil {
+			logger.FatalE("failed to create Parquet export job", err)
+		}
+	}
+
+	gtfsFeed, err := gtfsrt.NewFeedFromEnv(trainState, refData)
+	if err != nil {
+		logger.FatalE("failed to set up the GTFS-RT feed", err)
+	}
+	if gtfsFeed != nil {
+		_, err = s.NewJob(
+			gocron.DurationJob(
+				time.Hour,
+			),
+			gocron.NewTask(
+				gtfsrt.ReloadJob,
+				gtfsFeed,
+			),
+			gocron.WithContext(context.Background()),
+			gocron.WithSingletonMode(gocron.LimitModeReschedule),
+		)
+		if err != nil {
+			logger.FatalE("failed to create static GTFS reload job", err)
+		}
+	}
+
+	warmStart := warmstart.NewReplayerFromEnv(archiveReader)
+	if warmStart.Enabled() {
+		// replay before consuming, so live messages are applied on top of everything already archived today
+		err = warmStart.Replay(context.Background(), time.Now(), replayHandlers...)
+		if err != nil {
+			logger.ErrorE("failed to warm start from the archive", err)
+		}
+	}
+
 	rawMessagesChan := make(chan *rawstore.XmlMessageWithTime, 500_000)
+	consistMessagesChan := make(chan *rawstore.XmlMessageWithTime, 10_000)
+	pushPortMessagesChan := make(chan *rawstore.XmlMessageWithTime, 100_000)
+	metrics.ObserveChannel("raw", rawMessagesChan)
+	metrics.ObserveChannel("consist", consistMessagesChan)
+	metrics.ObserveChannel("pushport", pushPortMessagesChan)
+
+	healthChecker := health.NewCheckerFromEnv()
+	health.WatchChannel(healthChecker, "raw", rawMessagesChan)
+	health.WatchChannel(healthChecker, "consist", consistMessagesChan)
+	health.WatchChannel(healthChecker, "pushport", pushPortMessagesChan)
 
-	go pubsub.Thread(rawMessagesChan)
-	go rawstore.Thread(rawMessagesChan)
+	go pubsub.Thread(rawMessagesChan, consistMessagesChan, pushPortMessagesChan)
+	go rawstore.Thread(rawMessagesChan, validation.NewFromEnv(), archiveObservers...)
+	go ptac.Thread(consistMessagesChan, consistStore)
+	go pushport.Thread(pushPortMessagesChan, warmStart.Deduplicate(liveHandlers...))
+
+	httpServer := httpapi.NewFromEnv()
+	metrics.RegisterRoutes(httpServer)
+	healthChecker.RegisterRoutes(httpServer)
+	boardBuilder := boards.NewBuilder(trainState, crsResolver, refData)
+	boardBuilder.RegisterRoutes(httpServer)
+	serviceLookup.RegisterRoutes(httpServer)
+	stationMessages.RegisterRoutes(httpServer)
+	consistStore.RegisterRoutes(httpServer)
+	liveFeed.RegisterRoutes(httpServer)
+	if gtfsFeed != nil {
+		gtfsFeed.RegisterRoutes(httpServer)
+	}
+	if webhookDispatcher != nil {
+		webhookDispatcher.RegisterRoutes(httpServer)
+	}
+	if siriProducer != nil {
+		siriProducer.RegisterRoutes(httpServer)
+	}
+	if ldbService := ldbws.NewServiceFromEnv(boardBuilder, trainState, refData, stationMessages); ldbService != nil {
+		ldbService.RegisterRoutes(httpServer)
+	}
+	go httpServer.Thread()
+	if grpcServer.Enabled() {
+		go grpcServer.Thread()
+	}
 
 	s.Start()
 
-	sc := make(chan os.Signal)
+	sc := make(chan os.Signal, 1)
 	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt, os.Kill)
 	<-sc
 
@@ -97,8 +432,52 @@ func main() {
 		logger.ErrorE("failed to shutdown scheduler", err)
 	}
 
+	// stop accepting HTTP and gRPC requests, giving in-flight ones a chance to complete. Live feed clients and gRPC
+	// subscribers never finish on their own, so are disconnected first.
+	liveFeed.Close()
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+	err = httpServer.Shutdown(shutdownCtx)
+	if err != nil {
+		logger.ErrorE("failed to shutdown HTTP server", err)
+	}
+	if grpcServer.Enabled() {
+		grpcServer.Stop(shutdownCtx)
+	}
+	cancelShutdown()
+	if siriProducer != nil {
+		siriProducer.Close()
+	}
+
 	// Wait for the process to finish processing any remaining messages
 	time.Sleep(5 * time.Second)
 
+	if postgresSink != nil {
+		postgresSink.Close()
+	}
+	if analyticsSink != nil {
+		analyticsSink.Close()
+	}
+	if ndjsonWriter != nil {
+		ndjsonWriter.Close()
+	}
+
+	if trainStateSnapshotPath != "" {
+		trainstate.SnapshotJob(trainState, trainStateSnapshotPath)
+	}
+
 	logger.Infof("Shutting down consumer...")
 }
+
+func newS3Client() *s3.Client {
+	r2s3config, err := config.LoadDefaultConfig(context.TODO(),
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(os.Getenv("S3_COMPATIBLE_ACCESS_KEY_ID"), os.Getenv("S3_COMPATIBLE_SECRET_ACCESS_KEY"), "")),
+		config.WithRegion(os.Getenv("S3_COMPATIBLE_REGION")),
+	)
+	if err != nil {
+		logging.Logger.FatalE("failed to load s3 config", err)
+	}
+
+	return s3.NewFromConfig(r2s3config, func(opt *s3.Options) {
+		opt.BaseEndpoint = aws.String(os.Getenv("S3_COMPATIBLE_ENDPOINT"))
+	})
+}
@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"gemini-push-port/httpapi"
+	"os"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "pushport"
+
+// Every metric is labelled with the Kafka topic the process consumes, so several deployments can share a Prometheus
+var (
+	MessagesConsumed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_consumed_total",
+		Help:      "Messages fetched from Kafka.",
+	}, []string{"topic"})
+	MessagesCommitted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_committed_total",
+		Help:      "Messages committed to Kafka after being queued for archiving.",
+	}, []string{"topic"})
+	MessagesDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_dropped_total",
+		Help:      "Messages discarded because a channel was full, by the channel which was full.",
+	}, []string{"topic", "channel"})
+	MessagesQuarantined = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_quarantined_total",
+		Help:      "Messages written to the quarantine tree instead of the archive, by why they failed validation.",
+	}, []string{"topic", "reason"})
+	FetchErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "fetch_errors_total",
+		Help:      "Failed attempts to fetch a message from Kafka.",
+	}, []string{"topic"})
+	Reconnects = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reconnects_total",
+		Help:      "Times the Kafka reader was recreated after an error or a long silence.",
+	}, []string{"topic"})
+	MessageAge = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "message_age_seconds",
+		Help:      "Time between a message's Kafka timestamp and it being received.",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 300, 900, 3600},
+	}, []string{"topic"})
+
+	ArchiveBytesWritten = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "archive_bytes_written_total",
+		Help:      "Bytes appended to the hourly archive files.",
+	}, []string{"topic"})
+
+	UploadDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upload_duration_seconds",
+		Help:      "Time taken to compress and upload each hourly file to the bucket, by file extension.",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 12),
+	}, []string{"topic", "extension"})
+	UploadSize = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upload_size_bytes",
+		Help:      "Compressed size of each hourly file uploaded to the bucket, by file extension.",
+		Buckets:   prometheus.ExponentialBuckets(64*1024, 2, 12),
+	}, []string{"topic", "extension"})
+	UploadFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upload_failures_total",
+		Help:      "Hourly files which failed to upload to the bucket, by file extension.",
+	}, []string{"topic", "extension"})
+
+	CleanupDeletions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cleanup_deletions_total",
+		Help:      "Local files deleted by the cleanup job, by file extension.",
+	}, []string{"topic", "extension"})
+
+	DatabaseWriteFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "database_write_failures_total",
+		Help:      "Messages skipped because PostgreSQL rejected their statements.",
+	}, []string{"topic"})
+)
+
+var topic = sync.OnceValue(func() string {
+	return os.Getenv("KAFKA_TOPIC")
+})
+
+// Topic returns the topic label every metric is recorded with
+func Topic() string {
+	return topic()
+}
+
+// ObserveChannel exports the depth and capacity of a channel, named by the channel label
+func ObserveChannel[T any](name string, ch chan T) {
+	labels := prometheus.Labels{"topic": Topic(), "channel": name}
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Name:        "channel_depth",
+		Help:        "Messages waiting in a channel.",
+		ConstLabels: labels,
+	}, func() float64 {
+		return float64(len(ch))
+	})
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Name:        "channel_capacity",
+		Help:        "Messages a channel can hold.",
+		ConstLabels: labels,
+	}, func() float64 {
+		return float64(cap(ch))
+	})
+}
+
+func RegisterRoutes(server *httpapi.Server) {
+	server.Handle("GET /metrics", promhttp.Handler())
+}
@@ -0,0 +1,5 @@
+DROP TABLE station_messages;
+DROP TABLE associations;
+DROP TABLE location_updates;
+DROP TABLE schedule_locations;
+DROP TABLE schedules;
@@ -0,0 +1,124 @@
+CREATE TABLE schedules (
+    rid                  TEXT PRIMARY KEY,
+    uid                  TEXT NOT NULL,
+    train_id             TEXT NOT NULL,
+    rsid                 TEXT,
+    ssd                  DATE NOT NULL,
+    toc                  TEXT NOT NULL,
+    status               TEXT,
+    train_cat            TEXT,
+    is_passenger_svc     BOOLEAN NOT NULL,
+    is_active            BOOLEAN NOT NULL,
+    is_charter           BOOLEAN NOT NULL,
+    is_deleted           BOOLEAN NOT NULL,
+    -- every calling point is cancelled
+    is_cancelled         BOOLEAN NOT NULL,
+    cancel_reason_code   TEXT,
+    cancel_reason_tiploc TEXT,
+    cancel_reason_near   BOOLEAN,
+    diverted_via         TEXT,
+    late_reason_code     TEXT,
+    late_reason_tiploc   TEXT,
+    late_reason_near     BOOLEAN,
+    deactivated          BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at           TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX schedules_ssd_idx ON schedules (ssd);
+CREATE INDEX schedules_train_id_ssd_idx ON schedules (train_id, ssd);
+CREATE INDEX schedules_cancelled_idx ON schedules (ssd) WHERE is_cancelled;
+
+-- the locations in each service's current schedule, replaced whenever a new schedule is received
+CREATE TABLE schedule_locations (
+    rid                TEXT NOT NULL REFERENCES schedules (rid) ON DELETE CASCADE,
+    seq                INTEGER NOT NULL,
+    tiploc             TEXT NOT NULL,
+    type               TEXT NOT NULL,
+    activities         TEXT,
+    planned_activities TEXT,
+    cancelled          BOOLEAN NOT NULL,
+    false_destination  TEXT,
+    platform           TEXT,
+    pta                TEXT,
+    ptd                TEXT,
+    wta                TEXT,
+    wtd                TEXT,
+    wtp                TEXT,
+    PRIMARY KEY (rid, seq)
+);
+
+CREATE INDEX schedule_locations_tiploc_idx ON schedule_locations (tiploc);
+
+-- the latest forecasts and actuals at each location, from TS messages. A location is identified by its TIPLOC and
+-- working times, as services can call at the same TIPLOC more than once. There's no foreign key to schedules, as
+-- forecasts can arrive before the schedule does.
+CREATE TABLE location_updates (
+    rid                 TEXT NOT NULL,
+    tiploc              TEXT NOT NULL,
+    wta                 TEXT NOT NULL DEFAULT '',
+    wtd                 TEXT NOT NULL DEFAULT '',
+    wtp                 TEXT NOT NULL DEFAULT '',
+    pta                 TEXT,
+    ptd                 TEXT,
+    arrival_et          TEXT,
+    arrival_wet         TEXT,
+    arrival_at          TEXT,
+    arrival_delayed     BOOLEAN,
+    departure_et        TEXT,
+    departure_wet       TEXT,
+    departure_at        TEXT,
+    departure_delayed   BOOLEAN,
+    pass_et             TEXT,
+    pass_wet            TEXT,
+    pass_at             TEXT,
+    pass_delayed        BOOLEAN,
+    platform            TEXT,
+    platform_suppressed BOOLEAN,
+    platform_confirmed  BOOLEAN,
+    platform_source     TEXT,
+    suppressed          BOOLEAN NOT NULL DEFAULT FALSE,
+    length              TEXT,
+    updated_at          TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (rid, tiploc, wta, wtd, wtp)
+);
+
+CREATE INDEX location_updates_tiploc_idx ON location_updates (tiploc);
+
+CREATE TABLE associations (
+    main_rid     TEXT NOT NULL,
+    assoc_rid    TEXT NOT NULL,
+    tiploc       TEXT NOT NULL,
+    category     TEXT NOT NULL,
+    is_cancelled BOOLEAN NOT NULL,
+    is_deleted   BOOLEAN NOT NULL,
+    main_wta     TEXT,
+    main_wtd     TEXT,
+    main_wtp     TEXT,
+    main_pta     TEXT,
+    main_ptd     TEXT,
+    assoc_wta    TEXT,
+    assoc_wtd    TEXT,
+    assoc_wtp    TEXT,
+    assoc_pta    TEXT,
+    assoc_ptd    TEXT,
+    updated_at   TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (main_rid, assoc_rid, tiploc, category)
+);
+
+CREATE INDEX associations_assoc_rid_idx ON associations (assoc_rid);
+
+-- station messages are kept once cleared, with cleared_at set
+CREATE TABLE station_messages (
+    id           TEXT PRIMARY KEY,
+    category     TEXT,
+    severity     INTEGER,
+    suppress     BOOLEAN NOT NULL,
+    stations     TEXT[] NOT NULL,
+    message      TEXT NOT NULL,
+    message_html TEXT NOT NULL,
+    raised_at    TIMESTAMPTZ NOT NULL,
+    updated_at   TIMESTAMPTZ NOT NULL,
+    cleared_at   TIMESTAMPTZ
+);
+
+CREATE INDEX station_messages_stations_idx ON station_messages USING GIN (stations);
@@ -0,0 +1,269 @@
+package ndjson
+
+import (
+	"gemini-push-port/darwintime"
+	"gemini-push-port/pushport"
+	"strings"
+	"time"
+)
+
+// RecordVersion is incremented if a field of Record ever has to change meaning or be removed. Adding fields doesn't
+// change it.
+const RecordVersion = 1
+
+// Record is the JSON representation of a Push Port message, written one per line. Empty fields are left out.
+type Record struct {
+	Version int `json:"version"`
+	// ReceivedAt is when the message was consumed from Kafka
+	ReceivedAt time.Time `json:"receivedAt"`
+	// Timestamp is Darwin's timestamp for the message
+	Timestamp     time.Time `json:"timestamp"`
+	UpdateOrigin  string    `json:"updateOrigin,omitempty"`
+	RequestSource string    `json:"requestSource,omitempty"`
+	// Snapshot is set for messages which are part of a snapshot rather than an update
+	Snapshot bool     `json:"snapshot,omitempty"`
+	Updates  []Update `json:"updates"`
+}
+
+// Update is a single element of a message: a schedule, deactivation, association, TS or station message (OW), as
+// given by Type
+type Update struct {
+	Type    string `json:"type"`
+	RID     string `json:"rid,omitempty"`
+	UID     string `json:"uid,omitempty"`
+	TrainID string `json:"trainId,omitempty"`
+	// SSD is the service's scheduled start date, as YYYY-MM-DD
+	SSD string `json:"ssd,omitempty"`
+	TOC string `json:"toc,omitempty"`
+
+	// schedules only
+	PassengerService *bool   `json:"passengerService,omitempty"`
+	Deleted          bool    `json:"deleted,omitempty"`
+	CancelReason     *Reason `json:"cancelReason,omitempty"`
+
+	// TS only
+	LateReason *Reason `json:"lateReason,omitempty"`
+
+	// Locations are the calling points of a schedule, or the locations a TS updates
+	Locations      []Location      `json:"locations,omitempty"`
+	Association    *Association    `json:"association,omitempty"`
+	StationMessage *StationMessage `json:"stationMessage,omitempty"`
+}
+
+type Reason struct {
+	Code   string `json:"code"`
+	Tiploc string `json:"tiploc,omitempty"`
+	Near   bool   `json:"near,omitempty"`
+}
+
+// Location is a location in a schedule or TS. Darwin's HH:MM times are resolved to absolute times in the UK time zone,
+// taking into account services running past midnight.
+type Location struct {
+	Tiploc string `json:"tiploc"`
+	// Type is the schedule location type (OR, IP, PP, DT, OPOR, OPIP, OPDT), only given for schedules
+	Type              string `json:"type,omitempty"`
+	Cancelled         bool   `json:"cancelled,omitempty"`
+	Platform          string `json:"platform,omitempty"`
+	PlatformConfirmed bool   `json:"platformConfirmed,omitempty"`
+
+	WorkingArrival   *time.Time `json:"workingArrival,omitempty"`
+	WorkingDeparture *time.Time `json:"workingDeparture,omitempty"`
+	WorkingPass      *time.Time `json:"workingPass,omitempty"`
+	PublicArrival    *time.Time `json:"publicArrival,omitempty"`
+	PublicDeparture  *time.Time `json:"publicDeparture,omitempty"`
+
+	// forecasts and actuals, only given for TS
+	EstimatedArrival   *time.Time `json:"estimatedArrival,omitempty"`
+	ActualArrival      *time.Time `json:"actualArrival,omitempty"`
+	EstimatedDeparture *time.Time `json:"estimatedDeparture,omitempty"`
+	ActualDeparture    *time.Time `json:"actualDeparture,omitempty"`
+	EstimatedPass      *time.Time `json:"estimatedPass,omitempty"`
+	ActualPass         *time.Time `json:"actualPass,omitempty"`
+	Delayed            bool       `json:"delayed,omitempty"`
+}
+
+type Association struct {
+	// Category is JJ (join), VV (divide) or NP (next working)
+	Category  string `json:"category"`
+	Tiploc    string `json:"tiploc"`
+	MainRID   string `json:"mainRid"`
+	AssocRID  string `json:"assocRid"`
+	Cancelled bool   `json:"cancelled,omitempty"`
+	Deleted   bool   `json:"deleted,omitempty"`
+}
+
+type StationMessage struct {
+	ID       string `json:"id"`
+	Category string `json:"category,omitempty"`
+	Severity string `json:"severity,omitempty"`
+	// Stations are the CRS codes the message is shown at. It's empty when the message has been cleared.
+	Stations []string `json:"stations"`
+	Text     string   `json:"text"`
+}
+
+// NewRecord converts a parsed message to its JSON representation
+func NewRecord(msg *pushport.Message) Record {
+	response := msg.Pport.Response()
+	record := Record{
+		Version:       RecordVersion,
+		ReceivedAt:    msg.Raw.MessageTime.UTC(),
+		Timestamp:     msg.Time.UTC(),
+		UpdateOrigin:  response.UpdateOrigin,
+		RequestSource: response.RequestSource,
+		Snapshot:      msg.Pport.SnapshotResponse != nil,
+		Updates:       []Update{},
+	}
+
+	for i := range response.Schedules {
+		record.Updates = append(record.Updates, scheduleUpdate(&response.Schedules[i]))
+	}
+	for _, d := range response.Deactivated {
+		record.Updates = append(record.Updates, Update{Type: pushport.TypeDeactivated, RID: d.RID})
+	}
+	for _, a := range response.Associations {
+		record.Updates = append(record.Updates, Update{
+			Type: pushport.TypeAssociation,
+			RID:  a.Main.RID,
+			Association: &Association{
+				Category:  a.Category,
+				Tiploc:    a.Tiploc,
+				MainRID:   a.Main.RID,
+				AssocRID:  a.Assoc.RID,
+				Cancelled: a.IsCancelled,
+				Deleted:   a.IsDeleted,
+			},
+		})
+	}
+	for i := range response.TrainStatuses {
+		record.Updates = append(record.Updates, trainStatusUpdate(&response.TrainStatuses[i], msg.Time))
+	}
+	for _, ow := range response.StationMessages {
+		sm := &StationMessage{
+			ID:       ow.ID,
+			Category: ow.Category,
+			Severity: ow.Severity,
+			Stations: []string{},
+			Text:     ow.Msg.Text(),
+		}
+		for _, station := range ow.Stations {
+			sm.Stations = append(sm.Stations, strings.ToUpper(station.CRS))
+		}
+		record.Updates = append(record.Updates, Update{Type: pushport.TypeStationMessage, StationMessage: sm})
+	}
+
+	return record
+}
+
+func scheduleUpdate(s *pushport.Schedule) Update {
+	passengerService := s.PassengerService()
+	update := Update{
+		Type:             pushport.TypeSchedule,
+		RID:              s.RID,
+		UID:              s.UID,
+		TrainID:          s.TrainID,
+		SSD:              s.SSD,
+		TOC:              s.TOC,
+		PassengerService: &passengerService,
+		Deleted:          s.IsDeleted(),
+		CancelReason:     newReason(s.CancelReason),
+	}
+
+	ssd, err := darwintime.ParseSSD(s.SSD)
+	if err != nil {
+		return update
+	}
+	seq := darwintime.NewSequence(ssd)
+	for _, l := range s.Locations {
+		loc := Location{
+			Tiploc:           l.Tiploc,
+			Type:             l.Type,
+			Cancelled:        l.Cancelled,
+			Platform:         l.Platform,
+			WorkingArrival:   timePtr(seq.Next(l.Wta)),
+			WorkingPass:      timePtr(seq.Next(l.Wtp)),
+			WorkingDeparture: timePtr(seq.Next(l.Wtd)),
+		}
+		loc.PublicArrival = near(ssd, l.Pta, loc.WorkingArrival)
+		loc.PublicDeparture = near(ssd, l.Ptd, loc.WorkingDeparture)
+		update.Locations = append(update.Locations, loc)
+	}
+	return update
+}
+
+func trainStatusUpdate(ts *pushport.TrainStatus, msgTime time.Time) Update {
+	update := Update{
+		Type:       pushport.TypeTrainStatus,
+		RID:        ts.RID,
+		UID:        ts.UID,
+		SSD:        ts.SSD,
+		LateReason: newReason(ts.LateReason),
+	}
+
+	ssd, err := darwintime.ParseSSD(ts.SSD)
+	if err != nil {
+		return update
+	}
+	// a TS can start part way through the service, possibly after midnight
+	seq := darwintime.NewSequenceNear(ssd, msgTime)
+	for _, l := range ts.Locations {
+		loc := Location{
+			Tiploc:           l.Tiploc,
+			WorkingArrival:   timePtr(seq.Next(l.Wta)),
+			WorkingPass:      timePtr(seq.Next(l.Wtp)),
+			WorkingDeparture: timePtr(seq.Next(l.Wtd)),
+		}
+		loc.PublicArrival = near(ssd, l.Pta, loc.WorkingArrival)
+		loc.PublicDeparture = near(ssd, l.Ptd, loc.WorkingDeparture)
+		loc.EstimatedArrival, loc.ActualArrival = forecast(ssd, l.Arrival, loc.WorkingArrival)
+		loc.EstimatedDeparture, loc.ActualDeparture = forecast(ssd, l.Departure, loc.WorkingDeparture)
+		loc.EstimatedPass, loc.ActualPass = forecast(ssd, l.Pass, loc.WorkingPass)
+		for _, t := range []*pushport.TSTimeData{l.Arrival, l.Departure, l.Pass} {
+			if t != nil && t.Delayed {
+				loc.Delayed = true
+			}
+		}
+		if l.Platform != nil {
+			loc.Platform = l.Platform.Number
+			loc.PlatformConfirmed = l.Platform.Confirmed
+		}
+		update.Locations = append(update.Locations, loc)
+	}
+	return update
+}
+
+// forecast resolves the estimated and actual times of an arrival, departure or pass, near its working time
+func forecast(ssd time.Time, t *pushport.TSTimeData, working *time.Time) (*time.Time, *time.Time) {
+	if t == nil {
+		return nil, nil
+	}
+	estimated := t.Estimated
+	if estimated == "" {
+		estimated = t.WorkingEstimated
+	}
+	actual := t.Actual
+	if t.ActualRemoved {
+		actual = ""
+	}
+	return near(ssd, estimated, working), near(ssd, actual, working)
+}
+
+func near(ssd time.Time, clock string, reference *time.Time) *time.Time {
+	if reference == nil {
+		return nil
+	}
+	return timePtr(darwintime.Near(ssd, clock, *reference))
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func newReason(r *pushport.Reason) *Reason {
+	if r == nil {
+		return nil
+	}
+	return &Reason{Code: r.Code, Tiploc: r.Tiploc, Near: r.Near}
+}
@@ -0,0 +1,115 @@
+// Package ndjson writes every archived message as a line of JSON to hourly .ndjson files next to the .pport archives,
+// for consumers which would rather not parse XML. The files are uploaded and cleaned up along with the archives.
+package ndjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gemini-push-port/logging"
+	"gemini-push-port/pushport"
+	"gemini-push-port/rawstore"
+	"os"
+	"path"
+	"strconv"
+	"time"
+)
+
+const (
+	queueSize     = 100_000
+	maxBatchSize  = 1_000
+	flushInterval = time.Second
+)
+
+// Writer converts archived messages to JSON in batches, so it never holds up archiving
+type Writer struct {
+	workdir string
+	batcher *rawstore.Batcher[*rawstore.XmlMessageWithTime]
+}
+
+// EnabledFromEnv returns whether NDJSON_ENABLED is true
+func EnabledFromEnv() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("NDJSON_ENABLED"))
+	return enabled
+}
+
+// NewWriterFromEnv creates a writer which writes to the workdir, alongside the archives
+func NewWriterFromEnv() *Writer {
+	workdir := os.Getenv("PUSH_PORT_DUMP_WORKDIR")
+	if workdir == "" {
+		panic("PUSH_PORT_DUMP_WORKDIR environment variable not set")
+	}
+
+	w := &Writer{workdir: workdir}
+	w.batcher = rawstore.NewBatcher("NDJSON", queueSize, maxBatchSize, flushInterval, w.write)
+	return w
+}
+
+func (w *Writer) MessageArchived(msg *rawstore.XmlMessageWithTime, _ rawstore.ArchiveLocation) {
+	w.batcher.Add(msg)
+}
+
+// Thread writes queued messages until the writer is closed
+func (w *Writer) Thread() {
+	w.batcher.Thread()
+}
+
+// write appends a batch of messages to their hourly files. Each file gets a single write of whole lines, so the upload
+// job never sees half a line.
+func (w *Writer) write(batch []*rawstore.XmlMessageWithTime) {
+	lines := make(map[string]*bytes.Buffer)
+	var order []string
+
+	for _, raw := range batch {
+		msg, err := pushport.NewMessage(raw)
+		if err != nil {
+			// validated before it was archived, so this shouldn't happen
+			continue
+		}
+
+		filePath := raw.GetFilePathWithExtension(rawstore.NDJSONFileExtension)
+		buf, ok := lines[filePath]
+		if !ok {
+			buf = &bytes.Buffer{}
+			lines[filePath] = buf
+			order = append(order, filePath)
+		}
+
+		// Encode adds the newline
+		err = json.NewEncoder(buf).Encode(NewRecord(msg))
+		if err != nil {
+			logging.Logger.WarnE("failed to encode NDJSON record", err)
+		}
+	}
+
+	for _, filePath := range order {
+		err := w.appendToFile(filePath, lines[filePath].Bytes())
+		if err != nil {
+			logging.Logger.ErrorE(fmt.Sprintf("failed to append to %s", filePath), err)
+		}
+	}
+}
+
+func (w *Writer) appendToFile(filePath string, data []byte) error {
+	fullPath := path.Join(w.workdir, filePath)
+	err := os.MkdirAll(path.Dir(fullPath), 0755)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(fullPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	closeErr := f.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// Close stops queueing messages, and writes those already queued
+func (w *Writer) Close() {
+	w.batcher.Close()
+}
@@ -0,0 +1,79 @@
+// Package outbound makes HTTP requests to addresses given by API clients, such as webhook and SIRI subscription
+// URLs, without letting those clients reach services inside the network.
+package outbound
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+var ErrPrivateAddress = errors.New("url must not point at a loopback, link-local or private address")
+
+// AllowedAddr returns whether requests may be made to an address. Loopback, link-local, private and unspecified
+// addresses are rejected, so API clients can't use them to reach services inside the network.
+func AllowedAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() &&
+		!addr.IsLoopback() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() &&
+		!addr.IsInterfaceLocalMulticast() &&
+		!addr.IsPrivate() &&
+		!addr.IsUnspecified() &&
+		!addr.IsMulticast()
+}
+
+// CheckHost resolves a URL's host, and returns an error if any of its addresses aren't allowed
+func CheckHost(ctx context.Context, host string) error {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !AllowedAddr(addr) {
+			return ErrPrivateAddress
+		}
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("url host %q could not be resolved", host)
+	}
+	for _, addr := range addrs {
+		if !AllowedAddr(addr) {
+			return ErrPrivateAddress
+		}
+	}
+	return nil
+}
+
+// checkDial refuses connections to addresses which aren't allowed. Checking when connecting, rather than only when
+// the address is given, stops redirects and DNS records which change afterwards from reaching them.
+func checkDial(network string, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !AllowedAddr(addrPort.Addr()) {
+		return fmt.Errorf("connection to %s refused: loopback, link-local and private addresses aren't allowed", addrPort.Addr())
+	}
+	return nil
+}
+
+// NewClient creates a client which refuses to connect to disallowed addresses unless allowPrivate is set
+func NewClient(allowPrivate bool, timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}
+	if !allowPrivate {
+		dialer.Control = checkDial
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// connect directly, so the address check applies to the requested host rather than a proxy
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
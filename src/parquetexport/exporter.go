@@ -0,0 +1,317 @@
+// Package parquetexport turns each completed hour of the archive into Parquet files, one for each Push Port message
+// type plus a flattened table of TS locations, so the data can be queried without parsing XML.
+package parquetexport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gemini-push-port/logging"
+	"gemini-push-port/pushport"
+	"gemini-push-port/rawstore"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	defaultLookbackHours = 24
+	// how long after the end of an hour to wait before exporting it, so the last messages have been archived
+	exportDelay = 5 * time.Minute
+)
+
+// manifest is uploaded once every table for an hour has been, and marks the hour as exported
+type manifest struct {
+	Hour       time.Time        `json:"hour"`
+	Messages   int64            `json:"messages"`
+	Rows       map[string]int64 `json:"rows"`
+	ExportedAt time.Time        `json:"exportedAt"`
+}
+
+type Exporter struct {
+	archive    *rawstore.ArchiveReader
+	s3client   *s3.Client
+	bucketName string
+	prefix     string
+	workdir    string
+	lookback   time.Duration
+	enabled    bool
+}
+
+// NewExporterFromEnv creates an exporter which, when PARQUET_EXPORT_ENABLED is true, exports each of the last
+// PARQUET_EXPORT_LOOKBACK_HOURS hours (24 by default) which hasn't been already. Files are uploaded under
+// PARQUET_S3_PREFIX, which defaults to a parquet directory next to the raw archives.
+func NewExporterFromEnv(s3client *s3.Client, archive *rawstore.ArchiveReader) *Exporter {
+	enabled, _ := strconv.ParseBool(os.Getenv("PARQUET_EXPORT_ENABLED"))
+
+	prefix := os.Getenv("PARQUET_S3_PREFIX")
+	if prefix == "" {
+		prefix = path.Join(os.Getenv("S3_PUSH_PORT_DUMP_PATH_PREFIX"), "parquet")
+	}
+
+	lookbackHours, err := strconv.Atoi(os.Getenv("PARQUET_EXPORT_LOOKBACK_HOURS"))
+	if err != nil || lookbackHours < 1 {
+		lookbackHours = defaultLookbackHours
+	}
+
+	return &Exporter{
+		archive:    archive,
+		s3client:   s3client,
+		bucketName: os.Getenv("S3_COMPATIBLE_BUCKET_NAME"),
+		prefix:     prefix,
+		workdir:    os.Getenv("PUSH_PORT_DUMP_WORKDIR"),
+		lookback:   time.Duration(lookbackHours) * time.Hour,
+		enabled:    enabled,
+	}
+}
+
+func (e *Exporter) Enabled() bool {
+	return e.enabled
+}
+
+// ExportPending exports every completed hour in the lookback window which doesn't have a manifest yet
+func (e *Exporter) ExportPending(ctx context.Context, now time.Time) error {
+	lastComplete := now.UTC().Add(-exportDelay).Truncate(time.Hour).Add(-time.Hour)
+
+	for _, hour := range rawstore.HoursBetween(now.Add(-e.lookback), lastComplete) {
+		exported, err := e.exported(ctx, hour)
+		if err != nil {
+			return err
+		}
+		if exported {
+			continue
+		}
+
+		err = e.ExportHour(ctx, hour)
+		if errors.Is(err, rawstore.ErrArchiveNotFound) {
+			logging.Logger.Debugf("No archive to export to Parquet for %s", hour.Format("2006-01-02 15:00"))
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to export %s to Parquet: %v", hour.Format("2006-01-02 15:00"), err)
+		}
+	}
+	return nil
+}
+
+// ExportHour writes the archive for the hour starting at hour to Parquet, and uploads the files followed by the
+// hour's manifest
+func (e *Exporter) ExportHour(ctx context.Context, hour time.Time) error {
+	hour = hour.UTC()
+	start := time.Now()
+
+	tmpDir, err := os.MkdirTemp(e.workdir, "parquet-export-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	tables, messages, err := e.writeTables(ctx, hour, tmpDir)
+	if err != nil {
+		return err
+	}
+
+	m := manifest{Hour: hour, Messages: messages, Rows: make(map[string]int64)}
+	for _, table := range tables {
+		m.Rows[table.name] = table.rows
+		// partitions are only created for tables with rows, which Spark and friends treat the same as empty ones
+		if table.rows == 0 {
+			continue
+		}
+		err = e.uploadFile(ctx, table.path, e.tableKey(table.name, hour))
+		if err != nil {
+			return fmt.Errorf("failed to upload %s table: %v", table.name, err)
+		}
+	}
+
+	m.ExportedAt = time.Now().UTC()
+	body, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	err = e.putObject(ctx, e.manifestKey(hour), body)
+	if err != nil {
+		return fmt.Errorf("failed to upload manifest: %v", err)
+	}
+
+	logging.Logger.Infof("Exported %d messages for %s to Parquet in %v", messages, hour.Format("2006-01-02 15:00"), time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+func (e *Exporter) writeTables(ctx context.Context, hour time.Time, dir string) ([]writtenTable, int64, error) {
+	// if a writer can't be created, the ones created before it are closed on the way out
+	var writers []interface{ close() error }
+	defer func() {
+		for _, w := range writers {
+			_ = w.close()
+		}
+	}()
+
+	schedules, err := newTableWriter[scheduleRow](dir, tableSchedule)
+	if err != nil {
+		return nil, 0, err
+	}
+	writers = append(writers, schedules)
+	deactivated, err := newTableWriter[deactivatedRow](dir, tableDeactivated)
+	if err != nil {
+		return nil, 0, err
+	}
+	writers = append(writers, deactivated)
+	associations, err := newTableWriter[associationRow](dir, tableAssociation)
+	if err != nil {
+		return nil, 0, err
+	}
+	writers = append(writers, associations)
+	trainStatuses, err := newTableWriter[trainStatusRow](dir, tableTrainStatus)
+	if err != nil {
+		return nil, 0, err
+	}
+	writers = append(writers, trainStatuses)
+	tsLocations, err := newTableWriter[tsLocationRow](dir, tableTSLocation)
+	if err != nil {
+		return nil, 0, err
+	}
+	writers = append(writers, tsLocations)
+	stationMessages, err := newTableWriter[stationMessageRow](dir, tableStationMessage)
+	if err != nil {
+		return nil, 0, err
+	}
+	writers = append(writers, stationMessages)
+
+	var messages int64
+	readErr := e.archive.ReadHour(ctx, hour, func(raw *rawstore.XmlMessageWithTime) error {
+		msg, err := pushport.NewMessage(raw)
+		if err != nil {
+			return nil
+		}
+		messages++
+
+		response := msg.Pport.Response()
+		for i := range response.Schedules {
+			err = schedules.add(newScheduleRow(msg.Time, response, &response.Schedules[i]))
+			if err != nil {
+				return err
+			}
+		}
+		for _, d := range response.Deactivated {
+			err = deactivated.add(deactivatedRow{Time: msg.Time, UpdateOrigin: response.UpdateOrigin, RID: d.RID})
+			if err != nil {
+				return err
+			}
+		}
+		for i := range response.Associations {
+			err = associations.add(newAssociationRow(msg.Time, response, &response.Associations[i]))
+			if err != nil {
+				return err
+			}
+		}
+		for i := range response.TrainStatuses {
+			ts := &response.TrainStatuses[i]
+			err = trainStatuses.add(newTrainStatusRow(msg.Time, response, ts))
+			if err != nil {
+				return err
+			}
+			for seq := range ts.Locations {
+				err = tsLocations.add(newTSLocationRow(msg.Time, response, ts, seq, &ts.Locations[seq]))
+				if err != nil {
+					return err
+				}
+			}
+		}
+		for i := range response.StationMessages {
+			err = stationMessages.add(newStationMessageRow(msg.Time, response, &response.StationMessages[i]))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	// the files have to be closed whether or not the archive was read
+	closeErrs := []error{readErr}
+	for _, w := range writers {
+		closeErrs = append(closeErrs, w.close())
+	}
+	// they're closed now, so there's nothing left for the deferred close to do
+	writers = nil
+	if readErr != nil {
+		return nil, 0, readErr
+	}
+	if err = errors.Join(closeErrs...); err != nil {
+		return nil, 0, err
+	}
+
+	return []writtenTable{
+		schedules.written(),
+		deactivated.written(),
+		associations.written(),
+		trainStatuses.written(),
+		tsLocations.written(),
+		stationMessages.written(),
+	}, messages, nil
+}
+
+// tableKey returns where a table's file for an hour is uploaded, e.g. parquet/type=TS/date=2025-09-19/hour=14/part-0.parquet
+func (e *Exporter) tableKey(table string, hour time.Time) string {
+	return path.Join(e.prefix, "type="+table, "date="+hour.Format(time.DateOnly), "hour="+hour.Format("15"), "part-0.parquet")
+}
+
+// manifestKey is kept out of the type= partitions, so it isn't picked up as data
+func (e *Exporter) manifestKey(hour time.Time) string {
+	return path.Join(e.prefix, "_manifests", "date="+hour.Format(time.DateOnly), "hour="+hour.Format("15")+".json")
+}
+
+func (e *Exporter) exported(ctx context.Context, hour time.Time) (bool, error) {
+	_, err := e.s3client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(e.bucketName),
+		Key:    aws.String(e.manifestKey(hour)),
+	})
+	if err == nil {
+		return true, nil
+	}
+	var notFound *types.NotFound
+	if errors.As(err, &notFound) {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to check for Parquet manifest: %v", err)
+}
+
+func (e *Exporter) uploadFile(ctx context.Context, localPath string, key string) error {
+	file, err := os.Open(filepath.Clean(localPath))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = e.s3client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(e.bucketName),
+		Key:    aws.String(key),
+		Body:   file,
+	})
+	return err
+}
+
+func (e *Exporter) putObject(ctx context.Context, key string, body []byte) error {
+	_, err := e.s3client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(e.bucketName),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String("application/json"),
+	})
+	return err
+}
+
+func ExportJob(e *Exporter) {
+	err := e.ExportPending(context.Background(), time.Now())
+	if err != nil {
+		logging.Logger.ErrorE("failed to export archives to Parquet", err)
+	}
+}
@@ -0,0 +1,292 @@
+package parquetexport
+
+import (
+	"gemini-push-port/pushport"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Table names, used as the type= partition of each file. The message tables match the Push Port's element names.
+const (
+	tableSchedule       = pushport.TypeSchedule
+	tableDeactivated    = pushport.TypeDeactivated
+	tableAssociation    = pushport.TypeAssociation
+	tableTrainStatus    = pushport.TypeTrainStatus
+	tableTSLocation     = "TS_location"
+	tableStationMessage = pushport.TypeStationMessage
+)
+
+// Every row starts with the Darwin timestamp of the message it came from, and where the update came from. Optional
+// columns are null when Darwin leaves the attribute out.
+
+type scheduleRow struct {
+	Time               time.Time             `parquet:"time,timestamp(millisecond)"`
+	UpdateOrigin       string                `parquet:"update_origin,optional"`
+	RequestSource      string                `parquet:"request_source,optional"`
+	RID                string                `parquet:"rid"`
+	UID                string                `parquet:"uid"`
+	TrainID            string                `parquet:"train_id"`
+	RSID               string                `parquet:"rsid,optional"`
+	SSD                string                `parquet:"ssd"`
+	TOC                string                `parquet:"toc"`
+	Status             string                `parquet:"status,optional"`
+	TrainCat           string                `parquet:"train_cat,optional"`
+	IsPassengerSvc     bool                  `parquet:"is_passenger_svc"`
+	IsActive           bool                  `parquet:"is_active"`
+	IsCharter          bool                  `parquet:"is_charter"`
+	IsDeleted          bool                  `parquet:"is_deleted"`
+	CancelReasonCode   string                `parquet:"cancel_reason_code,optional"`
+	CancelReasonTiploc string                `parquet:"cancel_reason_tiploc,optional"`
+	DivertedVia        string                `parquet:"diverted_via,optional"`
+	Locations          []scheduleLocationRow `parquet:"locations,list"`
+}
+
+type scheduleLocationRow struct {
+	Type              string `parquet:"type"`
+	Tiploc            string `parquet:"tiploc"`
+	Activities        string `parquet:"activities,optional"`
+	PlannedActivities string `parquet:"planned_activities,optional"`
+	Cancelled         bool   `parquet:"cancelled"`
+	Platform          string `parquet:"platform,optional"`
+	Pta               string `parquet:"pta,optional"`
+	Ptd               string `parquet:"ptd,optional"`
+	Wta               string `parquet:"wta,optional"`
+	Wtd               string `parquet:"wtd,optional"`
+	Wtp               string `parquet:"wtp,optional"`
+	FalseDestination  string `parquet:"false_destination,optional"`
+}
+
+type deactivatedRow struct {
+	Time         time.Time `parquet:"time,timestamp(millisecond)"`
+	UpdateOrigin string    `parquet:"update_origin,optional"`
+	RID          string    `parquet:"rid"`
+}
+
+type associationRow struct {
+	Time         time.Time `parquet:"time,timestamp(millisecond)"`
+	UpdateOrigin string    `parquet:"update_origin,optional"`
+	Tiploc       string    `parquet:"tiploc"`
+	Category     string    `parquet:"category"`
+	IsCancelled  bool      `parquet:"is_cancelled"`
+	IsDeleted    bool      `parquet:"is_deleted"`
+	MainRID      string    `parquet:"main_rid"`
+	MainWta      string    `parquet:"main_wta,optional"`
+	MainWtd      string    `parquet:"main_wtd,optional"`
+	MainWtp      string    `parquet:"main_wtp,optional"`
+	MainPta      string    `parquet:"main_pta,optional"`
+	MainPtd      string    `parquet:"main_ptd,optional"`
+	AssocRID     string    `parquet:"assoc_rid"`
+	AssocWta     string    `parquet:"assoc_wta,optional"`
+	AssocWtd     string    `parquet:"assoc_wtd,optional"`
+	AssocWtp     string    `parquet:"assoc_wtp,optional"`
+	AssocPta     string    `parquet:"assoc_pta,optional"`
+	AssocPtd     string    `parquet:"assoc_ptd,optional"`
+}
+
+// trainStatusRow is a TS element without its locations, which are in the TS_location table
+type trainStatusRow struct {
+	Time               time.Time `parquet:"time,timestamp(millisecond)"`
+	UpdateOrigin       string    `parquet:"update_origin,optional"`
+	RequestSource      string    `parquet:"request_source,optional"`
+	RID                string    `parquet:"rid"`
+	UID                string    `parquet:"uid"`
+	SSD                string    `parquet:"ssd"`
+	IsReverseFormation bool      `parquet:"is_reverse_formation"`
+	LateReasonCode     string    `parquet:"late_reason_code,optional"`
+	LateReasonTiploc   string    `parquet:"late_reason_tiploc,optional"`
+	Locations          int32     `parquet:"locations"`
+}
+
+// tsLocationRow is a single location from a TS element, with its arrival, departure and pass flattened into columns
+type tsLocationRow struct {
+	Time                      time.Time `parquet:"time,timestamp(millisecond)"`
+	UpdateOrigin              string    `parquet:"update_origin,optional"`
+	RID                       string    `parquet:"rid"`
+	UID                       string    `parquet:"uid"`
+	SSD                       string    `parquet:"ssd"`
+	Seq                       int32     `parquet:"seq"`
+	Tiploc                    string    `parquet:"tiploc"`
+	Wta                       string    `parquet:"wta,optional"`
+	Wtd                       string    `parquet:"wtd,optional"`
+	Wtp                       string    `parquet:"wtp,optional"`
+	Pta                       string    `parquet:"pta,optional"`
+	Ptd                       string    `parquet:"ptd,optional"`
+	ArrivalEstimated          string    `parquet:"arrival_et,optional"`
+	ArrivalWorkingEstimated   string    `parquet:"arrival_wet,optional"`
+	ArrivalActual             string    `parquet:"arrival_at,optional"`
+	ArrivalActualRemoved      bool      `parquet:"arrival_at_removed"`
+	ArrivalDelayed            bool      `parquet:"arrival_delayed"`
+	ArrivalSource             string    `parquet:"arrival_src,optional"`
+	DepartureEstimated        string    `parquet:"departure_et,optional"`
+	DepartureWorkingEstimated string    `parquet:"departure_wet,optional"`
+	DepartureActual           string    `parquet:"departure_at,optional"`
+	DepartureActualRemoved    bool      `parquet:"departure_at_removed"`
+	DepartureDelayed          bool      `parquet:"departure_delayed"`
+	DepartureSource           string    `parquet:"departure_src,optional"`
+	PassEstimated             string    `parquet:"pass_et,optional"`
+	PassWorkingEstimated      string    `parquet:"pass_wet,optional"`
+	PassActual                string    `parquet:"pass_at,optional"`
+	PassActualRemoved         bool      `parquet:"pass_at_removed"`
+	PassDelayed               bool      `parquet:"pass_delayed"`
+	PassSource                string    `parquet:"pass_src,optional"`
+	Platform                  string    `parquet:"platform,optional"`
+	PlatformSuppressed        bool      `parquet:"platform_suppressed"`
+	PlatformConfirmed         bool      `parquet:"platform_confirmed"`
+	PlatformSource            string    `parquet:"platform_src,optional"`
+	Suppressed                bool      `parquet:"suppressed"`
+	Length                    string    `parquet:"length,optional"`
+	DetachFront               bool      `parquet:"detach_front"`
+}
+
+type stationMessageRow struct {
+	Time         time.Time `parquet:"time,timestamp(millisecond)"`
+	UpdateOrigin string    `parquet:"update_origin,optional"`
+	ID           string    `parquet:"id"`
+	Category     string    `parquet:"category,optional"`
+	Severity     int32     `parquet:"severity"`
+	Suppress     bool      `parquet:"suppress"`
+	// Stations is empty when the message has been cleared
+	Stations []string `parquet:"stations,list"`
+	Text     string   `parquet:"text"`
+	HTML     string   `parquet:"html"`
+}
+
+func newScheduleRow(msgTime time.Time, response *pushport.DataResponse, s *pushport.Schedule) scheduleRow {
+	row := scheduleRow{
+		Time:           msgTime,
+		UpdateOrigin:   response.UpdateOrigin,
+		RequestSource:  response.RequestSource,
+		RID:            s.RID,
+		UID:            s.UID,
+		TrainID:        s.TrainID,
+		RSID:           s.RSID,
+		SSD:            s.SSD,
+		TOC:            s.TOC,
+		Status:         s.Status,
+		TrainCat:       s.TrainCat,
+		IsPassengerSvc: s.PassengerService(),
+		IsActive:       s.Active(),
+		IsCharter:      s.Charter(),
+		IsDeleted:      s.IsDeleted(),
+		DivertedVia:    s.DivertedVia,
+		Locations:      make([]scheduleLocationRow, 0, len(s.Locations)),
+	}
+	if s.CancelReason != nil {
+		row.CancelReasonCode = s.CancelReason.Code
+		row.CancelReasonTiploc = s.CancelReason.Tiploc
+	}
+	for _, l := range s.Locations {
+		row.Locations = append(row.Locations, scheduleLocationRow{
+			Type:              l.Type,
+			Tiploc:            l.Tiploc,
+			Activities:        l.Activities,
+			PlannedActivities: l.PlannedActivities,
+			Cancelled:         l.Cancelled,
+			Platform:          l.Platform,
+			Pta:               l.Pta,
+			Ptd:               l.Ptd,
+			Wta:               l.Wta,
+			Wtd:               l.Wtd,
+			Wtp:               l.Wtp,
+			FalseDestination:  l.FalseDestination,
+		})
+	}
+	return row
+}
+
+func newAssociationRow(msgTime time.Time, response *pushport.DataResponse, a *pushport.Association) associationRow {
+	return associationRow{
+		Time:         msgTime,
+		UpdateOrigin: response.UpdateOrigin,
+		Tiploc:       a.Tiploc,
+		Category:     a.Category,
+		IsCancelled:  a.IsCancelled,
+		IsDeleted:    a.IsDeleted,
+		MainRID:      a.Main.RID,
+		MainWta:      a.Main.Wta,
+		MainWtd:      a.Main.Wtd,
+		MainWtp:      a.Main.Wtp,
+		MainPta:      a.Main.Pta,
+		MainPtd:      a.Main.Ptd,
+		AssocRID:     a.Assoc.RID,
+		AssocWta:     a.Assoc.Wta,
+		AssocWtd:     a.Assoc.Wtd,
+		AssocWtp:     a.Assoc.Wtp,
+		AssocPta:     a.Assoc.Pta,
+		AssocPtd:     a.Assoc.Ptd,
+	}
+}
+
+func newTrainStatusRow(msgTime time.Time, response *pushport.DataResponse, ts *pushport.TrainStatus) trainStatusRow {
+	row := trainStatusRow{
+		Time:               msgTime,
+		UpdateOrigin:       response.UpdateOrigin,
+		RequestSource:      response.RequestSource,
+		RID:                ts.RID,
+		UID:                ts.UID,
+		SSD:                ts.SSD,
+		IsReverseFormation: ts.IsReverseFormation,
+		Locations:          int32(len(ts.Locations)),
+	}
+	if ts.LateReason != nil {
+		row.LateReasonCode = ts.LateReason.Code
+		row.LateReasonTiploc = ts.LateReason.Tiploc
+	}
+	return row
+}
+
+func newTSLocationRow(msgTime time.Time, response *pushport.DataResponse, ts *pushport.TrainStatus, seq int, l *pushport.TSLocation) tsLocationRow {
+	row := tsLocationRow{
+		Time:         msgTime,
+		UpdateOrigin: response.UpdateOrigin,
+		RID:          ts.RID,
+		UID:          ts.UID,
+		SSD:          ts.SSD,
+		Seq:          int32(seq),
+		Tiploc:       l.Tiploc,
+		Wta:          l.Wta,
+		Wtd:          l.Wtd,
+		Wtp:          l.Wtp,
+		Pta:          l.Pta,
+		Ptd:          l.Ptd,
+		Suppressed:   l.Suppressed,
+		Length:       l.Length,
+		DetachFront:  l.DetachFront,
+	}
+	if a := l.Arrival; a != nil {
+		row.ArrivalEstimated, row.ArrivalWorkingEstimated, row.ArrivalActual = a.Estimated, a.WorkingEstimated, a.Actual
+		row.ArrivalActualRemoved, row.ArrivalDelayed, row.ArrivalSource = a.ActualRemoved, a.Delayed, a.Source
+	}
+	if d := l.Departure; d != nil {
+		row.DepartureEstimated, row.DepartureWorkingEstimated, row.DepartureActual = d.Estimated, d.WorkingEstimated, d.Actual
+		row.DepartureActualRemoved, row.DepartureDelayed, row.DepartureSource = d.ActualRemoved, d.Delayed, d.Source
+	}
+	if p := l.Pass; p != nil {
+		row.PassEstimated, row.PassWorkingEstimated, row.PassActual = p.Estimated, p.WorkingEstimated, p.Actual
+		row.PassActualRemoved, row.PassDelayed, row.PassSource = p.ActualRemoved, p.Delayed, p.Source
+	}
+	if p := l.Platform; p != nil {
+		row.Platform, row.PlatformSuppressed, row.PlatformConfirmed, row.PlatformSource = p.Number, p.Suppressed, p.Confirmed, p.Source
+	}
+	return row
+}
+
+func newStationMessageRow(msgTime time.Time, response *pushport.DataResponse, ow *pushport.StationMessage) stationMessageRow {
+	severity, _ := strconv.Atoi(ow.Severity)
+	row := stationMessageRow{
+		Time:         msgTime,
+		UpdateOrigin: response.UpdateOrigin,
+		ID:           ow.ID,
+		Category:     ow.Category,
+		Severity:     int32(severity),
+		Suppress:     ow.Suppress,
+		Stations:     make([]string, 0, len(ow.Stations)),
+		Text:         ow.Msg.Text(),
+		HTML:         strings.TrimSpace(ow.Msg.InnerXML),
+	}
+	for _, station := range ow.Stations {
+		row.Stations = append(row.Stations, strings.ToUpper(station.CRS))
+	}
+	return row
+}
@@ -0,0 +1,83 @@
+package parquetexport
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// rows are buffered and written in groups of this many, so an hour's rows are never all held in memory at once
+const writeBufferSize = 10_000
+
+// tableWriter writes one table's rows for an hour to a local Parquet file
+type tableWriter[T any] struct {
+	name   string
+	path   string
+	file   *os.File
+	writer *parquet.GenericWriter[T]
+	buffer []T
+	rows   int64
+}
+
+func newTableWriter[T any](dir string, name string) (*tableWriter[T], error) {
+	filePath := filepath.Join(dir, name+".parquet")
+	file, err := os.Create(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tableWriter[T]{
+		name:   name,
+		path:   filePath,
+		file:   file,
+		writer: parquet.NewGenericWriter[T](file, parquet.Compression(&parquet.Snappy)),
+		buffer: make([]T, 0, writeBufferSize),
+	}, nil
+}
+
+func (t *tableWriter[T]) add(row T) error {
+	t.buffer = append(t.buffer, row)
+	t.rows++
+	if len(t.buffer) < writeBufferSize {
+		return nil
+	}
+	return t.flush()
+}
+
+func (t *tableWriter[T]) flush() error {
+	if len(t.buffer) == 0 {
+		return nil
+	}
+	_, err := t.writer.Write(t.buffer)
+	if err != nil {
+		return fmt.Errorf("failed to write %s rows: %v", t.name, err)
+	}
+	clear(t.buffer)
+	t.buffer = t.buffer[:0]
+	return nil
+}
+
+func (t *tableWriter[T]) close() error {
+	err := t.flush()
+	if err == nil {
+		err = t.writer.Close()
+	}
+	closeErr := t.file.Close()
+	if err != nil {
+		return fmt.Errorf("failed to finish %s file: %v", t.name, err)
+	}
+	return closeErr
+}
+
+// writtenTable is what the exporter needs to know about a finished table to upload it
+type writtenTable struct {
+	name string
+	path string
+	rows int64
+}
+
+func (t *tableWriter[T]) written() writtenTable {
+	return writtenTable{name: t.name, path: t.path, rows: t.rows}
+}
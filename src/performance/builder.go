@@ -0,0 +1,117 @@
+package performance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gemini-push-port/darwintime"
+	"gemini-push-port/logging"
+	"gemini-push-port/pushport"
+	"gemini-push-port/rawstore"
+	"gemini-push-port/refdata"
+	"gemini-push-port/trainstate"
+	"time"
+)
+
+// services are counted once this long has passed since the start of the day they started on, by when even those
+// running past midnight have finished
+const settleAfter = 36 * time.Hour
+
+// DefaultLookbehind is how long before the first day the archive is read from, so services pick up the schedules
+// Darwin sends ahead of the day they run
+const DefaultLookbehind = 24 * time.Hour
+
+// Builder replays the archive to work out how punctual services were
+type Builder struct {
+	archive    *rawstore.ArchiveReader
+	names      *refdata.Store
+	lookbehind time.Duration
+}
+
+func NewBuilder(archive *rawstore.ArchiveReader, names *refdata.Store, lookbehind time.Duration) *Builder {
+	return &Builder{
+		archive:    archive,
+		names:      names,
+		lookbehind: lookbehind,
+	}
+}
+
+// Build reports on the services which started between from and to, which are dates in YYYY-MM-DD form. Every archive
+// hour from the lookbehind before from until the services have all finished is replayed through a train state
+// engine, and each service is counted once it's finished with.
+func (b *Builder) Build(ctx context.Context, from string, to string) (Report, error) {
+	fromDate, err := darwintime.ParseSSD(from)
+	if err != nil {
+		return Report{}, fmt.Errorf("invalid date %q", from)
+	}
+	toDate, err := darwintime.ParseSSD(to)
+	if err != nil {
+		return Report{}, fmt.Errorf("invalid date %q", to)
+	}
+	if toDate.Before(fromDate) {
+		return Report{}, errors.New("the end date is before the start date")
+	}
+
+	end := toDate.Add(settleAfter)
+	if now := time.Now(); end.After(now) {
+		end = now
+	}
+
+	engine := trainstate.NewEngine(0)
+	totals := newAggregator()
+	var missing []time.Time
+
+	// counts and removes the services which have finished by the end of the given hour
+	settle := func(hour time.Time) {
+		cutoff := hour.Add(time.Hour - settleAfter).In(darwintime.UK).Format(time.DateOnly)
+		finished := engine.Services(func(svc *trainstate.Service) bool {
+			return svc.SSD <= cutoff
+		})
+		for i := range finished {
+			if finished[i].SSD >= from && finished[i].SSD <= to {
+				totals.add(&finished[i])
+			}
+			engine.Remove(finished[i].RID)
+		}
+	}
+
+	hours := rawstore.HoursBetween(fromDate.Add(-b.lookbehind), end)
+	for i, hour := range hours {
+		err := b.archive.ReadHour(ctx, hour, func(raw *rawstore.XmlMessageWithTime) error {
+			msg, err := pushport.NewMessage(raw)
+			if err != nil {
+				return nil
+			}
+			engine.HandleMessage(msg)
+			return nil
+		})
+		if errors.Is(err, rawstore.ErrArchiveNotFound) {
+			missing = append(missing, hour)
+		} else if err != nil {
+			return Report{}, err
+		}
+
+		settle(hour)
+		if (i+1)%24 == 0 {
+			logging.Logger.Infof("Read %d of %d archive hours for the performance report", i+1, len(hours))
+		}
+	}
+
+	// count whatever's left, which only happens when the report runs up to the present
+	for _, svc := range engine.Services(func(*trainstate.Service) bool { return true }) {
+		if svc.SSD >= from && svc.SSD <= to {
+			totals.add(&svc)
+		}
+	}
+
+	if len(missing) > 0 {
+		logging.Logger.Warnf("%d of %d archive hours were missing, so the report may be incomplete", len(missing), len(hours))
+	}
+
+	report := totals.report(b.names)
+	report.From = from
+	report.To = to
+	report.GeneratedAt = time.Now().UTC()
+	report.MissingHours = missing
+	return report, nil
+}
@@ -0,0 +1,121 @@
+package performance
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+var csvHeader = []string{
+	"level", "toc", "operator", "tiploc", "crs", "name", "origin", "departure", "destination",
+	"planned", "cancelled", "recorded", "on_time", "t3", "t15",
+	"on_time_percent", "t3_percent", "t15_percent", "cancelled_percent",
+}
+
+// Write writes the report as JSON, or as CSV with a row for each service, operator and station. The level column says
+// which kind of row it is, and the columns which don't apply to it are left empty.
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r)
+	case FormatCSV:
+		return r.writeCSV(csv.NewWriter(w))
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+func (r *Report) writeCSV(w *csv.Writer) error {
+	err := w.Write(csvHeader)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range r.Services {
+		err = w.Write(append([]string{"service", s.TOC, s.Operator, "", "", "", s.OriginName, s.Departure, s.DestinationName}, s.Stats.csv()...))
+		if err != nil {
+			return err
+		}
+	}
+	for _, o := range r.Operators {
+		err = w.Write(append([]string{"operator", o.TOC, o.Operator, "", "", "", "", "", ""}, o.Stats.csv()...))
+		if err != nil {
+			return err
+		}
+	}
+	for _, s := range r.Stations {
+		err = w.Write(append([]string{"station", "", "", s.Tiploc, s.CRS, s.Name, "", "", ""}, s.Stats.csv()...))
+		if err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+func (s *Stats) csv() []string {
+	return []string{
+		strconv.Itoa(s.Planned), strconv.Itoa(s.Cancelled), strconv.Itoa(s.Recorded),
+		strconv.Itoa(s.OnTime), strconv.Itoa(s.T3), strconv.Itoa(s.T15),
+		formatPercent(s.OnTimePercent), formatPercent(s.T3Percent), formatPercent(s.T15Percent), formatPercent(s.CancelledPercent),
+	}
+}
+
+func formatPercent(p float64) string {
+	return strconv.FormatFloat(p, 'f', 1, 64)
+}
+
+// Upload writes the report to the bucket under REPORTS_S3_PREFIX, which defaults to a reports directory next to the
+// raw archives, returning the key it was written to
+func (r *Report) Upload(ctx context.Context, s3client *s3.Client, format string) (string, error) {
+	bucketName := os.Getenv("S3_COMPATIBLE_BUCKET_NAME")
+	if s3client == nil || bucketName == "" {
+		return "", errors.New("no bucket is configured to upload to")
+	}
+
+	prefix := os.Getenv("REPORTS_S3_PREFIX")
+	if prefix == "" {
+		prefix = path.Join(os.Getenv("S3_PUSH_PORT_DUMP_PATH_PREFIX"), "reports")
+	}
+
+	var body bytes.Buffer
+	err := r.Write(&body, format)
+	if err != nil {
+		return "", err
+	}
+
+	contentType := "text/csv"
+	if format == FormatJSON {
+		contentType = "application/json"
+	}
+
+	key := path.Join(prefix, fmt.Sprintf("performance-%s-%s.%s", r.From, r.To, format))
+	_, err = s3client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(bucketName),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(body.Bytes()),
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload report: %v", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,241 @@
+package performance
+
+import (
+	"cmp"
+	"gemini-push-port/pushport"
+	"gemini-push-port/refdata"
+	"gemini-push-port/trainstate"
+	"slices"
+	"time"
+)
+
+// Thresholds for the industry's punctuality measures. A train is on time if it's less than a minute late, and T-3 or
+// T-15 if it's less than 3 or 15 minutes late. Each includes the ones before it, so on time trains are T-3 too.
+const (
+	onTimeThreshold = time.Minute
+	t3Threshold     = 3 * time.Minute
+	t15Threshold    = 15 * time.Minute
+)
+
+// Stats counts the outcomes of planned calls. Recorded calls are the ones with an actual time, and the percentages
+// are of those, apart from CancelledPercent which is of every planned call.
+type Stats struct {
+	Planned          int     `json:"planned"`
+	Cancelled        int     `json:"cancelled"`
+	Recorded         int     `json:"recorded"`
+	OnTime           int     `json:"onTime"`
+	T3               int     `json:"t3"`
+	T15              int     `json:"t15"`
+	OnTimePercent    float64 `json:"onTimePercent"`
+	T3Percent        float64 `json:"t3Percent"`
+	T15Percent       float64 `json:"t15Percent"`
+	CancelledPercent float64 `json:"cancelledPercent"`
+}
+
+// ServiceStats is the punctuality at its destination of a service which runs on several days. Services are told
+// apart by their operator, origin, departure time and destination, as UIDs change between timetables.
+type ServiceStats struct {
+	TOC             string `json:"toc"`
+	Operator        string `json:"operator"`
+	Origin          string `json:"origin"`
+	OriginName      string `json:"originName"`
+	Departure       string `json:"departure"`
+	Destination     string `json:"destination"`
+	DestinationName string `json:"destinationName"`
+	Stats
+}
+
+// OperatorStats is the punctuality of all of an operator's services at their destinations
+type OperatorStats struct {
+	TOC      string `json:"toc"`
+	Operator string `json:"operator"`
+	Stats
+}
+
+// StationStats is the punctuality of every call at a station, by arrival, or by departure where services start
+type StationStats struct {
+	Tiploc string `json:"tiploc"`
+	CRS    string `json:"crs,omitempty"`
+	Name   string `json:"name"`
+	Stats
+}
+
+type Report struct {
+	From        string          `json:"from"`
+	To          string          `json:"to"`
+	GeneratedAt time.Time       `json:"generatedAt"`
+	Services    []ServiceStats  `json:"services"`
+	Operators   []OperatorStats `json:"operators"`
+	Stations    []StationStats  `json:"stations"`
+	// MissingHours are the archive hours which couldn't be found, so services in them may be missing or incomplete
+	MissingHours []time.Time `json:"missingHours,omitempty"`
+}
+
+type serviceKey struct {
+	toc         string
+	origin      string
+	departure   string
+	destination string
+}
+
+// aggregator adds up the outcomes of services as they're finished with
+type aggregator struct {
+	services  map[serviceKey]*Stats
+	operators map[string]*Stats
+	stations  map[string]*Stats
+}
+
+func newAggregator() *aggregator {
+	return &aggregator{
+		services:  make(map[serviceKey]*Stats),
+		operators: make(map[string]*Stats),
+		stations:  make(map[string]*Stats),
+	}
+}
+
+// add counts a service's calls. Only passenger services are counted, and only at locations with public times.
+func (a *aggregator) add(svc *trainstate.Service) {
+	if !svc.HasSchedule || !svc.IsPassengerSvc || svc.IsDeleted {
+		return
+	}
+
+	var calls []int
+	for i, loc := range svc.Locations {
+		if loc.Type == pushport.LocationTypePassing || loc.Pta == "" && loc.Ptd == "" {
+			continue
+		}
+		calls = append(calls, i)
+	}
+	if len(calls) < 2 {
+		return
+	}
+
+	times := svc.Times()
+	for n, i := range calls {
+		loc := &svc.Locations[i]
+		scheduled, actual := times[i].ScheduledArrival, times[i].ActualArrival
+		if n == 0 {
+			scheduled, actual = times[i].ScheduledDeparture, times[i].ActualDeparture
+		}
+		cancelled := svc.IsCancelled || loc.Cancelled
+
+		a.station(loc.Tiploc).count(cancelled, scheduled, actual)
+		if n == len(calls)-1 {
+			origin := &svc.Locations[calls[0]]
+			key := serviceKey{toc: svc.TOC, origin: origin.Tiploc, departure: origin.Ptd, destination: loc.Tiploc}
+			a.service(key).count(cancelled, scheduled, actual)
+			a.operator(svc.TOC).count(cancelled, scheduled, actual)
+		}
+	}
+}
+
+func (a *aggregator) service(key serviceKey) *Stats {
+	return getOrAdd(a.services, key)
+}
+
+func (a *aggregator) operator(toc string) *Stats {
+	return getOrAdd(a.operators, toc)
+}
+
+func (a *aggregator) station(tiploc string) *Stats {
+	return getOrAdd(a.stations, tiploc)
+}
+
+func getOrAdd[K comparable](m map[K]*Stats, key K) *Stats {
+	s, ok := m[key]
+	if !ok {
+		s = &Stats{}
+		m[key] = s
+	}
+	return s
+}
+
+func (s *Stats) count(cancelled bool, scheduled time.Time, actual time.Time) {
+	s.Planned++
+	switch {
+	case cancelled:
+		s.Cancelled++
+	case actual.IsZero() || scheduled.IsZero():
+		// nothing was reported, so the call can't be measured either way
+	default:
+		s.Recorded++
+		late := actual.Sub(scheduled)
+		if late < onTimeThreshold {
+			s.OnTime++
+		}
+		if late < t3Threshold {
+			s.T3++
+		}
+		if late < t15Threshold {
+			s.T15++
+		}
+	}
+}
+
+func (s Stats) withPercentages() Stats {
+	s.OnTimePercent = percent(s.OnTime, s.Recorded)
+	s.T3Percent = percent(s.T3, s.Recorded)
+	s.T15Percent = percent(s.T15, s.Recorded)
+	s.CancelledPercent = percent(s.Cancelled, s.Planned)
+	return s
+}
+
+func percent(n int, of int) float64 {
+	if of == 0 {
+		return 0
+	}
+	// to one decimal place
+	return float64(n*1000/of) / 10
+}
+
+// report names everything counted, and sorts services by operator, origin and departure time, and operators and
+// stations by name
+func (a *aggregator) report(names *refdata.Store) Report {
+	var r Report
+
+	for key, stats := range a.services {
+		r.Services = append(r.Services, ServiceStats{
+			TOC:             key.toc,
+			Operator:        names.OperatorName(key.toc),
+			Origin:          key.origin,
+			OriginName:      names.LocationName(key.origin),
+			Departure:       key.departure,
+			Destination:     key.destination,
+			DestinationName: names.LocationName(key.destination),
+			Stats:           stats.withPercentages(),
+		})
+	}
+	slices.SortFunc(r.Services, func(x, y ServiceStats) int {
+		return cmp.Or(
+			cmp.Compare(x.TOC, y.TOC),
+			cmp.Compare(x.OriginName, y.OriginName),
+			cmp.Compare(x.Departure, y.Departure),
+			cmp.Compare(x.DestinationName, y.DestinationName),
+		)
+	})
+
+	for toc, stats := range a.operators {
+		r.Operators = append(r.Operators, OperatorStats{
+			TOC:      toc,
+			Operator: names.OperatorName(toc),
+			Stats:    stats.withPercentages(),
+		})
+	}
+	slices.SortFunc(r.Operators, func(x, y OperatorStats) int {
+		return cmp.Compare(x.Operator, y.Operator)
+	})
+
+	for tiploc, stats := range a.stations {
+		r.Stations = append(r.Stations, StationStats{
+			Tiploc: tiploc,
+			CRS:    names.CRS(tiploc),
+			Name:   names.LocationName(tiploc),
+			Stats:  stats.withPercentages(),
+		})
+	}
+	slices.SortFunc(r.Stations, func(x, y StationStats) int {
+		return cmp.Or(cmp.Compare(x.Name, y.Name), cmp.Compare(x.Tiploc, y.Tiploc))
+	})
+
+	return r
+}
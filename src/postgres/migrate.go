@@ -0,0 +1,41 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"gemini-push-port/logging"
+
+	"github.com/golang-migrate/migrate/v4"
+	pgxmigrate "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// Migrate applies every migration in migrationsPath which hasn't been applied to the database yet
+func Migrate(pool *pgxpool.Pool, migrationsPath string) error {
+	db := stdlib.OpenDBFromPool(pool)
+	defer db.Close()
+
+	driver, err := pgxmigrate.WithInstance(db, &pgxmigrate.Config{})
+	if err != nil {
+		return fmt.Errorf("failed to create migration driver: %v", err)
+	}
+
+	m, err := migrate.NewWithDatabaseInstance("file://"+migrationsPath, "postgres", driver)
+	if err != nil {
+		return fmt.Errorf("failed to load migrations from %s: %v", migrationsPath, err)
+	}
+
+	err = m.Up()
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to migrate database: %v", err)
+	}
+
+	version, _, _ := m.Version()
+	logging.Logger.Infof("Migrated database to version %d", version)
+	return nil
+}
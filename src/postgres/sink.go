@@ -0,0 +1,166 @@
+// Package postgres persists the parsed Push Port stream to PostgreSQL: schedules and their cancellations, the latest
+// forecasts and actuals at each location, associations and station messages.
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gemini-push-port/logging"
+	"gemini-push-port/metrics"
+	"gemini-push-port/pushport"
+	"gemini-push-port/rawstore"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	defaultMigrationsPath = "migrations"
+	defaultBatchSize      = 500
+	defaultFlushInterval  = time.Second
+	defaultQueueSize      = 10_000
+	writeTimeout          = 30 * time.Second
+)
+
+// Sink upserts messages into the database in batches, so a slow database never holds up the other handlers
+type Sink struct {
+	pool    *pgxpool.Pool
+	batcher *rawstore.Batcher[*pushport.Message]
+}
+
+// NewSinkFromEnv connects to the database at POSTGRES_URL and applies the migrations in POSTGRES_MIGRATIONS_PATH
+// (migrations by default). Returns nil if POSTGRES_URL isn't set, in which case nothing is persisted.
+//
+// Up to POSTGRES_BATCH_SIZE messages (500 by default) are written in each transaction, at least every
+// POSTGRES_FLUSH_INTERVAL (1s by default), and up to POSTGRES_QUEUE_SIZE messages (10,000 by default) are queued.
+func NewSinkFromEnv(ctx context.Context) (*Sink, error) {
+	url := os.Getenv("POSTGRES_URL")
+	if url == "" {
+		return nil, nil
+	}
+
+	migrationsPath := os.Getenv("POSTGRES_MIGRATIONS_PATH")
+	if migrationsPath == "" {
+		migrationsPath = defaultMigrationsPath
+	}
+
+	batchSize, err := strconv.Atoi(os.Getenv("POSTGRES_BATCH_SIZE"))
+	if err != nil || batchSize < 1 {
+		batchSize = defaultBatchSize
+	}
+
+	flushInterval, err := time.ParseDuration(os.Getenv("POSTGRES_FLUSH_INTERVAL"))
+	if err != nil || flushInterval <= 0 {
+		flushInterval = defaultFlushInterval
+	}
+
+	queueSize, err := strconv.Atoi(os.Getenv("POSTGRES_QUEUE_SIZE"))
+	if err != nil || queueSize < 1 {
+		queueSize = defaultQueueSize
+	}
+
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %v", err)
+	}
+	err = pool.Ping(ctx)
+	if err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to connect to database: %v", err)
+	}
+
+	err = Migrate(pool, migrationsPath)
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	return NewSink(pool, batchSize, flushInterval, queueSize), nil
+}
+
+func NewSink(pool *pgxpool.Pool, batchSize int, flushInterval time.Duration, queueSize int) *Sink {
+	s := &Sink{pool: pool}
+	s.batcher = rawstore.NewBatcher("database", queueSize, batchSize, flushInterval, s.write)
+	return s
+}
+
+func (s *Sink) HandleMessage(msg *pushport.Message) {
+	response := msg.Pport.Response()
+	if len(response.Schedules) == 0 && len(response.Deactivated) == 0 && len(response.Associations) == 0 &&
+		len(response.TrainStatuses) == 0 && len(response.StationMessages) == 0 {
+		return
+	}
+
+	s.batcher.Add(msg)
+}
+
+// Thread writes queued messages to the database until the sink is closed
+func (s *Sink) Thread() {
+	s.batcher.Thread()
+}
+
+// write stores a batch of messages in a single transaction, in the order they were received. If the database rejects
+// a statement, the batch is written again a message at a time, so only the message it came from is lost.
+func (s *Sink) write(messages []*pushport.Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+
+	batch := &pgx.Batch{}
+	for _, msg := range messages {
+		queueMessage(batch, msg)
+	}
+
+	start := time.Now()
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		return tx.SendBatch(ctx, batch).Close()
+	})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		logging.Logger.WarnE(fmt.Sprintf("failed to write %d messages to the database, retrying them one at a time", len(messages)), err)
+		err = s.writeEach(ctx, messages)
+	}
+	if err != nil {
+		logging.Logger.ErrorE(fmt.Sprintf("failed to write %d messages to the database", len(messages)), err)
+		return
+	}
+	logging.Logger.Debugf("Wrote %d messages (%d statements) to the database in %v", len(messages), batch.Len(), time.Since(start))
+}
+
+// writeEach stores messages in a single transaction with a savepoint around each one, skipping messages whose
+// statements are rejected. Any other error, such as losing the connection, fails the whole transaction.
+func (s *Sink) writeEach(ctx context.Context, messages []*pushport.Message) error {
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		for _, msg := range messages {
+			batch := &pgx.Batch{}
+			queueMessage(batch, msg)
+
+			err := pgx.BeginFunc(ctx, tx, func(savepoint pgx.Tx) error {
+				return savepoint.SendBatch(ctx, batch).Close()
+			})
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) {
+				metrics.DatabaseWriteFailures.WithLabelValues(metrics.Topic()).Inc()
+				response := msg.Pport.Response()
+				logging.Logger.ErrorE(fmt.Sprintf("failed to write %s message from %s for %s to the database, skipping it",
+					strings.Join(response.MessageTypes(), ", "), msg.Time.Format(time.RFC3339), strings.Join(response.RIDs(), ", ")), err)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Close stops queueing messages, writes those already queued, and disconnects from the database
+func (s *Sink) Close() {
+	s.batcher.Close()
+	s.pool.Close()
+}
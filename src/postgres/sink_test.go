@@ -0,0 +1,281 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"gemini-push-port/internal/testfixtures"
+	"gemini-push-port/pushport"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// These tests need a PostgreSQL database, given by POSTGRES_TEST_URL, and are skipped without one. Each test migrates
+// and writes to its own schema, which is dropped afterwards.
+
+func TestMain(m *testing.M) {
+	testfixtures.Main(m, "postgres-test")
+}
+
+func newTestSink(t *testing.T) *Sink {
+	t.Helper()
+	url := os.Getenv("POSTGRES_TEST_URL")
+	if url == "" {
+		t.Skip("POSTGRES_TEST_URL not set")
+	}
+	ctx := context.Background()
+
+	admin, err := pgx.Connect(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := fmt.Sprintf("pushport_test_%d", time.Now().UnixNano())
+	_, err = admin.Exec(ctx, "CREATE SCHEMA "+schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_, err := admin.Exec(ctx, "DROP SCHEMA "+schema+" CASCADE")
+		if err != nil {
+			t.Errorf("failed to drop schema %s: %v", schema, err)
+		}
+		_ = admin.Close(ctx)
+	})
+
+	config, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.ConnConfig.RuntimeParams["search_path"] = schema
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+
+	err = Migrate(pool, "../migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewSink(pool, defaultBatchSize, defaultFlushInterval, defaultQueueSize)
+}
+
+// fixtureSchedule is the fixture archive's schedule for 1A02, which has five locations
+func fixtureSchedule(t *testing.T) *pushport.Message {
+	t.Helper()
+	return testfixtures.Messages(t, 9)[0]
+}
+
+// schedule is 1A02's schedule with other locations, or for another RID or date
+func schedule(rid string, ssd string, locations string) string {
+	return fmt.Sprintf(`<schedule rid="%s" uid="W12345" trainId="1A02" ssd="%s" toc="SW">%s</schedule>`, rid, ssd, locations)
+}
+
+const (
+	rid       = testfixtures.RID1A02
+	otherRID  = testfixtures.RID1C10
+	locations = `<OR tpl="WATRLMN" wtd="10:30" ptd="10:30"/>` +
+		`<IP tpl="WOKING" wta="10:57" wtd="10:58" pta="10:57" ptd="10:58"/>` +
+		`<DT tpl="SOTON" wta="11:45" pta="11:45"/>`
+)
+
+// queryOne scans a single row, failing the test if there isn't exactly one
+func queryOne(t *testing.T, s *Sink, sql string, args []any, dest ...any) {
+	t.Helper()
+	rows, err := s.pool.Query(context.Background(), sql, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for rows.Next() {
+		n++
+		err = rows.Scan(dest...)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if rows.Err() != nil {
+		t.Fatal(rows.Err())
+	}
+	if n != 1 {
+		t.Fatalf("%d rows for %q, want 1", n, sql)
+	}
+}
+
+func count(t *testing.T, s *Sink, table string) int {
+	t.Helper()
+	var n int
+	queryOne(t, s, "SELECT COUNT(*) FROM "+table, nil, &n)
+	return n
+}
+
+func scheduleTiplocs(t *testing.T, s *Sink, rid string) []string {
+	t.Helper()
+	rows, err := s.pool.Query(context.Background(), `SELECT tiploc FROM schedule_locations WHERE rid = $1 ORDER BY seq`, rid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tiplocs, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tiplocs
+}
+
+func TestWriteSchedule(t *testing.T) {
+	s := newTestSink(t)
+	first := fixtureSchedule(t)
+
+	// replaying a message leaves the same rows
+	for range 2 {
+		s.write([]*pushport.Message{first})
+
+		var trainID, toc, ssd string
+		var cancelled bool
+		var updatedAt time.Time
+		queryOne(t, s, `SELECT train_id, toc, ssd::text, is_cancelled, updated_at FROM schedules WHERE rid = $1`, []any{rid},
+			&trainID, &toc, &ssd, &cancelled, &updatedAt)
+		if trainID != "1A02" || toc != "SW" || ssd != "2025-09-19" || cancelled {
+			t.Errorf("schedule %s %s %s cancelled %v", trainID, toc, ssd, cancelled)
+		}
+		if !updatedAt.Equal(first.Time) {
+			t.Errorf("updated_at %v, want %v", updatedAt, first.Time)
+		}
+		if got := scheduleTiplocs(t, s, rid); !slices.Equal(got, []string{"WATRLMN", "CLPHMJC", "WOKING", "BSNGSTK", "SOTON"}) {
+			t.Errorf("locations %v", got)
+		}
+		if n := count(t, s, "schedules"); n != 1 {
+			t.Errorf("%d schedules, want 1", n)
+		}
+	}
+
+	// a revised schedule replaces the locations rather than adding to them
+	revised := testfixtures.Message(t, "2025-09-19T10:30:00.000+01:00", schedule(rid, "2025-09-19",
+		`<OR tpl="WATRLMN" wtd="10:30" ptd="10:30"/>`+
+			`<IP tpl="WOKING" wta="10:57" wtd="10:58" pta="10:57" ptd="10:58" can="true"/>`+
+			`<DT tpl="SOTON" wta="11:45" pta="11:45" can="true"/>`))
+	s.write([]*pushport.Message{revised})
+
+	var cancelled bool
+	queryOne(t, s, `SELECT is_cancelled FROM schedules WHERE rid = $1`, []any{rid}, &cancelled)
+	if cancelled {
+		t.Error("partly cancelled schedule stored as cancelled")
+	}
+	var cancelledLocations int
+	queryOne(t, s, `SELECT COUNT(*) FROM schedule_locations WHERE rid = $1 AND cancelled`, []any{rid}, &cancelledLocations)
+	if cancelledLocations != 2 {
+		t.Errorf("%d cancelled locations, want 2", cancelledLocations)
+	}
+	if n := count(t, s, "schedule_locations"); n != 3 {
+		t.Errorf("%d schedule locations, want 3", n)
+	}
+}
+
+func TestWriteTrainStatus(t *testing.T) {
+	s := newTestSink(t)
+	arrived := testfixtures.Message(t, "2025-09-19T10:59:00.000+01:00", `<TS rid="`+rid+`" uid="W12345" ssd="2025-09-19">`+
+		`<Location tpl="WOKING" wta="10:57" wtd="10:58" pta="10:57" ptd="10:58"><arr at="10:59"/><dep et="11:00"/><plat>2</plat></Location>`+
+		`</TS>`)
+	// only includes the departure, so the arrival and platform are left as they were
+	departing := testfixtures.Message(t, "2025-09-19T11:00:00.000+01:00", `<TS rid="`+rid+`" uid="W12345" ssd="2025-09-19">`+
+		`<Location tpl="WOKING" wta="10:57" wtd="10:58" pta="10:57" ptd="10:58"><dep et="11:01" delayed="true"/></Location>`+
+		`</TS>`)
+
+	for range 2 {
+		s.write([]*pushport.Message{arrived, departing})
+
+		var arrivalAt, departureEt, platform string
+		var departureDelayed bool
+		queryOne(t, s, `SELECT arrival_at, departure_et, departure_delayed, platform FROM location_updates
+			WHERE rid = $1 AND tiploc = 'WOKING' AND wta = '10:57' AND wtd = '10:58' AND wtp = ''`, []any{rid},
+			&arrivalAt, &departureEt, &departureDelayed, &platform)
+		if arrivalAt != "10:59" || departureEt != "11:01" || !departureDelayed || platform != "2" {
+			t.Errorf("arrival at %s, departure expected %s (delayed %v), platform %s", arrivalAt, departureEt, departureDelayed, platform)
+		}
+		if n := count(t, s, "location_updates"); n != 1 {
+			t.Errorf("%d location updates, want 1", n)
+		}
+	}
+}
+
+func TestWriteAssociation(t *testing.T) {
+	s := newTestSink(t)
+	association := func(cancelled bool) string {
+		return fmt.Sprintf(`<association tiploc="WOKING" category="VV" isCancelled="%v">`+
+			`<main rid="%s" wta="10:57" wtd="10:58"/><assoc rid="%s" wtd="11:05"/></association>`, cancelled, rid, otherRID)
+	}
+
+	for range 2 {
+		s.write([]*pushport.Message{testfixtures.Message(t, "2025-09-19T09:00:00.000+01:00", association(false))})
+		if n := count(t, s, "associations"); n != 1 {
+			t.Errorf("%d associations, want 1", n)
+		}
+	}
+
+	s.write([]*pushport.Message{testfixtures.Message(t, "2025-09-19T09:30:00.000+01:00", association(true))})
+	var cancelled bool
+	var mainWtd, assocWtd string
+	queryOne(t, s, `SELECT is_cancelled, main_wtd, assoc_wtd FROM associations
+		WHERE main_rid = $1 AND assoc_rid = $2 AND tiploc = 'WOKING' AND category = 'VV'`, []any{rid, otherRID},
+		&cancelled, &mainWtd, &assocWtd)
+	if !cancelled || mainWtd != "10:58" || assocWtd != "11:05" {
+		t.Errorf("association cancelled %v, main wtd %s, assoc wtd %s", cancelled, mainWtd, assocWtd)
+	}
+}
+
+func TestWriteDeactivated(t *testing.T) {
+	s := newTestSink(t)
+	messages := []*pushport.Message{
+		fixtureSchedule(t),
+		testfixtures.Message(t, "2025-09-19T12:00:00.000+01:00", `<deactivated rid="`+rid+`"/>`),
+	}
+
+	for range 2 {
+		s.write(messages)
+
+		var deactivated bool
+		var updatedAt time.Time
+		queryOne(t, s, `SELECT deactivated, updated_at FROM schedules WHERE rid = $1`, []any{rid}, &deactivated, &updatedAt)
+		if !deactivated || !updatedAt.Equal(messages[1].Time) {
+			t.Errorf("deactivated %v, updated at %v", deactivated, updatedAt)
+		}
+		if n := count(t, s, "schedules"); n != 1 {
+			t.Errorf("%d schedules, want 1", n)
+		}
+	}
+
+	// deactivating a service without a schedule doesn't fail the batch
+	s.write([]*pushport.Message{testfixtures.Message(t, "2025-09-19T12:00:00.000+01:00", `<deactivated rid="`+otherRID+`"/>`)})
+	if n := count(t, s, "schedules"); n != 1 {
+		t.Errorf("%d schedules, want 1", n)
+	}
+}
+
+func TestWriteSkipsRejectedMessages(t *testing.T) {
+	s := newTestSink(t)
+	s.write([]*pushport.Message{
+		testfixtures.Message(t, "2025-09-19T09:00:00.000+01:00", schedule(rid, "2025-09-19", locations)),
+		// there's no 30th of February, so the database rejects the date
+		testfixtures.Message(t, "2025-09-19T09:01:00.000+01:00", schedule("202502308001234", "2025-02-30", locations)),
+		testfixtures.Message(t, "2025-09-19T09:02:00.000+01:00", schedule(otherRID, "2025-09-19", locations)),
+	})
+
+	rows, err := s.pool.Query(context.Background(), `SELECT rid FROM schedules ORDER BY rid`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(rids, []string{rid, otherRID}) {
+		t.Errorf("schedules for %v, want %v", rids, []string{rid, otherRID})
+	}
+	if n := count(t, s, "schedule_locations"); n != 6 {
+		t.Errorf("%d schedule locations, want 6", n)
+	}
+}
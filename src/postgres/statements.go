@@ -0,0 +1,272 @@
+package postgres
+
+import (
+	"gemini-push-port/pushport"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const upsertSchedule = `
+INSERT INTO schedules (rid, uid, train_id, rsid, ssd, toc, status, train_cat, is_passenger_svc, is_active, is_charter,
+	is_deleted, is_cancelled, cancel_reason_code, cancel_reason_tiploc, cancel_reason_near, diverted_via, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+ON CONFLICT (rid) DO UPDATE SET
+	uid = EXCLUDED.uid,
+	train_id = EXCLUDED.train_id,
+	rsid = EXCLUDED.rsid,
+	ssd = EXCLUDED.ssd,
+	toc = EXCLUDED.toc,
+	status = EXCLUDED.status,
+	train_cat = EXCLUDED.train_cat,
+	is_passenger_svc = EXCLUDED.is_passenger_svc,
+	is_active = EXCLUDED.is_active,
+	is_charter = EXCLUDED.is_charter,
+	is_deleted = EXCLUDED.is_deleted,
+	is_cancelled = EXCLUDED.is_cancelled,
+	cancel_reason_code = EXCLUDED.cancel_reason_code,
+	cancel_reason_tiploc = EXCLUDED.cancel_reason_tiploc,
+	cancel_reason_near = EXCLUDED.cancel_reason_near,
+	diverted_via = EXCLUDED.diverted_via,
+	updated_at = EXCLUDED.updated_at`
+
+const deleteScheduleLocations = `DELETE FROM schedule_locations WHERE rid = $1`
+
+const insertScheduleLocations = `
+INSERT INTO schedule_locations (rid, seq, tiploc, type, activities, planned_activities, cancelled, false_destination,
+	platform, pta, ptd, wta, wtd, wtp)
+SELECT $1, * FROM unnest($2::integer[], $3::text[], $4::text[], $5::text[], $6::text[], $7::boolean[], $8::text[],
+	$9::text[], $10::text[], $11::text[], $12::text[], $13::text[], $14::text[])`
+
+const deactivateSchedule = `UPDATE schedules SET deactivated = TRUE, updated_at = $2 WHERE rid = $1`
+
+const updateLateReason = `
+UPDATE schedules SET late_reason_code = $2, late_reason_tiploc = $3, late_reason_near = $4, updated_at = $5
+WHERE rid = $1`
+
+// TS messages only include the arrival, departure, pass and platform elements which have changed, so the others are
+// left as they were. $27 to $30 say which elements the message included.
+const upsertLocationUpdate = `
+INSERT INTO location_updates (rid, tiploc, wta, wtd, wtp, pta, ptd,
+	arrival_et, arrival_wet, arrival_at, arrival_delayed,
+	departure_et, departure_wet, departure_at, departure_delayed,
+	pass_et, pass_wet, pass_at, pass_delayed,
+	platform, platform_suppressed, platform_confirmed, platform_source,
+	suppressed, length, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24,
+	$25, $26)
+ON CONFLICT (rid, tiploc, wta, wtd, wtp) DO UPDATE SET
+	pta = COALESCE(EXCLUDED.pta, location_updates.pta),
+	ptd = COALESCE(EXCLUDED.ptd, location_updates.ptd),
+	arrival_et = CASE WHEN $27 THEN EXCLUDED.arrival_et ELSE location_updates.arrival_et END,
+	arrival_wet = CASE WHEN $27 THEN EXCLUDED.arrival_wet ELSE location_updates.arrival_wet END,
+	arrival_at = CASE WHEN $27 THEN EXCLUDED.arrival_at ELSE location_updates.arrival_at END,
+	arrival_delayed = CASE WHEN $27 THEN EXCLUDED.arrival_delayed ELSE location_updates.arrival_delayed END,
+	departure_et = CASE WHEN $28 THEN EXCLUDED.departure_et ELSE location_updates.departure_et END,
+	departure_wet = CASE WHEN $28 THEN EXCLUDED.departure_wet ELSE location_updates.departure_wet END,
+	departure_at = CASE WHEN $28 THEN EXCLUDED.departure_at ELSE location_updates.departure_at END,
+	departure_delayed = CASE WHEN $28 THEN EXCLUDED.departure_delayed ELSE location_updates.departure_delayed END,
+	pass_et = CASE WHEN $29 THEN EXCLUDED.pass_et ELSE location_updates.pass_et END,
+	pass_wet = CASE WHEN $29 THEN EXCLUDED.pass_wet ELSE location_updates.pass_wet END,
+	pass_at = CASE WHEN $29 THEN EXCLUDED.pass_at ELSE location_updates.pass_at END,
+	pass_delayed = CASE WHEN $29 THEN EXCLUDED.pass_delayed ELSE location_updates.pass_delayed END,
+	platform = CASE WHEN $30 THEN EXCLUDED.platform ELSE location_updates.platform END,
+	platform_suppressed = CASE WHEN $30 THEN EXCLUDED.platform_suppressed ELSE location_updates.platform_suppressed END,
+	platform_confirmed = CASE WHEN $30 THEN EXCLUDED.platform_confirmed ELSE location_updates.platform_confirmed END,
+	platform_source = CASE WHEN $30 THEN EXCLUDED.platform_source ELSE location_updates.platform_source END,
+	suppressed = EXCLUDED.suppressed,
+	length = COALESCE(EXCLUDED.length, location_updates.length),
+	updated_at = EXCLUDED.updated_at`
+
+const upsertAssociation = `
+INSERT INTO associations (main_rid, assoc_rid, tiploc, category, is_cancelled, is_deleted,
+	main_wta, main_wtd, main_wtp, main_pta, main_ptd, assoc_wta, assoc_wtd, assoc_wtp, assoc_pta, assoc_ptd, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+ON CONFLICT (main_rid, assoc_rid, tiploc, category) DO UPDATE SET
+	is_cancelled = EXCLUDED.is_cancelled,
+	is_deleted = EXCLUDED.is_deleted,
+	main_wta = EXCLUDED.main_wta,
+	main_wtd = EXCLUDED.main_wtd,
+	main_wtp = EXCLUDED.main_wtp,
+	main_pta = EXCLUDED.main_pta,
+	main_ptd = EXCLUDED.main_ptd,
+	assoc_wta = EXCLUDED.assoc_wta,
+	assoc_wtd = EXCLUDED.assoc_wtd,
+	assoc_wtp = EXCLUDED.assoc_wtp,
+	assoc_pta = EXCLUDED.assoc_pta,
+	assoc_ptd = EXCLUDED.assoc_ptd,
+	updated_at = EXCLUDED.updated_at`
+
+const upsertStationMessage = `
+INSERT INTO station_messages (id, category, severity, suppress, stations, message, message_html, raised_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+ON CONFLICT (id) DO UPDATE SET
+	category = EXCLUDED.category,
+	severity = EXCLUDED.severity,
+	suppress = EXCLUDED.suppress,
+	stations = EXCLUDED.stations,
+	message = EXCLUDED.message,
+	message_html = EXCLUDED.message_html,
+	raised_at = CASE WHEN station_messages.cleared_at IS NULL THEN station_messages.raised_at ELSE EXCLUDED.raised_at END,
+	updated_at = EXCLUDED.updated_at,
+	cleared_at = NULL`
+
+const clearStationMessage = `UPDATE station_messages SET cleared_at = $2, updated_at = $2 WHERE id = $1 AND cleared_at IS NULL`
+
+// queueMessage adds the statements to store everything in a message to the batch
+func queueMessage(batch *pgx.Batch, msg *pushport.Message) {
+	response := msg.Pport.Response()
+
+	for i := range response.Schedules {
+		queueSchedule(batch, &response.Schedules[i], msg.Time)
+	}
+	for _, d := range response.Deactivated {
+		batch.Queue(deactivateSchedule, d.RID, msg.Time)
+	}
+	for _, a := range response.Associations {
+		batch.Queue(upsertAssociation, a.Main.RID, a.Assoc.RID, a.Tiploc, a.Category, a.IsCancelled, a.IsDeleted,
+			nullable(a.Main.Wta), nullable(a.Main.Wtd), nullable(a.Main.Wtp), nullable(a.Main.Pta), nullable(a.Main.Ptd),
+			nullable(a.Assoc.Wta), nullable(a.Assoc.Wtd), nullable(a.Assoc.Wtp), nullable(a.Assoc.Pta), nullable(a.Assoc.Ptd),
+			msg.Time)
+	}
+	for i := range response.TrainStatuses {
+		queueTrainStatus(batch, &response.TrainStatuses[i], msg.Time)
+	}
+	for _, ow := range response.StationMessages {
+		queueStationMessage(batch, ow, msg.Time)
+	}
+}
+
+func queueSchedule(batch *pgx.Batch, s *pushport.Schedule, msgTime time.Time) {
+	n := len(s.Locations)
+	var (
+		seqs              = make([]int32, n)
+		tiplocs           = make([]string, n)
+		types             = make([]string, n)
+		activities        = make([]*string, n)
+		plannedActivities = make([]*string, n)
+		cancelled         = make([]bool, n)
+		falseDestinations = make([]*string, n)
+		platforms         = make([]*string, n)
+		ptas              = make([]*string, n)
+		ptds              = make([]*string, n)
+		wtas              = make([]*string, n)
+		wtds              = make([]*string, n)
+		wtps              = make([]*string, n)
+	)
+
+	// matches how the train state decides a service is cancelled
+	allCancelled := n > 0
+	for i, l := range s.Locations {
+		seqs[i] = int32(i)
+		tiplocs[i] = l.Tiploc
+		types[i] = l.Type
+		activities[i] = nullable(l.Activities)
+		plannedActivities[i] = nullable(l.PlannedActivities)
+		cancelled[i] = l.Cancelled
+		falseDestinations[i] = nullable(l.FalseDestination)
+		platforms[i] = nullable(l.Platform)
+		ptas[i] = nullable(l.Pta)
+		ptds[i] = nullable(l.Ptd)
+		wtas[i] = nullable(l.Wta)
+		wtds[i] = nullable(l.Wtd)
+		wtps[i] = nullable(l.Wtp)
+
+		if !l.Cancelled && l.Type != pushport.LocationTypePassing {
+			allCancelled = false
+		}
+	}
+
+	reasonCode, reasonTiploc, reasonNear := reasonColumns(s.CancelReason)
+	batch.Queue(upsertSchedule, s.RID, s.UID, s.TrainID, nullable(s.RSID), s.SSD, s.TOC, nullable(s.Status),
+		nullable(s.TrainCat), s.PassengerService(), s.Active(), s.Charter(), s.IsDeleted(), allCancelled,
+		reasonCode, reasonTiploc, reasonNear, nullable(s.DivertedVia), msgTime)
+
+	// a schedule message always has the complete schedule, so replaces the locations rather than merging them
+	batch.Queue(deleteScheduleLocations, s.RID)
+	if n > 0 {
+		batch.Queue(insertScheduleLocations, s.RID, seqs, tiplocs, types, activities, plannedActivities, cancelled,
+			falseDestinations, platforms, ptas, ptds, wtas, wtds, wtps)
+	}
+}
+
+func queueTrainStatus(batch *pgx.Batch, ts *pushport.TrainStatus, msgTime time.Time) {
+	if ts.LateReason != nil {
+		reasonCode, reasonTiploc, reasonNear := reasonColumns(ts.LateReason)
+		batch.Queue(updateLateReason, ts.RID, reasonCode, reasonTiploc, reasonNear, msgTime)
+	}
+
+	for _, l := range ts.Locations {
+		arrET, arrWET, arrAT, arrDelayed := timeColumns(l.Arrival)
+		depET, depWET, depAT, depDelayed := timeColumns(l.Departure)
+		passET, passWET, passAT, passDelayed := timeColumns(l.Pass)
+
+		var platform, platformSource *string
+		var platformSuppressed, platformConfirmed *bool
+		if l.Platform != nil {
+			platform = nullable(l.Platform.Number)
+			platformSource = nullable(l.Platform.Source)
+			platformSuppressed = &l.Platform.Suppressed
+			platformConfirmed = &l.Platform.Confirmed
+		}
+
+		batch.Queue(upsertLocationUpdate, ts.RID, l.Tiploc, l.Wta, l.Wtd, l.Wtp, nullable(l.Pta), nullable(l.Ptd),
+			arrET, arrWET, arrAT, arrDelayed,
+			depET, depWET, depAT, depDelayed,
+			passET, passWET, passAT, passDelayed,
+			platform, platformSuppressed, platformConfirmed, platformSource,
+			l.Suppressed, nullable(l.Length), msgTime,
+			l.Arrival != nil, l.Departure != nil, l.Pass != nil, l.Platform != nil)
+	}
+}
+
+func queueStationMessage(batch *pgx.Batch, ow pushport.StationMessage, msgTime time.Time) {
+	// Darwin clears a message by sending it again without any stations
+	if len(ow.Stations) == 0 {
+		batch.Queue(clearStationMessage, ow.ID, msgTime)
+		return
+	}
+
+	stations := make([]string, 0, len(ow.Stations))
+	for _, station := range ow.Stations {
+		stations = append(stations, strings.ToUpper(station.CRS))
+	}
+
+	var severity *int
+	if s, err := strconv.Atoi(ow.Severity); err == nil {
+		severity = &s
+	}
+
+	batch.Queue(upsertStationMessage, ow.ID, nullable(ow.Category), severity, ow.Suppress, stations, ow.Msg.Text(),
+		strings.TrimSpace(ow.Msg.InnerXML), msgTime)
+}
+
+func reasonColumns(r *pushport.Reason) (*string, *string, *bool) {
+	if r == nil {
+		return nil, nil, nil
+	}
+	return nullable(r.Code), nullable(r.Tiploc), &r.Near
+}
+
+// timeColumns returns the estimated, working estimated and actual times, and whether the forecast is delayed. An
+// actual which has been removed is stored as NULL.
+func timeColumns(t *pushport.TSTimeData) (*string, *string, *string, *bool) {
+	if t == nil {
+		return nil, nil, nil, nil
+	}
+	actual := nullable(t.Actual)
+	if t.ActualRemoved {
+		actual = nil
+	}
+	return nullable(t.Estimated), nullable(t.WorkingEstimated), actual, &t.Delayed
+}
+
+// nullable stores empty strings as NULL
+func nullable(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
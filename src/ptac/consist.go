@@ -0,0 +1,229 @@
+package ptac
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Consist is the current view of the vehicles allocated to one train on one day
+type Consist struct {
+	Headcode      string              `json:"headcode"`
+	TrainIdCore   string              `json:"trainIdCore"`
+	StartDate     string              `json:"startDate"`
+	ResponsibleRU string              `json:"responsibleRU"`
+	MessageId     string              `json:"messageId"`
+	UpdatedAt     time.Time           `json:"updatedAt"`
+	Allocations   []ConsistAllocation `json:"allocations"`
+}
+
+type ConsistAllocation struct {
+	Sequence              int              `json:"sequence"`
+	Position              int              `json:"position"`
+	Reversed              bool             `json:"reversed"`
+	DiagramNo             string           `json:"diagramNo,omitempty"`
+	TrainOrigin           ConsistLocation  `json:"trainOrigin"`
+	TrainDestination      ConsistLocation  `json:"trainDestination"`
+	AllocationOrigin      ConsistLocation  `json:"allocationOrigin"`
+	AllocationDestination ConsistLocation  `json:"allocationDestination"`
+	ResourceGroupId       string           `json:"resourceGroupId"`
+	ResourceType          string           `json:"resourceType"`
+	FleetId               string           `json:"fleetId"`
+	Vehicles              []ConsistVehicle `json:"vehicles"`
+	TotalSeats            int              `json:"totalSeats"`
+}
+
+type ConsistLocation struct {
+	Tiploc   string `json:"tiploc,omitempty"`
+	Name     string `json:"name,omitempty"`
+	DateTime string `json:"dateTime,omitempty"`
+}
+
+type ConsistVehicle struct {
+	VehicleId    string `json:"vehicleId"`
+	Position     int    `json:"position"`
+	Type         string `json:"type"`
+	SpecificType string `json:"specificType,omitempty"`
+	Seats        int    `json:"seats"`
+	LengthMetres string `json:"lengthMetres,omitempty"`
+	Defects      int    `json:"defects"`
+}
+
+// Store keeps the latest consist for every train the feed has told us about
+type Store struct {
+	mu       sync.RWMutex
+	consists map[string]*Consist
+}
+
+func NewStore() *Store {
+	return &Store{
+		consists: make(map[string]*Consist),
+	}
+}
+
+func consistKey(core string, startDate string) string {
+	return core + "|" + startDate
+}
+
+// Apply updates the store with a newly received message, replacing any previous allocations for the train
+func (s *Store) Apply(msg *PassengerTrainConsistMessage, receivedAt time.Time) {
+	id := msg.OperationalTrainNumberIdentifier
+	key := consistKey(id.Core, id.StartDate)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if msg.MessageStatus == MessageStatusDeletion {
+		delete(s.consists, key)
+		return
+	}
+
+	s.consists[key] = buildConsist(msg, receivedAt)
+}
+
+func buildConsist(msg *PassengerTrainConsistMessage, receivedAt time.Time) *Consist {
+	consist := &Consist{
+		Headcode:      msg.Headcode(),
+		TrainIdCore:   msg.OperationalTrainNumberIdentifier.Core,
+		StartDate:     msg.OperationalTrainNumberIdentifier.StartDate,
+		ResponsibleRU: msg.ResponsibleRU,
+		MessageId:     msg.MessageHeader.MessageReference.MessageIdentifier,
+		UpdatedAt:     receivedAt,
+	}
+
+	for _, a := range msg.Allocations {
+		allocation := ConsistAllocation{
+			Sequence:              a.AllocationSequenceNumber,
+			Position:              a.ResourceGroupPosition,
+			Reversed:              strings.EqualFold(a.ReversedResourceGroup, "Y"),
+			DiagramNo:             a.DiagramNo,
+			TrainOrigin:           toConsistLocation(a.TrainOriginLocation, a.TrainOriginDateTime),
+			TrainDestination:      toConsistLocation(a.TrainDestLocation, a.TrainDestDateTime),
+			AllocationOrigin:      toConsistLocation(a.AllocationOriginLocation, a.AllocationOriginDateTime),
+			AllocationDestination: toConsistLocation(a.AllocationDestinationLocation, a.AllocationDestinationDateTime),
+			ResourceGroupId:       a.ResourceGroup.ResourceGroupId,
+			ResourceType:          a.ResourceGroup.TypeOfResource,
+			FleetId:               a.ResourceGroup.FleetId,
+		}
+
+		for _, v := range a.ResourceGroup.Vehicles {
+			seats, _ := strconv.Atoi(strings.TrimSpace(v.NumberOfSeats))
+			allocation.TotalSeats += seats
+
+			allocation.Vehicles = append(allocation.Vehicles, ConsistVehicle{
+				VehicleId:    v.VehicleId,
+				Position:     v.ResourcePosition,
+				Type:         v.TypeOfVehicle,
+				SpecificType: v.SpecificType,
+				Seats:        seats,
+				LengthMetres: v.Length.Value,
+				Defects:      len(v.Defects),
+			})
+		}
+
+		slices.SortFunc(allocation.Vehicles, func(a, b ConsistVehicle) int {
+			return a.Position - b.Position
+		})
+
+		consist.Allocations = append(consist.Allocations, allocation)
+	}
+
+	slices.SortFunc(consist.Allocations, func(a, b ConsistAllocation) int {
+		if a.Sequence != b.Sequence {
+			return a.Sequence - b.Sequence
+		}
+		return a.Position - b.Position
+	})
+
+	return consist
+}
+
+func toConsistLocation(l Location, dateTime string) ConsistLocation {
+	return ConsistLocation{
+		Tiploc:   l.Tiploc(),
+		Name:     l.PrimaryLocationName,
+		DateTime: dateTime,
+	}
+}
+
+// Get returns the consist for a train by its identifier core and start date (YYYY-MM-DD)
+func (s *Store) Get(core string, startDate string) (Consist, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.consists[consistKey(core, startDate)]
+	if !ok {
+		return Consist{}, false
+	}
+	return *c, true
+}
+
+// FindByHeadcode returns every consist running with the given headcode on a day. Headcodes aren't unique, so more
+// than one train may be returned.
+func (s *Store) FindByHeadcode(headcode string, startDate string) []Consist {
+	return s.find(func(c *Consist) bool {
+		return c.StartDate == startDate && strings.EqualFold(c.Headcode, headcode)
+	})
+}
+
+// FindByVehicle returns every consist a unit or vehicle has been allocated to on a day
+func (s *Store) FindByVehicle(id string, startDate string) []Consist {
+	return s.find(func(c *Consist) bool {
+		if c.StartDate != startDate {
+			return false
+		}
+		for _, a := range c.Allocations {
+			if a.ResourceGroupId == id {
+				return true
+			}
+			for _, v := range a.Vehicles {
+				if v.VehicleId == id {
+					return true
+				}
+			}
+		}
+		return false
+	})
+}
+
+// ForDate returns every consist for a day
+func (s *Store) ForDate(startDate string) []Consist {
+	return s.find(func(c *Consist) bool {
+		return c.StartDate == startDate
+	})
+}
+
+func (s *Store) find(match func(c *Consist) bool) []Consist {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []Consist
+	for _, c := range s.consists {
+		if match(c) {
+			result = append(result, *c)
+		}
+	}
+
+	slices.SortFunc(result, func(a, b Consist) int {
+		return strings.Compare(a.TrainIdCore, b.TrainIdCore)
+	})
+
+	return result
+}
+
+// Prune removes consists for trains which started before the given date (YYYY-MM-DD)
+func (s *Store) Prune(before string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for key, c := range s.consists {
+		if c.StartDate < before {
+			delete(s.consists, key)
+			removed++
+		}
+	}
+	return removed
+}
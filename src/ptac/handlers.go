@@ -0,0 +1,50 @@
+package ptac
+
+import (
+	"gemini-push-port/darwintime"
+	"gemini-push-port/httpapi"
+	"net/http"
+	"time"
+)
+
+func (s *Store) RegisterRoutes(server *httpapi.Server) {
+	server.HandleFunc("GET /consists", s.handleConsists)
+}
+
+// handleConsists looks up consists on a day (today by default) by train identifier core, headcode or vehicle, or
+// lists every consist for the day when none is given
+func (s *Store) handleConsists(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	date := query.Get("date")
+	if date == "" {
+		date = time.Now().In(darwintime.UK).Format(time.DateOnly)
+	} else if _, err := time.Parse(time.DateOnly, date); err != nil {
+		httpapi.WriteError(w, r, http.StatusBadRequest, "date must be YYYY-MM-DD")
+		return
+	}
+
+	switch {
+	case query.Get("core") != "":
+		consist, ok := s.Get(query.Get("core"), date)
+		if !ok {
+			httpapi.WriteError(w, r, http.StatusNotFound, "consist not found")
+			return
+		}
+		httpapi.WriteJSON(w, r, http.StatusOK, []Consist{consist})
+	case query.Get("headcode") != "":
+		httpapi.WriteJSON(w, r, http.StatusOK, orEmpty(s.FindByHeadcode(query.Get("headcode"), date)))
+	case query.Get("vehicle") != "":
+		httpapi.WriteJSON(w, r, http.StatusOK, orEmpty(s.FindByVehicle(query.Get("vehicle"), date)))
+	default:
+		httpapi.WriteJSON(w, r, http.StatusOK, orEmpty(s.ForDate(date)))
+	}
+}
+
+// orEmpty makes sure an empty result is written as [] rather than null
+func orEmpty(consists []Consist) []Consist {
+	if consists == nil {
+		return []Consist{}
+	}
+	return consists
+}
@@ -0,0 +1,68 @@
+package ptac
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"gemini-push-port/validation"
+	"strings"
+)
+
+var ErrNotConsistMessage = errors.New("message is not a PassengerTrainConsistMessage")
+
+func Parse(raw string) (*PassengerTrainConsistMessage, error) {
+	payload, err := validation.ExtractXml(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := xml.NewDecoder(strings.NewReader(payload))
+
+	// find the root element before decoding, so that messages from other feeds can be skipped cheaply
+	for {
+		tok, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find root element: %v", err)
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		if start.Name.Local != "PassengerTrainConsistMessage" {
+			return nil, ErrNotConsistMessage
+		}
+
+		var msg PassengerTrainConsistMessage
+		err = decoder.DecodeElement(&msg, &start)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode consist message: %v", err)
+		}
+
+		return &msg, nil
+	}
+}
+
+// Tiploc returns the TIPLOC of the location, if one was provided
+func (l Location) Tiploc() string {
+	code := l.LocationSubsidiaryIdentification.LocationSubsidiaryCode
+	if code.TypeCode != "" && code.TypeCode != "0" {
+		return ""
+	}
+	return strings.TrimSpace(code.Value)
+}
+
+// Headcode returns the four character headcode of the train, falling back to the identifier core when the message
+// doesn't include an operational train number
+func (m *PassengerTrainConsistMessage) Headcode() string {
+	if m.OperationalTrainNumber != "" {
+		return m.OperationalTrainNumber
+	}
+
+	core := strings.TrimLeft(m.OperationalTrainNumberIdentifier.Core, "-")
+	if len(core) >= 4 {
+		return core[:4]
+	}
+	return core
+}
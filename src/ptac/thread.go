@@ -0,0 +1,38 @@
+package ptac
+
+import (
+	"errors"
+	"gemini-push-port/logging"
+	"gemini-push-port/rawstore"
+	"time"
+)
+
+// how many days of consists to keep in memory, including today
+const retainDays = 2
+
+func Thread(msgChan chan *rawstore.XmlMessageWithTime, store *Store) {
+	for {
+		msg, ok := <-msgChan
+		if !ok {
+			// Channel closed, exit the loop
+			return
+		}
+
+		consistMsg, err := Parse(msg.Message)
+		if err != nil {
+			if !errors.Is(err, ErrNotConsistMessage) {
+				logging.Logger.WarnE("failed to parse consist message", err)
+			}
+			continue
+		}
+
+		store.Apply(consistMsg, msg.MessageTime)
+	}
+}
+
+func PruneJob(store *Store) {
+	cutoff := time.Now().UTC().AddDate(0, 0, -(retainDays - 1)).Format(time.DateOnly)
+
+	removed := store.Prune(cutoff)
+	logging.Logger.Infof("pruned %d consists which started before %s", removed, cutoff)
+}
@@ -0,0 +1,129 @@
+package ptac
+
+import "encoding/xml"
+
+// Message statuses used by PassengerTrainConsistMessage
+const (
+	MessageStatusCreation     = "1"
+	MessageStatusModification = "2"
+	MessageStatusDeletion     = "3"
+)
+
+// PassengerTrainConsistMessage is the root element of every message published on the Passenger Train Allocation and
+// Consist feed. Element names follow the TAF/TSI schema, so namespaces are ignored when decoding.
+type PassengerTrainConsistMessage struct {
+	XMLName                          xml.Name        `xml:"PassengerTrainConsistMessage"`
+	MessageHeader                    MessageHeader   `xml:"MessageHeader"`
+	MessageStatus                    string          `xml:"MessageStatus"`
+	OperationalTrainNumberIdentifier TrainIdentifier `xml:"OperationalTrainNumberIdentifier"`
+	OperationalTrainNumber           string          `xml:"OperationalTrainNumber"`
+	ResponsibleRU                    string          `xml:"ResponsibleRU"`
+	Allocations                      []Allocation    `xml:"Allocation"`
+}
+
+type MessageHeader struct {
+	MessageReference MessageReference `xml:"MessageReference"`
+	SenderReference  string           `xml:"SenderReference"`
+	Sender           string           `xml:"Sender"`
+	Recipient        string           `xml:"Recipient"`
+}
+
+type MessageReference struct {
+	MessageType        string `xml:"MessageType"`
+	MessageTypeVersion string `xml:"MessageTypeVersion"`
+	MessageIdentifier  string `xml:"MessageIdentifier"`
+	MessageDateTime    string `xml:"MessageDateTime"`
+}
+
+// TrainIdentifier is the TAF/TSI composite identifier for a train. Core contains the headcode, and StartDate is the
+// day the train runs on.
+type TrainIdentifier struct {
+	ObjectType    string `xml:"ObjectType"`
+	Company       string `xml:"Company"`
+	Core          string `xml:"Core"`
+	Variant       string `xml:"Variant"`
+	TimetableYear string `xml:"TimetableYear"`
+	StartDate     string `xml:"StartDate"`
+}
+
+// Allocation describes a single resource group (unit or rake) allocated to part, or all, of a train's journey.
+type Allocation struct {
+	AllocationSequenceNumber      int           `xml:"AllocationSequenceNumber"`
+	TrainOriginDateTime           string        `xml:"TrainOriginDateTime"`
+	TrainOriginLocation           Location      `xml:"TrainOriginLocation"`
+	TrainDestDateTime             string        `xml:"TrainDestDateTime"`
+	TrainDestLocation             Location      `xml:"TrainDestLocation"`
+	ResourceGroupPosition         int           `xml:"ResourceGroupPosition"`
+	DiagramDate                   string        `xml:"DiagramDate"`
+	DiagramNo                     string        `xml:"DiagramNo"`
+	AllocationOriginDateTime      string        `xml:"AllocationOriginDateTime"`
+	AllocationOriginLocation      Location      `xml:"AllocationOriginLocation"`
+	AllocationOriginMiles         string        `xml:"AllocationOriginMiles"`
+	AllocationDestinationDateTime string        `xml:"AllocationDestinationDateTime"`
+	AllocationDestinationLocation Location      `xml:"AllocationDestinationLocation"`
+	AllocationDestinationMiles    string        `xml:"AllocationDestinationMiles"`
+	ReversedResourceGroup         string        `xml:"ReversedResourceGroup"`
+	ResourceGroup                 ResourceGroup `xml:"ResourceGroup"`
+}
+
+type Location struct {
+	CountryCodeISO                   string             `xml:"CountryCodeISO"`
+	LocationPrimaryCode              string             `xml:"LocationPrimaryCode"`
+	PrimaryLocationName              string             `xml:"PrimaryLocationName"`
+	LocationSubsidiaryIdentification SubsidiaryLocation `xml:"LocationSubsidiaryIdentification"`
+}
+
+type SubsidiaryLocation struct {
+	LocationSubsidiaryCode SubsidiaryCode `xml:"LocationSubsidiaryCode"`
+	AllocationCompany      string         `xml:"AllocationCompany"`
+}
+
+// SubsidiaryCode holds the TIPLOC of a location when LocationSubsidiaryTypeCode is 0
+type SubsidiaryCode struct {
+	TypeCode string `xml:"LocationSubsidiaryTypeCode,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type ResourceGroup struct {
+	TypeOfResource      string    `xml:"TypeOfResource"`
+	ResourceGroupId     string    `xml:"ResourceGroupId"`
+	ResourceGroupStatus string    `xml:"ResourceGroupStatus"`
+	FleetId             string    `xml:"FleetId"`
+	EndOfDayMiles       string    `xml:"EndOfDayMiles"`
+	Vehicles            []Vehicle `xml:"Vehicle"`
+}
+
+type Vehicle struct {
+	VehicleId            string   `xml:"VehicleId"`
+	TypeOfVehicle        string   `xml:"TypeOfVehicle"`
+	ResourcePosition     int      `xml:"ResourcePosition"`
+	PlannedResourceGroup string   `xml:"PlannedResourceGroup"`
+	SpecificType         string   `xml:"SpecificType"`
+	Length               Measure  `xml:"Length"`
+	Weight               string   `xml:"Weight"`
+	Livery               string   `xml:"Livery"`
+	Decor                string   `xml:"Decor"`
+	VehicleStatus        string   `xml:"VehicleStatus"`
+	RegisteredStatus     string   `xml:"RegisteredStatus"`
+	RegisteredCategory   string   `xml:"RegisteredCategory"`
+	DateEnteredService   string   `xml:"DateEnteredService"`
+	NumberOfSeats        string   `xml:"NumberOfSeats"`
+	TrainBrakeType       string   `xml:"TrainBrakeType"`
+	MaximumSpeed         string   `xml:"MaximumSpeed"`
+	RadioNumberGSMR      string   `xml:"RadioNumberGSMR"`
+	Cabs                 string   `xml:"Cabs"`
+	Defects              []Defect `xml:"Defect"`
+}
+
+type Measure struct {
+	Value   string `xml:"Value"`
+	Measure string `xml:"Measure"`
+}
+
+type Defect struct {
+	MaintenanceUID    string `xml:"MaintenanceUID"`
+	DefectCode        string `xml:"DefectCode"`
+	DefectDescription string `xml:"DefectDescription"`
+	DefectStatus      string `xml:"DefectStatus"`
+	DefectLocation    string `xml:"DefectLocation"`
+}
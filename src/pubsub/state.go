@@ -0,0 +1,37 @@
+package pubsub
+
+import (
+	"sync"
+	"time"
+)
+
+// ConnectionState is what the consumer is doing, for health checks
+type ConnectionState struct {
+	// Connected is true once a message has been fetched from the current reader, until fetching from it fails
+	Connected bool `json:"connected"`
+	// LastMessage is when the last message was received, or zero if none have been
+	LastMessage    time.Time `json:"lastMessage,omitempty"`
+	FailedAttempts int       `json:"failedAttempts"`
+	// BackoffUntil is when the next connection attempt will be made, while waiting after failed attempts
+	BackoffUntil time.Time `json:"backoffUntil,omitempty"`
+}
+
+var (
+	stateMu sync.Mutex
+	state   ConnectionState
+)
+
+// State returns a copy of the consumer's connection state
+func State() ConnectionState {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	return state
+}
+
+func updateState(update func(s *ConnectionState)) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	update(&state)
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/tls"
 	"errors"
 	"gemini-push-port/logging"
+	"gemini-push-port/metrics"
 	"gemini-push-port/rawstore"
 	"log"
 	"os"
@@ -22,9 +23,13 @@ const maxBatchSize = 1_000_000 // 1 MB
 const minBatchSize = 100       // 100 B
 const readTimeout = 30 * time.Minute
 
-func Thread(rawMessageChan chan *rawstore.XmlMessageWithTime) {
+// Thread consumes messages from Kafka and sends them to rawMessageChan. Every message which is accepted by
+// rawMessageChan is also offered to each subscriber channel, but messages are dropped for a subscriber when its channel
+// is full so that a slow subscriber can never hold up archiving.
+func Thread(rawMessageChan chan *rawstore.XmlMessageWithTime, subscribers ...chan *rawstore.XmlMessageWithTime) {
 	failedAttempts := 0
 	messageCounter := 0
+	subscriberDrops := make([]int, len(subscribers))
 
 	var r *kafka.Reader
 	defer func(r *kafka.Reader) {
@@ -48,6 +53,11 @@ func Thread(rawMessageChan chan *rawstore.XmlMessageWithTime) {
 	password := os.Getenv("CONSUMER_PASSWORD")
 
 	rawChanFailures := 0
+	readerCreated := false
+
+	consumed := metrics.MessagesConsumed.WithLabelValues(topic)
+	committed := metrics.MessagesCommitted.WithLabelValues(topic)
+	messageAge := metrics.MessageAge.WithLabelValues(topic)
 
 outer:
 	for {
@@ -65,6 +75,11 @@ outer:
 			seconds := 1 << (min(failedAttempts, 8) - 1)
 
 			logging.Logger.Warnf("%d failed connection attempts. Waiting %d seconds for next attempt", failedAttempts, seconds)
+			updateState(func(s *ConnectionState) {
+				s.Connected = false
+				s.FailedAttempts = failedAttempts
+				s.BackoffUntil = time.Now().Add(time.Duration(seconds) * time.Second)
+			})
 			time.Sleep(time.Duration(seconds) * time.Second)
 			logging.Logger.Warnf("Starting next connection attempt...")
 		}
@@ -90,6 +105,10 @@ outer:
 			MaxBytes:  maxBatchSize,
 		})
 		logging.Logger.Infof("Created reader for Kafka topic %s on host %s", topic, host)
+		if readerCreated {
+			metrics.Reconnects.WithLabelValues(topic).Inc()
+		}
+		readerCreated = true
 
 		ctx := context.Background()
 
@@ -99,17 +118,29 @@ outer:
 			cancel()
 
 			if err != nil {
+				updateState(func(s *ConnectionState) {
+					s.Connected = false
+				})
 				if errors.Is(err, context.DeadlineExceeded) {
 					logging.Logger.Warnf("No message received for %v, restarting reader", readTimeout)
 					continue outer
 				}
 
 				logging.Logger.Errorf(err, "failed to read pubsub message")
+				metrics.FetchErrors.WithLabelValues(topic).Inc()
 				failedAttempts++
 				continue outer
 			}
 
+			updateState(func(s *ConnectionState) {
+				s.Connected = true
+				s.LastMessage = time.Now()
+				s.BackoffUntil = time.Time{}
+			})
+
 			messageCounter++
+			consumed.Inc()
+			messageAge.Observe(time.Since(m.Time).Seconds())
 			if messageCounter%messageLogInterval == 0 {
 				logging.Logger.Infof("Consumed %d messages", messageCounter)
 				messageCounter = 0
@@ -131,9 +162,24 @@ outer:
 				err := r.CommitMessages(ctx, m)
 				if err != nil {
 					logging.Logger.Errorf(err, "failed to commit message: %s", string(m.Value))
+				} else {
+					committed.Inc()
+				}
+
+				for i, sub := range subscribers {
+					select {
+					case sub <- &rawMsg:
+					default:
+						subscriberDrops[i]++
+						metrics.MessagesDropped.WithLabelValues(topic, "subscriber").Inc()
+						if subscriberDrops[i]%messageLogInterval == 1 {
+							logging.Logger.Warnf("Subscriber channel %d full, %d messages dropped so far", i, subscriberDrops[i])
+						}
+					}
 				}
 			default:
 				logging.Logger.ErrorMsg("Raw message channel full, discarding value")
+				metrics.MessagesDropped.WithLabelValues(topic, "raw").Inc()
 				rawChanFailures++
 				if rawChanFailures > 200 {
 					logging.Logger.Fatal(errors.New("raw message queue stuck for 200 messages, quitting"))
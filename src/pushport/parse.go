@@ -0,0 +1,62 @@
+package pushport
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"gemini-push-port/validation"
+	"strings"
+	"time"
+)
+
+var ErrNotPushPort = errors.New("message is not a Push Port message")
+
+func Parse(raw string) (*Pport, error) {
+	payload, err := validation.ExtractXml(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := xml.NewDecoder(strings.NewReader(payload))
+
+	// find the root element before decoding, so that messages from other feeds can be skipped cheaply
+	for {
+		tok, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find root element: %v", err)
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		if start.Name.Local != "Pport" {
+			return nil, ErrNotPushPort
+		}
+
+		var pport Pport
+		err = decoder.DecodeElement(&pport, &start)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode Push Port message: %v", err)
+		}
+
+		return &pport, nil
+	}
+}
+
+// Response returns the update or snapshot response carried by the message
+func (p *Pport) Response() *DataResponse {
+	if p.UpdateResponse != nil {
+		return p.UpdateResponse
+	}
+	if p.SnapshotResponse != nil {
+		return p.SnapshotResponse
+	}
+	return &DataResponse{}
+}
+
+// Timestamp returns the time the message was generated by Darwin
+func (p *Pport) Timestamp() (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, p.Ts)
+}
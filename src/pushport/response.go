@@ -0,0 +1,58 @@
+package pushport
+
+// Message type names, matching the element names used by the Push Port
+const (
+	TypeSchedule       = "schedule"
+	TypeDeactivated    = "deactivated"
+	TypeAssociation    = "association"
+	TypeTrainStatus    = "TS"
+	TypeStationMessage = "OW"
+)
+
+// MessageTypes returns the types of element carried by the response
+func (r *DataResponse) MessageTypes() []string {
+	var types []string
+	if len(r.Schedules) > 0 {
+		types = append(types, TypeSchedule)
+	}
+	if len(r.Deactivated) > 0 {
+		types = append(types, TypeDeactivated)
+	}
+	if len(r.Associations) > 0 {
+		types = append(types, TypeAssociation)
+	}
+	if len(r.TrainStatuses) > 0 {
+		types = append(types, TypeTrainStatus)
+	}
+	if len(r.StationMessages) > 0 {
+		types = append(types, TypeStationMessage)
+	}
+	return types
+}
+
+// RIDs returns the RID of every service the response refers to, without duplicates
+func (r *DataResponse) RIDs() []string {
+	seen := make(map[string]bool)
+	var rids []string
+	add := func(rid string) {
+		if rid != "" && !seen[rid] {
+			seen[rid] = true
+			rids = append(rids, rid)
+		}
+	}
+
+	for _, s := range r.Schedules {
+		add(s.RID)
+	}
+	for _, d := range r.Deactivated {
+		add(d.RID)
+	}
+	for _, a := range r.Associations {
+		add(a.Main.RID)
+		add(a.Assoc.RID)
+	}
+	for _, ts := range r.TrainStatuses {
+		add(ts.RID)
+	}
+	return rids
+}
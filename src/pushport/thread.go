@@ -0,0 +1,63 @@
+package pushport
+
+import (
+	"errors"
+	"gemini-push-port/logging"
+	"gemini-push-port/rawstore"
+	"time"
+)
+
+// Message is a parsed Push Port message, along with the raw message it was parsed from
+type Message struct {
+	Raw   *rawstore.XmlMessageWithTime
+	Pport *Pport
+	// Time is Darwin's timestamp for the message, or the time it was received if Darwin's couldn't be parsed
+	Time time.Time
+}
+
+// Handler is implemented by components which consume parsed Push Port messages. Handlers are called in turn from a
+// single goroutine, so should return quickly.
+type Handler interface {
+	HandleMessage(msg *Message)
+}
+
+func NewMessage(raw *rawstore.XmlMessageWithTime) (*Message, error) {
+	pport, err := Parse(raw.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	msgTime, err := pport.Timestamp()
+	if err != nil {
+		msgTime = raw.MessageTime
+	}
+
+	return &Message{
+		Raw:   raw,
+		Pport: pport,
+		Time:  msgTime.UTC(),
+	}, nil
+}
+
+// Thread parses every message received on msgChan once, and passes it to each handler
+func Thread(msgChan chan *rawstore.XmlMessageWithTime, handlers ...Handler) {
+	for {
+		raw, ok := <-msgChan
+		if !ok {
+			// Channel closed, exit the loop
+			return
+		}
+
+		msg, err := NewMessage(raw)
+		if err != nil {
+			if !errors.Is(err, ErrNotPushPort) {
+				logging.Logger.WarnE("failed to parse Push Port message", err)
+			}
+			continue
+		}
+
+		for _, handler := range handlers {
+			handler.HandleMessage(msg)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package pushport
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Pport is the root element of every Darwin Push Port message. Element names are matched without their namespaces, so
+// the same types can decode any of the v16 schema family.
+type Pport struct {
+	XMLName          xml.Name      `xml:"Pport" json:"-"`
+	Ts               string        `xml:"ts,attr" json:"ts,omitempty"`
+	Version          string        `xml:"version,attr" json:"version,omitempty"`
+	UpdateResponse   *DataResponse `xml:"uR" json:"updateResponse,omitempty"`
+	SnapshotResponse *DataResponse `xml:"sR" json:"snapshotResponse,omitempty"`
+}
+
+// DataResponse is the content of an update (uR) or snapshot (sR) response
+type DataResponse struct {
+	UpdateOrigin    string           `xml:"updateOrigin,attr" json:"updateOrigin,omitempty"`
+	RequestSource   string           `xml:"requestSource,attr" json:"requestSource,omitempty"`
+	RequestID       string           `xml:"requestID,attr" json:"requestId,omitempty"`
+	Schedules       []Schedule       `xml:"schedule" json:"schedules,omitempty"`
+	Deactivated     []Deactivated    `xml:"deactivated" json:"deactivated,omitempty"`
+	Associations    []Association    `xml:"association" json:"associations,omitempty"`
+	TrainStatuses   []TrainStatus    `xml:"TS" json:"trainStatuses,omitempty"`
+	StationMessages []StationMessage `xml:"OW" json:"stationMessages,omitempty"`
+}
+
+// Location types used in schedules, in the order the schema lists them
+const (
+	LocationTypeOrigin                  = "OR"
+	LocationTypeOperationalOrigin       = "OPOR"
+	LocationTypeIntermediate            = "IP"
+	LocationTypeOperationalIntermediate = "OPIP"
+	LocationTypePassing                 = "PP"
+	LocationTypeDestination             = "DT"
+	LocationTypeOperationalDestination  = "OPDT"
+)
+
+var scheduleLocationTypes = map[string]bool{
+	LocationTypeOrigin:                  true,
+	LocationTypeOperationalOrigin:       true,
+	LocationTypeIntermediate:            true,
+	LocationTypeOperationalIntermediate: true,
+	LocationTypePassing:                 true,
+	LocationTypeDestination:             true,
+	LocationTypeOperationalDestination:  true,
+}
+
+type Schedule struct {
+	RID             string             `xml:"rid,attr" json:"rid,omitempty"`
+	UID             string             `xml:"uid,attr" json:"uid,omitempty"`
+	TrainID         string             `xml:"trainId,attr" json:"trainId,omitempty"`
+	RSID            string             `xml:"rsid,attr" json:"rsid,omitempty"`
+	SSD             string             `xml:"ssd,attr" json:"ssd,omitempty"`
+	TOC             string             `xml:"toc,attr" json:"toc,omitempty"`
+	Status          string             `xml:"status,attr" json:"status,omitempty"`
+	TrainCat        string             `xml:"trainCat,attr" json:"trainCat,omitempty"`
+	IsPassengerSvc  string             `xml:"isPassengerSvc,attr" json:"isPassengerSvc,omitempty"`
+	IsActive        string             `xml:"isActive,attr" json:"isActive,omitempty"`
+	Deleted         string             `xml:"deleted,attr" json:"deleted,omitempty"`
+	IsCharter       string             `xml:"isCharter,attr" json:"isCharter,omitempty"`
+	Locations       []ScheduleLocation `xml:"-" json:"locations,omitempty"`
+	CancelReason    *Reason            `xml:"-" json:"cancelReason,omitempty"`
+	DivertedVia     string             `xml:"-" json:"divertedVia,omitempty"`
+	DiversionReason *Reason            `xml:"-" json:"diversionReason,omitempty"`
+}
+
+// scheduleAttributes shares Schedule's attribute tags, without its custom unmarshaller
+type scheduleAttributes Schedule
+
+// UnmarshalXML keeps a schedule's locations in order, as each location type has its own element name
+func (s *Schedule) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	// decode the attributes on their own, as the children need to be read in order below
+	attrDecoder := xml.NewTokenDecoder(&tokenSlice{tokens: []xml.Token{start, start.End()}})
+	err := attrDecoder.Decode((*scheduleAttributes)(s))
+	if err != nil {
+		return err
+	}
+
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case scheduleLocationTypes[t.Name.Local]:
+				var loc ScheduleLocation
+				err = d.DecodeElement(&loc, &t)
+				loc.Type = t.Name.Local
+				s.Locations = append(s.Locations, loc)
+			case t.Name.Local == "cancelReason":
+				s.CancelReason = &Reason{}
+				err = d.DecodeElement(s.CancelReason, &t)
+			case t.Name.Local == "diversionReason":
+				s.DiversionReason = &Reason{}
+				err = d.DecodeElement(s.DiversionReason, &t)
+			case t.Name.Local == "divertedVia":
+				err = d.DecodeElement(&s.DivertedVia, &t)
+			default:
+				err = d.Skip()
+			}
+			if err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+type tokenSlice struct {
+	tokens []xml.Token
+}
+
+func (ts *tokenSlice) Token() (xml.Token, error) {
+	if len(ts.tokens) == 0 {
+		return nil, io.EOF
+	}
+	tok := ts.tokens[0]
+	ts.tokens = ts.tokens[1:]
+	return tok, nil
+}
+
+type ScheduleLocation struct {
+	Type              string `xml:"-" json:"type,omitempty"`
+	Tiploc            string `xml:"tpl,attr" json:"tiploc,omitempty"`
+	Activities        string `xml:"act,attr" json:"activities,omitempty"`
+	PlannedActivities string `xml:"planAct,attr" json:"plannedActivities,omitempty"`
+	Cancelled         bool   `xml:"can,attr" json:"cancelled,omitempty"`
+	FormationID       string `xml:"fid,attr" json:"formationId,omitempty"`
+	AffectedBy        string `xml:"affectedBy,attr" json:"affectedBy,omitempty"`
+	Platform          string `xml:"plat,attr" json:"platform,omitempty"`
+	Pta               string `xml:"pta,attr" json:"pta,omitempty"`
+	Ptd               string `xml:"ptd,attr" json:"ptd,omitempty"`
+	Wta               string `xml:"wta,attr" json:"wta,omitempty"`
+	Wtd               string `xml:"wtd,attr" json:"wtd,omitempty"`
+	Wtp               string `xml:"wtp,attr" json:"wtp,omitempty"`
+	FalseDestination  string `xml:"fd,attr" json:"falseDestination,omitempty"`
+	RouteDelay        string `xml:"rdelay,attr" json:"routeDelay,omitempty"`
+}
+
+type Reason struct {
+	Code   string `xml:",chardata" json:"code"`
+	Tiploc string `xml:"tiploc,attr" json:"tiploc,omitempty"`
+	Near   bool   `xml:"near,attr" json:"near,omitempty"`
+}
+
+type Deactivated struct {
+	RID string `xml:"rid,attr" json:"rid,omitempty"`
+}
+
+// Association categories
+const (
+	AssociationJoin        = "JJ"
+	AssociationDivide      = "VV"
+	AssociationNextWorking = "NP"
+	AssociationLinked      = "LK"
+)
+
+type Association struct {
+	Tiploc      string             `xml:"tiploc,attr" json:"tiploc,omitempty"`
+	Category    string             `xml:"category,attr" json:"category,omitempty"`
+	IsCancelled bool               `xml:"isCancelled,attr" json:"isCancelled,omitempty"`
+	IsDeleted   bool               `xml:"isDeleted,attr" json:"isDeleted,omitempty"`
+	Main        AssociationService `xml:"main" json:"main,omitempty"`
+	Assoc       AssociationService `xml:"assoc" json:"assoc,omitempty"`
+}
+
+type AssociationService struct {
+	RID string `xml:"rid,attr" json:"rid,omitempty"`
+	Wta string `xml:"wta,attr" json:"wta,omitempty"`
+	Wtd string `xml:"wtd,attr" json:"wtd,omitempty"`
+	Wtp string `xml:"wtp,attr" json:"wtp,omitempty"`
+	Pta string `xml:"pta,attr" json:"pta,omitempty"`
+	Ptd string `xml:"ptd,attr" json:"ptd,omitempty"`
+}
+
+// TrainStatus (TS) carries forecasts and actuals for a service
+type TrainStatus struct {
+	RID                string       `xml:"rid,attr" json:"rid,omitempty"`
+	UID                string       `xml:"uid,attr" json:"uid,omitempty"`
+	SSD                string       `xml:"ssd,attr" json:"ssd,omitempty"`
+	IsReverseFormation bool         `xml:"isReverseFormation,attr" json:"isReverseFormation,omitempty"`
+	LateReason         *Reason      `xml:"LateReason" json:"lateReason,omitempty"`
+	Locations          []TSLocation `xml:"Location" json:"locations,omitempty"`
+}
+
+type TSLocation struct {
+	Tiploc      string      `xml:"tpl,attr" json:"tiploc,omitempty"`
+	Pta         string      `xml:"pta,attr" json:"pta,omitempty"`
+	Ptd         string      `xml:"ptd,attr" json:"ptd,omitempty"`
+	Wta         string      `xml:"wta,attr" json:"wta,omitempty"`
+	Wtd         string      `xml:"wtd,attr" json:"wtd,omitempty"`
+	Wtp         string      `xml:"wtp,attr" json:"wtp,omitempty"`
+	Arrival     *TSTimeData `xml:"arr" json:"arrival,omitempty"`
+	Departure   *TSTimeData `xml:"dep" json:"departure,omitempty"`
+	Pass        *TSTimeData `xml:"pass" json:"pass,omitempty"`
+	Platform    *Platform   `xml:"plat" json:"platform,omitempty"`
+	Suppressed  bool        `xml:"suppr" json:"suppressed,omitempty"`
+	Length      string      `xml:"length" json:"length,omitempty"`
+	DetachFront bool        `xml:"detachFront" json:"detachFront,omitempty"`
+}
+
+type TSTimeData struct {
+	Estimated        string `xml:"et,attr" json:"estimated,omitempty"`
+	WorkingEstimated string `xml:"wet,attr" json:"workingEstimated,omitempty"`
+	Actual           string `xml:"at,attr" json:"actual,omitempty"`
+	ActualRemoved    bool   `xml:"atRemoved,attr" json:"actualRemoved,omitempty"`
+	ActualClass      string `xml:"atClass,attr" json:"actualClass,omitempty"`
+	EstimatedMinimum string `xml:"etmin,attr" json:"estimatedMinimum,omitempty"`
+	EstimateUnknown  bool   `xml:"etUnknown,attr" json:"estimateUnknown,omitempty"`
+	Delayed          bool   `xml:"delayed,attr" json:"delayed,omitempty"`
+	Source           string `xml:"src,attr" json:"source,omitempty"`
+	SourceInstance   string `xml:"srcInst,attr" json:"sourceInstance,omitempty"`
+}
+
+// StationMessage (OW) is a disruption notice for one or more stations. A message without any stations deletes the
+// previous message with the same ID.
+type StationMessage struct {
+	ID       string                  `xml:"id,attr" json:"id,omitempty"`
+	Category string                  `xml:"cat,attr" json:"category,omitempty"`
+	Severity string                  `xml:"sev,attr" json:"severity,omitempty"`
+	Suppress bool                    `xml:"suppress,attr" json:"suppress,omitempty"`
+	Stations []StationMessageStation `xml:"Station" json:"stations,omitempty"`
+	Msg      StationMessageBody      `xml:"Msg" json:"msg,omitempty"`
+}
+
+type StationMessageStation struct {
+	CRS string `xml:"crs,attr" json:"crs,omitempty"`
+}
+
+// StationMessageBody holds the XHTML content of a station message
+type StationMessageBody struct {
+	InnerXML string `xml:",innerxml" json:"innerXml,omitempty"`
+}
+
+type Platform struct {
+	Number        string `xml:",chardata" json:"number,omitempty"`
+	Suppressed    bool   `xml:"platsup,attr" json:"suppressed,omitempty"`
+	CisSuppressed bool   `xml:"cisPlatsup,attr" json:"cisSuppressed,omitempty"`
+	Source        string `xml:"platsrc,attr" json:"source,omitempty"`
+	Confirmed     bool   `xml:"conf,attr" json:"confirmed,omitempty"`
+}
+
+// boolAttr parses an optional boolean attribute, using the schema's default when it isn't present
+func boolAttr(value string, defaultValue bool) bool {
+	if value == "" {
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return defaultValue
+	}
+	return b
+}
+
+func (s *Schedule) PassengerService() bool {
+	return boolAttr(s.IsPassengerSvc, true)
+}
+
+func (s *Schedule) Active() bool {
+	return boolAttr(s.IsActive, true)
+}
+
+func (s *Schedule) IsDeleted() bool {
+	return boolAttr(s.Deleted, false)
+}
+
+func (s *Schedule) Charter() bool {
+	return boolAttr(s.IsCharter, false)
+}
+
+// Text returns the message with its markup removed
+func (b StationMessageBody) Text() string {
+	decoder := xml.NewDecoder(strings.NewReader("<Msg>" + b.InnerXML + "</Msg>"))
+	decoder.Strict = false
+
+	var text strings.Builder
+	for {
+		tok, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		if data, ok := tok.(xml.CharData); ok {
+			text.Write(data)
+		}
+	}
+
+	return strings.Join(strings.Fields(text.String()), " ")
+}
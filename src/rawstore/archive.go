@@ -0,0 +1,129 @@
+package rawstore
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+var ErrArchiveNotFound = errors.New("archive file not found")
+
+// ArchiveReader reads the hourly archives back, from the local workdir where they're still on disk, or from the
+// bucket they were uploaded to otherwise
+type ArchiveReader struct {
+	workdir    string
+	s3client   *s3.Client
+	bucketName string
+	pathPrefix string
+}
+
+func NewArchiveReaderFromEnv(s3client *s3.Client) *ArchiveReader {
+	return &ArchiveReader{
+		workdir:    os.Getenv("PUSH_PORT_DUMP_WORKDIR"),
+		s3client:   s3client,
+		bucketName: os.Getenv("S3_COMPATIBLE_BUCKET_NAME"),
+		pathPrefix: os.Getenv("S3_PUSH_PORT_DUMP_PATH_PREFIX"),
+	}
+}
+
+// HoursBetween returns the start of every archive hour from the hour containing from, up to and including the hour
+// containing to
+func HoursBetween(from time.Time, to time.Time) []time.Time {
+	var hours []time.Time
+	for hour := from.UTC().Truncate(time.Hour); !hour.After(to); hour = hour.Add(time.Hour) {
+		hours = append(hours, hour)
+	}
+	return hours
+}
+
+// ReadHour calls fn with every message archived in the hour starting at hour. Archived messages don't keep the exact
+// time they were received, so MessageTime is set to the start of the hour. Returns ErrArchiveNotFound if the hour
+// isn't archived locally or in the bucket.
+func (r *ArchiveReader) ReadHour(ctx context.Context, hour time.Time, fn func(msg *XmlMessageWithTime) error) error {
+	filePath := getFilePathForTime(hour.UTC())
+
+	reader, err := r.open(ctx, filePath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	buffered := bufio.NewReaderSize(reader, 1<<20)
+	for {
+		line, err := buffered.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if line != "" {
+			fnErr := fn(&XmlMessageWithTime{
+				MessageTime: hour.UTC(),
+				Message:     line,
+			})
+			if fnErr != nil {
+				return fnErr
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive %s: %v", filePath, err)
+		}
+	}
+}
+
+func (r *ArchiveReader) open(ctx context.Context, filePath string) (io.ReadCloser, error) {
+	if r.workdir != "" {
+		f, err := os.Open(path.Join(r.workdir, filePath))
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	if r.s3client == nil || r.bucketName == "" {
+		return nil, ErrArchiveNotFound
+	}
+
+	obj, err := r.s3client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(r.bucketName),
+		Key:    aws.String(path.Join(r.pathPrefix, filePath+".gz")),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, ErrArchiveNotFound
+		}
+		return nil, err
+	}
+
+	gzReader, err := gzip.NewReader(obj.Body)
+	if err != nil {
+		_ = obj.Body.Close()
+		return nil, err
+	}
+
+	return &gzipObjectReader{Reader: gzReader, body: obj.Body}, nil
+}
+
+type gzipObjectReader struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (g *gzipObjectReader) Close() error {
+	_ = g.Reader.Close()
+	return g.body.Close()
+}
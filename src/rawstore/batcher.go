@@ -0,0 +1,99 @@
+package rawstore
+
+import (
+	"gemini-push-port/logging"
+	"sync"
+	"time"
+)
+
+// Batcher queues items and passes them to a write function in batches, from its own goroutine, so a slow writer never
+// holds up the caller. Items are dropped if the queue is full.
+type Batcher[T any] struct {
+	name          string
+	write         func(batch []T)
+	items         chan T
+	batchSize     int
+	flushInterval time.Duration
+
+	mu      sync.Mutex
+	closed  bool
+	dropped int
+	done    chan struct{}
+}
+
+// NewBatcher creates a batcher which queues up to queueSize items, and writes up to batchSize at a time, at least
+// every flushInterval. The name is used when logging dropped items.
+func NewBatcher[T any](name string, queueSize int, batchSize int, flushInterval time.Duration, write func(batch []T)) *Batcher[T] {
+	return &Batcher[T]{
+		name:          name,
+		write:         write,
+		items:         make(chan T, queueSize),
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		done:          make(chan struct{}),
+	}
+}
+
+// Add queues an item, unless the queue is full or the batcher is closed
+func (b *Batcher[T]) Add(item T) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	select {
+	case b.items <- item:
+		if b.dropped > 0 {
+			logging.Logger.Warnf("Dropped %d messages while the %s queue was full", b.dropped, b.name)
+			b.dropped = 0
+		}
+	default:
+		b.dropped++
+	}
+}
+
+// Thread writes queued items until the batcher is closed
+func (b *Batcher[T]) Thread() {
+	defer close(b.done)
+
+	ticker := time.NewTicker(b.flushInterval)
+	defer ticker.Stop()
+
+	var batch []T
+	for {
+		select {
+		case item, ok := <-b.items:
+			if !ok {
+				if len(batch) > 0 {
+					b.write(batch)
+				}
+				return
+			}
+			batch = append(batch, item)
+			if len(batch) < b.batchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+
+		b.write(batch)
+		batch = nil
+	}
+}
+
+// Close stops queueing items, and waits for those already queued to be written
+func (b *Batcher[T]) Close() {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.items)
+	}
+	b.mu.Unlock()
+
+	<-b.done
+}
@@ -0,0 +1,31 @@
+package rawstore
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBatcher(t *testing.T) {
+	var batches [][]int
+	b := NewBatcher("test", 100, 3, time.Hour, func(batch []int) {
+		batches = append(batches, slices.Clone(batch))
+	})
+	for i := range 7 {
+		b.Add(i)
+	}
+	go b.Thread()
+	// the last, partial batch is written on closing, long before the flush interval
+	b.Close()
+
+	want := [][]int{{0, 1, 2}, {3, 4, 5}, {6}}
+	if !slices.EqualFunc(batches, want, slices.Equal) {
+		t.Errorf("batches %v, want %v", batches, want)
+	}
+
+	// nothing is queued once closed
+	b.Add(7)
+	if len(b.items) != 0 {
+		t.Errorf("%d items queued after closing", len(b.items))
+	}
+}
@@ -3,8 +3,10 @@ package rawstore
 import (
 	"fmt"
 	"gemini-push-port/logging"
+	"gemini-push-port/metrics"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -19,7 +21,13 @@ func CleanUpLocalFilesJob() {
 	nowTime := time.Now().UTC()
 	cleanupCutoff := nowTime.Add(-48 * time.Hour)
 
-	err := recursiveDeletionWalk(workDir, cleanupCutoff)
+	err := recursiveDeletionWalk(workDir, cleanupCutoff, archiveFileExtension)
+	if err == nil {
+		err = recursiveDeletionWalk(workDir, cleanupCutoff, NDJSONFileExtension)
+	}
+	if err == nil {
+		err = recursiveDeletionWalk(getQuarantineDir(workDir), cleanupCutoff, quarantineFileExtension)
+	}
 	if err != nil {
 		logging.Logger.ErrorE("failed to clean up local files", err)
 	} else {
@@ -27,7 +35,7 @@ func CleanUpLocalFilesJob() {
 	}
 }
 
-func recursiveDeletionWalk(dir string, cutoff time.Time) error {
+func recursiveDeletionWalk(dir string, cutoff time.Time, extension string) error {
 	absDir, err := filepath.Abs(dir)
 	if err != nil {
 		return fmt.Errorf("failed to get absolute path of %s: %v", dir, err)
@@ -36,6 +44,10 @@ func recursiveDeletionWalk(dir string, cutoff time.Time) error {
 	// delete files older than 48 hours by their file path, looking for files that match the pattern {workdir}/YYYY/MM/DD/HH.pport
 	return filepath.WalkDir(absDir, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
+			if os.IsNotExist(err) && path == absDir {
+				// nothing has been written here yet
+				return filepath.SkipDir
+			}
 			return err
 		}
 
@@ -51,7 +63,7 @@ func recursiveDeletionWalk(dir string, cutoff time.Time) error {
 		}
 
 		var year, month, day, hour int
-		n, err := fmt.Sscanf(relPath, "%d/%d/%d/%d.pport", &year, &month, &day, &hour)
+		n, err := fmt.Sscanf(relPath, "%d/%d/%d/%d"+extension, &year, &month, &day, &hour)
 		if err != nil || n != 4 {
 			// not a file we care about
 			return nil
@@ -64,6 +76,8 @@ func recursiveDeletionWalk(dir string, cutoff time.Time) error {
 			err := os.Remove(path)
 			if err != nil {
 				logging.Logger.Warnf("failed to delete file %s: %v", path, err)
+			} else {
+				metrics.CleanupDeletions.WithLabelValues(metrics.Topic(), strings.TrimPrefix(extension, ".")).Inc()
 			}
 		}
 
@@ -5,15 +5,30 @@ import (
 	"compress/gzip"
 	"context"
 	"gemini-push-port/logging"
+	"gemini-push-port/metrics"
 	"io"
 	"os"
 	"path"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 )
 
+// lastUpload is when an hourly file was last uploaded successfully, in Unix nanoseconds
+var lastUpload atomic.Int64
+
+// LastUpload returns when an hourly file was last uploaded successfully, or zero if none have been
+func LastUpload() time.Time {
+	nanos := lastUpload.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
 func DumpToBucketJob(s3client *s3.Client) {
 	logging.Logger.Infof("Starting dump to bucket job...")
 
@@ -25,18 +40,34 @@ func DumpToBucketJob(s3client *s3.Client) {
 		XmlMessageWithTime{MessageTime: nowTime}.GetFilePath(),
 	}
 
+	// the NDJSON files are optional, so are only uploaded when they've been written
+	for _, t := range []time.Time{nowTime.Add(-1 * time.Hour), nowTime} {
+		filePath := XmlMessageWithTime{MessageTime: t}.GetFilePathWithExtension(NDJSONFileExtension)
+		_, err := os.Stat(path.Join(os.Getenv("PUSH_PORT_DUMP_WORKDIR"), filePath))
+		if err == nil {
+			hourlyFiles = append(hourlyFiles, filePath)
+		}
+	}
+
 	for _, filePath := range hourlyFiles {
-		err := uploadToS3(s3client, filePath)
+		extension := strings.TrimPrefix(path.Ext(filePath), ".")
+		start := time.Now()
+		size, err := uploadToS3(s3client, filePath)
 		if err != nil {
+			metrics.UploadFailures.WithLabelValues(metrics.Topic(), extension).Inc()
 			logging.Logger.Errorf(err, "failed to upload file %s to S3", filePath)
 			continue
 		} else {
+			metrics.UploadDuration.WithLabelValues(metrics.Topic(), extension).Observe(time.Since(start).Seconds())
+			metrics.UploadSize.WithLabelValues(metrics.Topic(), extension).Observe(float64(size))
+			lastUpload.Store(time.Now().UnixNano())
 			logging.Logger.Infof("successfully uploaded file %s to S3", filePath)
 		}
 	}
 }
 
-func uploadToS3(s3client *s3.Client, filePath string) error {
+// uploadToS3 gzips a file from the workdir and uploads it, returning the compressed size
+func uploadToS3(s3client *s3.Client, filePath string) (int, error) {
 	workDir := os.Getenv("PUSH_PORT_DUMP_WORKDIR")
 	r2PathPrefix := os.Getenv("S3_PUSH_PORT_DUMP_PATH_PREFIX")
 	bucketName := os.Getenv("S3_COMPATIBLE_BUCKET_NAME")
@@ -46,7 +77,7 @@ func uploadToS3(s3client *s3.Client, filePath string) error {
 
 	file, err := os.OpenFile(localFilePath, os.O_RDONLY, 0644)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	defer func(file *os.File) {
 		err := file.Close()
@@ -60,13 +91,13 @@ func uploadToS3(s3client *s3.Client, filePath string) error {
 	gzWriter := gzip.NewWriter(&b)
 	_, err = io.Copy(gzWriter, file)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	// It's important to close the writer before reading from the buffer.
 	// Closing the writer flushes any buffered data and writes the gzip footer.
 	if err := gzWriter.Close(); err != nil {
-		return err
+		return 0, err
 	}
 
 	_, err = s3client.PutObject(context.Background(), &s3.PutObjectInput{
@@ -75,8 +106,8 @@ func uploadToS3(s3client *s3.Client, filePath string) error {
 		Body:   bytes.NewReader(b.Bytes()),
 	})
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return b.Len(), nil
 }
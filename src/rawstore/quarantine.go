@@ -0,0 +1,107 @@
+package rawstore
+
+import (
+	"encoding/json"
+	"errors"
+	"gemini-push-port/logging"
+	"gemini-push-port/metrics"
+	"gemini-push-port/validation"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const quarantineFileExtension = ".quarantine"
+
+// report the first quarantined message, then every Nth one after that, so a burst of bad messages doesn't flood Sentry
+const defaultQuarantineReportEvery = 100
+
+var (
+	quarantinedMalformed atomic.Int64
+	quarantinedSchema    atomic.Int64
+)
+
+type quarantinedMessage struct {
+	MessageTime time.Time `json:"messageTime"`
+	Kind        string    `json:"kind"`
+	Error       string    `json:"error"`
+	Message     string    `json:"message"`
+}
+
+func getQuarantineDir(workdir string) string {
+	quarantineDir := os.Getenv("PUSH_PORT_QUARANTINE_WORKDIR")
+	if quarantineDir == "" {
+		quarantineDir = filepath.Join(workdir, "quarantine")
+	}
+	return quarantineDir
+}
+
+func getQuarantineReportEvery() int64 {
+	reportEvery, err := strconv.ParseInt(os.Getenv("PUSH_PORT_QUARANTINE_REPORT_EVERY"), 10, 64)
+	if err != nil || reportEvery < 1 {
+		return defaultQuarantineReportEvery
+	}
+	return reportEvery
+}
+
+func quarantineMessage(quarantineDir string, reportEvery int64, msg *XmlMessageWithTime, validationErr error) {
+	kind := validation.KindMalformed
+	var vErr *validation.Error
+	if errors.As(validationErr, &vErr) {
+		kind = vErr.Kind
+	}
+
+	var count int64
+	if kind == validation.KindSchema {
+		count = quarantinedSchema.Add(1)
+	} else {
+		count = quarantinedMalformed.Add(1)
+	}
+	metrics.MessagesQuarantined.WithLabelValues(metrics.Topic(), string(kind)).Inc()
+
+	if count%reportEvery == 1 || reportEvery == 1 {
+		logging.Logger.
+			WithField("quarantineKind", kind).
+			WithField("quarantineCount", count).
+			Errorf(validationErr, "quarantined %s message (%d since startup)", kind, count)
+	}
+
+	line, err := json.Marshal(quarantinedMessage{
+		MessageTime: msg.MessageTime,
+		Kind:        string(kind),
+		Error:       validationErr.Error(),
+		Message:     msg.Message,
+	})
+	if err != nil {
+		logging.Logger.ErrorE("failed to marshal quarantined message", err)
+		return
+	}
+
+	filePath := path.Join(quarantineDir, getFilePathForTimeWithExtension(msg.MessageTime, quarantineFileExtension))
+
+	err = os.MkdirAll(path.Dir(filePath), 0755)
+	if err != nil {
+		logging.Logger.ErrorE("failed to create quarantine directory", err)
+		return
+	}
+
+	f, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		logging.Logger.ErrorE("failed to open quarantine file", err)
+		return
+	}
+	defer func(f *os.File) {
+		err := f.Close()
+		if err != nil {
+			logging.Logger.ErrorE("failed to close file", err)
+		}
+	}(f)
+
+	_, err = f.Write(append(line, '\n'))
+	if err != nil {
+		logging.Logger.ErrorE("failed to write quarantined message", err)
+	}
+}
@@ -2,12 +2,30 @@ package rawstore
 
 import (
 	"gemini-push-port/logging"
+	"gemini-push-port/metrics"
+	"gemini-push-port/validation"
 	"os"
 	"path"
 	"strings"
 )
 
-func Thread(rawMessageChan chan *XmlMessageWithTime) {
+// ArchiveLocation is where a message was written in the hourly archives
+type ArchiveLocation struct {
+	// File is the archive's path relative to the workdir, e.g. 2025/09/19/14.pport
+	File string
+	// Offset is the byte offset of the message's line within the uncompressed file
+	Offset int64
+}
+
+// Observer is told about every message once it has been appended to the hourly archive. It's called from the archiving
+// goroutine, so must not block.
+type Observer interface {
+	MessageArchived(msg *XmlMessageWithTime, location ArchiveLocation)
+}
+
+// Thread archives every message received on rawMessageChan, telling each observer where it was written. Messages which
+// fail validation are written to the quarantine tree instead of the hourly archive.
+func Thread(rawMessageChan chan *XmlMessageWithTime, validator *validation.Validator, observers ...Observer) {
 	workdir := os.Getenv("PUSH_PORT_DUMP_WORKDIR")
 	if workdir == "" {
 		panic("PUSH_PORT_DUMP_WORKDIR environment variable not set")
@@ -19,6 +37,9 @@ func Thread(rawMessageChan chan *XmlMessageWithTime) {
 		panic(err)
 	}
 
+	quarantineDir := getQuarantineDir(workdir)
+	quarantineReportEvery := getQuarantineReportEvery()
+
 	for {
 		msg, ok := <-rawMessageChan
 		if !ok {
@@ -26,14 +47,32 @@ func Thread(rawMessageChan chan *XmlMessageWithTime) {
 			return
 		}
 
-		err := appendMessageToFile(workdir, msg)
+		validationErr := validator.Validate(msg.Message)
+		if validationErr != nil {
+			quarantineMessage(quarantineDir, quarantineReportEvery, msg, validationErr)
+			continue
+		}
+
+		location, err := appendMessageToFile(workdir, msg)
 		if err != nil {
 			logging.Logger.ErrorE("failed to append message to file", err)
+			continue
+		}
+		for _, observer := range observers {
+			observer.MessageArchived(msg, location)
 		}
 	}
 }
 
-func appendMessageToFile(workdir string, msg *XmlMessageWithTime) error {
+// ArchiveLine returns a message as it's written to the archive, with its line breaks replaced by spaces
+func ArchiveLine(message string) string {
+	return archiveLineReplacer.Replace(message)
+}
+
+var archiveLineReplacer = strings.NewReplacer("\n", " ", "\r", " ")
+
+// appendMessageToFile appends the message to its hourly archive as a single line, returning where it was written
+func appendMessageToFile(workdir string, msg *XmlMessageWithTime) (ArchiveLocation, error) {
 	filePath := path.Join(workdir, msg.GetFilePath())
 
 	// ensure the directory exists
@@ -45,7 +84,7 @@ func appendMessageToFile(workdir string, msg *XmlMessageWithTime) error {
 	// append the message to the file
 	f, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
-		return err
+		return ArchiveLocation{}, err
 	}
 	defer func(f *os.File) {
 		err := f.Close()
@@ -53,12 +92,18 @@ func appendMessageToFile(workdir string, msg *XmlMessageWithTime) error {
 			logging.Logger.ErrorE("failed to close file", err)
 		}
 	}(f)
-	cleanMsg := strings.ReplaceAll(msg.Message, "\n", " ")
-	cleanMsg = strings.ReplaceAll(cleanMsg, "\r", " ")
-	_, err = f.WriteString(cleanMsg + "\n")
+	// this is the only goroutine appending to the archives, so the line starts at the current end of the file
+	info, err := f.Stat()
+	if err != nil {
+		return ArchiveLocation{}, err
+	}
+	location := ArchiveLocation{File: msg.GetFilePath(), Offset: info.Size()}
+
+	n, err := f.WriteString(ArchiveLine(msg.Message) + "\n")
+	metrics.ArchiveBytesWritten.WithLabelValues(metrics.Topic()).Add(float64(n))
 	if err != nil {
-		return err
+		return ArchiveLocation{}, err
 	}
 
-	return nil
+	return location, nil
 }
@@ -2,6 +2,12 @@ package rawstore
 
 import "time"
 
+const archiveFileExtension = ".pport"
+
+// NDJSONFileExtension is the extension of the hourly JSON files written alongside the archive, which are uploaded and
+// cleaned up in the same way
+const NDJSONFileExtension = ".ndjson"
+
 type XmlMessageWithTime struct {
 	MessageTime time.Time
 	Message     string
@@ -11,6 +17,15 @@ func (x XmlMessageWithTime) GetFilePath() string {
 	return getFilePathForTime(x.MessageTime)
 }
 
+// GetFilePathWithExtension returns the path of the hourly file with the given extension which the message belongs in
+func (x XmlMessageWithTime) GetFilePathWithExtension(extension string) string {
+	return getFilePathForTimeWithExtension(x.MessageTime, extension)
+}
+
 func getFilePathForTime(t time.Time) string {
-	return t.Format("2006/01/02/15") + ".pport"
+	return getFilePathForTimeWithExtension(t, archiveFileExtension)
+}
+
+func getFilePathForTimeWithExtension(t time.Time, extension string) string {
+	return t.Format("2006/01/02/15") + extension
 }
@@ -0,0 +1,148 @@
+package refdata
+
+import (
+	"gemini-push-port/pushport"
+	"gemini-push-port/trainstate"
+	"slices"
+	"strings"
+)
+
+// LocationName returns the name of a location, or the TIPLOC itself if it isn't known
+func (s *Store) LocationName(tiploc string) string {
+	if loc, ok := s.Tables().Locations[tiploc]; ok && loc.Name != "" {
+		return loc.Name
+	}
+	return tiploc
+}
+
+// CRS returns the CRS code for a TIPLOC, if it has one
+func (s *Store) CRS(tiploc string) string {
+	return s.Tables().Locations[tiploc].CRS
+}
+
+// TiplocsForCRS returns the TIPLOCs which make up a station. Codes which aren't known are treated as TIPLOCs.
+func (s *Store) TiplocsForCRS(crs string) []string {
+	crs = strings.ToUpper(crs)
+	if tiplocs, ok := s.Tables().TiplocsByCRS[crs]; ok {
+		return tiplocs
+	}
+	return []string{crs}
+}
+
+// OperatorName returns the name of a train operating company, or its code if it isn't known
+func (s *Store) OperatorName(toc string) string {
+	if op, ok := s.Tables().Operators[toc]; ok && op.Name != "" {
+		return op.Name
+	}
+	return toc
+}
+
+func (s *Store) CISSourceName(code string) string {
+	if name, ok := s.Tables().CISSources[code]; ok {
+		return name
+	}
+	return code
+}
+
+// LateReasonText returns the passenger-facing text for a late running reason, including where it happened
+func (s *Store) LateReasonText(reason *pushport.Reason) string {
+	return s.reasonText(s.Tables().LateReasons, reason)
+}
+
+// CancelReasonText returns the passenger-facing text for a cancellation reason, including where it happened
+func (s *Store) CancelReasonText(reason *pushport.Reason) string {
+	return s.reasonText(s.Tables().CancelReasons, reason)
+}
+
+func (s *Store) reasonText(table map[string]string, reason *pushport.Reason) string {
+	if reason == nil {
+		return ""
+	}
+
+	text, ok := table[strings.TrimSpace(reason.Code)]
+	if !ok {
+		return ""
+	}
+
+	if reason.Tiploc != "" {
+		if reason.Near {
+			text += " near " + s.LocationName(reason.Tiploc)
+		} else {
+			text += " at " + s.LocationName(reason.Tiploc)
+		}
+	}
+	return text
+}
+
+// ViaText returns the via text to show for a service at a station, given the TIPLOCs it calls at after the station
+func (s *Store) ViaText(atCRS string, destTiploc string, subsequentTiplocs []string) string {
+	for _, via := range s.Tables().Vias[viaKey(atCRS, destTiploc)] {
+		i := slices.Index(subsequentTiplocs, via.Loc1)
+		if i < 0 {
+			continue
+		}
+		if via.Loc2 != "" && !slices.Contains(subsequentTiplocs[i+1:], via.Loc2) {
+			continue
+		}
+		return via.Text
+	}
+	return ""
+}
+
+// LocationNames are the names and CRS codes of a set of TIPLOCs
+type LocationNames map[string]LocationName
+
+type LocationName struct {
+	Name string `json:"name"`
+	CRS  string `json:"crs,omitempty"`
+}
+
+// ServiceNames are the human-readable names for the codes used by a service
+type ServiceNames struct {
+	Operator     string        `json:"operator"`
+	LateReason   string        `json:"lateReason,omitempty"`
+	CancelReason string        `json:"cancelReason,omitempty"`
+	Locations    LocationNames `json:"locations"`
+}
+
+func (s *Store) NamesForService(svc *trainstate.Service) ServiceNames {
+	names := ServiceNames{
+		Operator:     s.OperatorName(svc.TOC),
+		LateReason:   s.LateReasonText(svc.LateReason),
+		CancelReason: s.CancelReasonText(svc.CancelReason),
+		Locations:    make(LocationNames),
+	}
+
+	tiplocs := make([]string, 0, len(svc.Locations))
+	for _, loc := range svc.Locations {
+		tiplocs = append(tiplocs, loc.Tiploc)
+	}
+	for _, a := range svc.Associations {
+		tiplocs = append(tiplocs, a.Tiploc)
+	}
+	names.Locations = s.NamesForTiplocs(tiplocs)
+
+	return names
+}
+
+func (s *Store) NamesForTiplocs(tiplocs []string) LocationNames {
+	names := make(LocationNames, len(tiplocs))
+	for _, tiploc := range tiplocs {
+		names[tiploc] = LocationName{
+			Name: s.LocationName(tiploc),
+			CRS:  s.CRS(tiploc),
+		}
+	}
+	return names
+}
+
+// StationName returns the name of a station by its CRS code, or the code itself if it isn't known
+func (s *Store) StationName(crs string) string {
+	tables := s.Tables()
+	for _, tiploc := range tables.TiplocsByCRS[strings.ToUpper(crs)] {
+		if loc := tables.Locations[tiploc]; loc.Name != "" {
+			return loc.Name
+		}
+	}
+	return crs
+}
@@ -0,0 +1,87 @@
+package refdata
+
+import (
+	"context"
+	"gemini-push-port/darwinfiles"
+	"gemini-push-port/logging"
+	"os"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Store holds the latest reference data tables. Tables are swapped atomically when a newer file is loaded, so lookups
+// never see a partially loaded file.
+type Store struct {
+	tables atomic.Pointer[Tables]
+	source darwinfiles.Source
+}
+
+// NewStoreFromEnv creates a store which loads reference data from REFDATA_PATH (a file, or a directory containing
+// reference files), or from the bucket under REFDATA_S3_PREFIX. The newest file is loaded straight away. If neither is
+// configured, the store stays empty and every lookup falls back to the code it was given.
+func NewStoreFromEnv(s3client *s3.Client) *Store {
+	s := &Store{
+		source: darwinfiles.Source{
+			Kind:       "reference data",
+			LocalPath:  os.Getenv("REFDATA_PATH"),
+			S3Client:   s3client,
+			BucketName: os.Getenv("S3_COMPATIBLE_BUCKET_NAME"),
+			S3Prefix:   os.Getenv("REFDATA_S3_PREFIX"),
+			Match:      isReferenceFile,
+		},
+	}
+	s.tables.Store(emptyTables())
+
+	if s.Configured() {
+		err := s.Reload(context.Background())
+		if err != nil {
+			logging.Logger.ErrorE("failed to load reference data", err)
+		}
+	}
+
+	return s
+}
+
+// Configured returns whether a source of reference data has been configured
+func (s *Store) Configured() bool {
+	return s.source.Configured()
+}
+
+func (s *Store) Tables() *Tables {
+	return s.tables.Load()
+}
+
+// Reload loads the newest reference data file, if it is newer than the one currently loaded
+func (s *Store) Reload(ctx context.Context) error {
+	file, err := s.source.Newest(ctx)
+	if err != nil {
+		return err
+	}
+
+	if file.Name <= s.Tables().Source {
+		return nil
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	tables, err := parseTables(file.Name, reader)
+	if err != nil {
+		return err
+	}
+
+	s.tables.Store(tables)
+	logging.Logger.Infof("Loaded reference data %s with %d locations and %d operators", file.Name, len(tables.Locations), len(tables.Operators))
+	return nil
+}
+
+func ReloadJob(store *Store) {
+	err := store.Reload(context.Background())
+	if err != nil {
+		logging.Logger.ErrorE("failed to reload reference data", err)
+	}
+}
@@ -0,0 +1,164 @@
+package refdata
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type Location struct {
+	Tiploc string `xml:"tpl,attr" json:"tiploc"`
+	CRS    string `xml:"crs,attr" json:"crs,omitempty"`
+	TOC    string `xml:"toc,attr" json:"toc,omitempty"`
+	Name   string `xml:"locname,attr" json:"name"`
+}
+
+type Operator struct {
+	Code string `xml:"toc,attr" json:"code"`
+	Name string `xml:"tocname,attr" json:"name"`
+	URL  string `xml:"url,attr" json:"url,omitempty"`
+}
+
+type reason struct {
+	Code string `xml:"code,attr"`
+	Text string `xml:"reasontext,attr"`
+}
+
+// Via is the text shown after a destination on boards at a station, when the service calls at Loc1 (and Loc2, if
+// given) after the station
+type Via struct {
+	At   string `xml:"at,attr" json:"at"`
+	Dest string `xml:"dest,attr" json:"dest"`
+	Loc1 string `xml:"loc1,attr" json:"loc1"`
+	Loc2 string `xml:"loc2,attr" json:"loc2,omitempty"`
+	Text string `xml:"viatext,attr" json:"text"`
+}
+
+type cisSource struct {
+	Code string `xml:"code,attr"`
+	Name string `xml:"name,attr"`
+}
+
+// Tables are the lookup tables built from a single reference data file
+type Tables struct {
+	Source        string
+	TimetableID   string
+	Locations     map[string]Location
+	TiplocsByCRS  map[string][]string
+	Operators     map[string]Operator
+	LateReasons   map[string]string
+	CancelReasons map[string]string
+	// Vias are keyed by the CRS of the station they are shown at and the TIPLOC of the destination
+	Vias       map[string][]Via
+	CISSources map[string]string
+}
+
+func emptyTables() *Tables {
+	return &Tables{
+		Locations:     make(map[string]Location),
+		TiplocsByCRS:  make(map[string][]string),
+		Operators:     make(map[string]Operator),
+		LateReasons:   make(map[string]string),
+		CancelReasons: make(map[string]string),
+		Vias:          make(map[string][]Via),
+		CISSources:    make(map[string]string),
+	}
+}
+
+func viaKey(atCRS string, destTiploc string) string {
+	return atCRS + "|" + destTiploc
+}
+
+// parseTables decodes a Darwin reference data (PportTimetableRef) file
+func parseTables(source string, r io.Reader) (*Tables, error) {
+	tables := emptyTables()
+	tables.Source = source
+
+	decoder := xml.NewDecoder(r)
+
+	// reasons are listed under a parent element which says what kind of reason they are
+	var reasonTable map[string]string
+	foundRoot := false
+
+	for {
+		tok, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read reference data %s: %v", source, err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "PportTimetableRef":
+				foundRoot = true
+				for _, a := range t.Attr {
+					if a.Name.Local == "timetableId" {
+						tables.TimetableID = a.Value
+					}
+				}
+			case "LocationRef":
+				var loc Location
+				err = decoder.DecodeElement(&loc, &t)
+				if err == nil {
+					tables.Locations[loc.Tiploc] = loc
+					if loc.CRS != "" {
+						tables.TiplocsByCRS[loc.CRS] = append(tables.TiplocsByCRS[loc.CRS], loc.Tiploc)
+					}
+				}
+			case "TocRef":
+				var op Operator
+				err = decoder.DecodeElement(&op, &t)
+				if err == nil {
+					tables.Operators[op.Code] = op
+				}
+			case "LateRunningReasons":
+				reasonTable = tables.LateReasons
+			case "CancellationReasons":
+				reasonTable = tables.CancelReasons
+			case "Reason":
+				var rsn reason
+				err = decoder.DecodeElement(&rsn, &t)
+				if err == nil && reasonTable != nil {
+					reasonTable[rsn.Code] = rsn.Text
+				}
+			case "Via":
+				var via Via
+				err = decoder.DecodeElement(&via, &t)
+				if err == nil {
+					key := viaKey(via.At, via.Dest)
+					tables.Vias[key] = append(tables.Vias[key], via)
+				}
+			case "CISSource":
+				var src cisSource
+				err = decoder.DecodeElement(&src, &t)
+				if err == nil {
+					tables.CISSources[src.Code] = src.Name
+				}
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode <%s> in %s: %v", t.Name.Local, source, err)
+			}
+		case xml.EndElement:
+			if t.Name.Local == "LateRunningReasons" || t.Name.Local == "CancellationReasons" {
+				reasonTable = nil
+			}
+		}
+	}
+
+	if !foundRoot {
+		return nil, fmt.Errorf("%s is not a Darwin reference data file", source)
+	}
+
+	return tables, nil
+}
+
+// isReferenceFile returns whether a file name looks like a Darwin reference data file, e.g. 20250919020500_ref_v4.xml.gz
+func isReferenceFile(name string) bool {
+	name = strings.TrimSuffix(name, ".gz")
+	return strings.Contains(name, "_ref_v") && strings.HasSuffix(name, ".xml")
+}
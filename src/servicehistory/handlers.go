@@ -0,0 +1,41 @@
+package servicehistory
+
+import (
+	"errors"
+	"gemini-push-port/httpapi"
+	"net/http"
+	"strconv"
+)
+
+func (l *Lookup) RegisterRoutes(server *httpapi.Server) {
+	server.HandleFunc("GET /services/{rid}", l.handleGetService)
+}
+
+func (l *Lookup) handleGetService(w http.ResponseWriter, r *http.Request) {
+	includeRaw := false
+	if raw := r.URL.Query().Get("raw"); raw != "" {
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			httpapi.WriteError(w, r, http.StatusBadRequest, "raw must be true or false")
+			return
+		}
+		includeRaw = v
+	}
+
+	detail, err := l.Get(r.Context(), r.PathValue("rid"), includeRaw)
+	if errors.Is(err, ErrInvalidRID) {
+		httpapi.WriteError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if errors.Is(err, ErrServiceNotFound) {
+		httpapi.WriteError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		httpapi.Logger(r).ErrorE("failed to look up service", err)
+		httpapi.WriteError(w, r, http.StatusInternalServerError, "failed to look up service")
+		return
+	}
+
+	httpapi.WriteJSON(w, r, http.StatusOK, detail)
+}
@@ -0,0 +1,217 @@
+package servicehistory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gemini-push-port/pushport"
+	"gemini-push-port/rawstore"
+	"gemini-push-port/refdata"
+	"gemini-push-port/trainstate"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrServiceNotFound = errors.New("service not found")
+	ErrInvalidRID      = errors.New("invalid RID")
+)
+
+const (
+	SourceLive    = "live"
+	SourceArchive = "archive"
+)
+
+// how far either side of a service's start date to search the archive for messages about it
+const (
+	archiveLookbehind = 6 * time.Hour
+	archiveLookahead  = 32 * time.Hour
+)
+
+// ServiceDetail is the current state of a service, along with every update which touched it in order
+type ServiceDetail struct {
+	Service trainstate.Service `json:"service"`
+	// Names are the human-readable names for the codes used by the service
+	Names refdata.ServiceNames `json:"names"`
+	// Journey describes the services this one joins, divides from or forms
+	Journey []trainstate.JourneyLink `json:"journey"`
+	History []Update                 `json:"history"`
+	Source  string                   `json:"source"`
+}
+
+// Lookup finds services in the live train state, or rebuilds them from the archive when they are no longer live
+type Lookup struct {
+	engine   *trainstate.Engine
+	recorder *Recorder
+	archive  *rawstore.ArchiveReader
+	names    *refdata.Store
+}
+
+// NewLookup creates a Lookup. engine and recorder may be nil, in which case services are always read from the archive.
+func NewLookup(engine *trainstate.Engine, recorder *Recorder, archive *rawstore.ArchiveReader, names *refdata.Store) *Lookup {
+	return &Lookup{
+		engine:   engine,
+		recorder: recorder,
+		archive:  archive,
+		names:    names,
+	}
+}
+
+func (l *Lookup) Get(ctx context.Context, rid string, includeRaw bool) (*ServiceDetail, error) {
+	if l.engine != nil && l.recorder != nil {
+		svc, ok := l.engine.Get(rid)
+		history := l.recorder.Get(rid)
+		if ok && len(history) > 0 {
+			detail := &ServiceDetail{
+				Service: svc,
+				Journey: l.engine.Journey(rid),
+				History: history,
+				Source:  SourceLive,
+			}
+			detail.Names = l.namesFor(detail)
+
+			if includeRaw {
+				// read back the raw messages from only the hours we know the service was updated in
+				var hours []time.Time
+				for _, update := range history {
+					hour := update.ReceivedAt.UTC().Truncate(time.Hour)
+					if !slices.ContainsFunc(hours, hour.Equal) {
+						hours = append(hours, hour)
+					}
+				}
+
+				_, archived, err := l.readArchive(ctx, rid, hours)
+				if err != nil {
+					return nil, err
+				}
+				detail.History = archived
+			}
+
+			return detail, nil
+		}
+	}
+
+	ssd, err := startDateFromRID(rid)
+	if err != nil {
+		return nil, err
+	}
+
+	engine, history, err := l.readArchive(ctx, rid, rawstore.HoursBetween(ssd.Add(-archiveLookbehind), ssd.Add(archiveLookahead)))
+	if err != nil {
+		return nil, err
+	}
+	if len(history) == 0 {
+		return nil, ErrServiceNotFound
+	}
+
+	if !includeRaw {
+		for i := range history {
+			history[i].Raw = ""
+		}
+	}
+
+	svc, _ := engine.Get(rid)
+	detail := &ServiceDetail{
+		Service: svc,
+		Journey: engine.Journey(rid),
+		History: history,
+		Source:  SourceArchive,
+	}
+	detail.Names = l.namesFor(detail)
+	return detail, nil
+}
+
+func (l *Lookup) namesFor(detail *ServiceDetail) refdata.ServiceNames {
+	names := l.names.NamesForService(&detail.Service)
+
+	var journeyTiplocs []string
+	for _, link := range detail.Journey {
+		journeyTiplocs = append(journeyTiplocs, link.OtherOrigin, link.OtherDestination)
+	}
+	for tiploc, name := range l.names.NamesForTiplocs(journeyTiplocs) {
+		if tiploc != "" {
+			names.Locations[tiploc] = name
+		}
+	}
+
+	return names
+}
+
+// readArchive replays every archived message about a service in the given hours, rebuilding its state as it goes.
+// Messages about services it is associated with are replayed too, so that the combined journey can be described.
+func (l *Lookup) readArchive(ctx context.Context, rid string, hours []time.Time) (*trainstate.Engine, []Update, error) {
+	engine := trainstate.NewEngine(archiveLookahead + archiveLookbehind)
+	var history []Update
+	relevant := map[string]bool{rid: true}
+
+	now := time.Now()
+	for _, hour := range hours {
+		if hour.After(now) {
+			break
+		}
+
+		err := l.archive.ReadHour(ctx, hour, func(raw *rawstore.XmlMessageWithTime) error {
+			// avoid parsing the many messages which can't be about these services
+			if !containsAny(raw.Message, relevant) {
+				return nil
+			}
+
+			msg, err := pushport.NewMessage(raw)
+			if err != nil {
+				return nil
+			}
+
+			response := msg.Pport.Response()
+			rids := response.RIDs()
+			if !slices.ContainsFunc(rids, func(r string) bool { return relevant[r] }) {
+				return nil
+			}
+
+			engine.HandleMessage(msg)
+
+			if slices.Contains(rids, rid) {
+				history = append(history, newUpdate(msg, true))
+				for _, a := range response.Associations {
+					relevant[a.Main.RID] = true
+					relevant[a.Assoc.RID] = true
+				}
+			}
+			return nil
+		})
+		if errors.Is(err, rawstore.ErrArchiveNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	slices.SortStableFunc(history, func(a, b Update) int {
+		return a.Time.Compare(b.Time)
+	})
+
+	return engine, history, nil
+}
+
+func containsAny(s string, substrings map[string]bool) bool {
+	for substring := range substrings {
+		if strings.Contains(s, substring) {
+			return true
+		}
+	}
+	return false
+}
+
+// startDateFromRID returns midnight UTC on the date a RID was allocated, which is encoded in its first 8 digits
+func startDateFromRID(rid string) (time.Time, error) {
+	if len(rid) < 8 {
+		return time.Time{}, fmt.Errorf("%w %q", ErrInvalidRID, rid)
+	}
+
+	ssd, err := time.Parse("20060102", rid[:8])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w %q", ErrInvalidRID, rid)
+	}
+	return ssd, nil
+}
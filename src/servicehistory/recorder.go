@@ -0,0 +1,101 @@
+package servicehistory
+
+import (
+	"gemini-push-port/logging"
+	"gemini-push-port/pushport"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Update describes a single Push Port message which touched a service
+type Update struct {
+	// Time is Darwin's timestamp for the message
+	Time time.Time `json:"time"`
+	// ReceivedAt is when the message was received from Kafka. Messages read from the archive only know the hour they
+	// were received in.
+	ReceivedAt    time.Time `json:"receivedAt"`
+	UpdateOrigin  string    `json:"updateOrigin,omitempty"`
+	RequestSource string    `json:"requestSource,omitempty"`
+	Snapshot      bool      `json:"snapshot"`
+	Types         []string  `json:"types"`
+	Raw           string    `json:"raw,omitempty"`
+}
+
+func newUpdate(msg *pushport.Message, includeRaw bool) Update {
+	response := msg.Pport.Response()
+
+	update := Update{
+		Time:          msg.Time,
+		ReceivedAt:    msg.Raw.MessageTime,
+		UpdateOrigin:  response.UpdateOrigin,
+		RequestSource: response.RequestSource,
+		Snapshot:      msg.Pport.SnapshotResponse != nil,
+		Types:         response.MessageTypes(),
+	}
+	if includeRaw {
+		update.Raw = msg.Raw.Message
+	}
+	return update
+}
+
+// Recorder keeps a list of the updates received for every service seen on the live feed. The raw messages aren't
+// kept in memory, but can be read back from the archive for the hours listed.
+type Recorder struct {
+	mu        sync.RWMutex
+	updates   map[string][]Update
+	retention time.Duration
+}
+
+func NewRecorder(retention time.Duration) *Recorder {
+	return &Recorder{
+		updates:   make(map[string][]Update),
+		retention: retention,
+	}
+}
+
+func (r *Recorder) HandleMessage(msg *pushport.Message) {
+	rids := msg.Pport.Response().RIDs()
+	if len(rids) == 0 {
+		return
+	}
+
+	update := newUpdate(msg, false)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, rid := range rids {
+		r.updates[rid] = append(r.updates[rid], update)
+	}
+}
+
+// Get returns a copy of the updates recorded for a service
+func (r *Recorder) Get(rid string) []Update {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return slices.Clone(r.updates[rid])
+}
+
+// Expire removes services whose last update was received longer ago than the retention period
+func (r *Recorder) Expire(now time.Time) int {
+	cutoff := now.Add(-r.retention)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	removed := 0
+	for rid, updates := range r.updates {
+		if updates[len(updates)-1].ReceivedAt.Before(cutoff) {
+			delete(r.updates, rid)
+			removed++
+		}
+	}
+	return removed
+}
+
+func ExpireJob(recorder *Recorder) {
+	removed := recorder.Expire(time.Now())
+	logging.Logger.Infof("expired update history for %d services", removed)
+}
@@ -0,0 +1,141 @@
+package siri
+
+import (
+	"encoding/xml"
+	"gemini-push-port/httpapi"
+	"io"
+	"net/http"
+	"time"
+)
+
+const maxRequestSize = 1 << 20
+
+func (p *Producer) RegisterRoutes(server *httpapi.Server) {
+	server.HandleFunc("POST /siri", p.handleRequest)
+	server.HandleFunc("GET /siri/et", p.handleGetEstimatedTimetable)
+}
+
+// handleRequest answers a SIRI request document: an Estimated Timetable ServiceRequest, a SubscriptionRequest, a
+// TerminateSubscriptionRequest or a CheckStatusRequest
+func (p *Producer) handleRequest(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestSize))
+	if err != nil {
+		httpapi.WriteError(w, r, http.StatusBadRequest, "failed to read request")
+		return
+	}
+
+	var req Siri
+	err = xml.Unmarshal(body, &req)
+	if err != nil {
+		httpapi.WriteError(w, r, http.StatusBadRequest, "request must be a SIRI document")
+		return
+	}
+
+	now := time.Now()
+	resp := newSiri()
+
+	switch {
+	case req.ServiceRequest != nil:
+		resp.ServiceDelivery = p.handleServiceRequest(r, req.ServiceRequest, now)
+	case req.SubscriptionRequest != nil:
+		if !p.authenticated(r, req.SubscriptionRequest.RequestorRef) {
+			resp.SubscriptionResponse = &SubscriptionResponse{
+				ResponseTimestamp: formatTime(now),
+				ResponderRef:      p.producerRef,
+				ResponseStatus: []ResponseStatus{{
+					ResponseTimestamp: formatTime(now),
+					ErrorCondition:    accessNotAllowed(),
+				}},
+			}
+			break
+		}
+		resp.SubscriptionResponse = p.Subscribe(r.Context(), req.SubscriptionRequest, now)
+	case req.TerminateSubscriptionRequest != nil:
+		if !p.authenticated(r, req.TerminateSubscriptionRequest.RequestorRef) {
+			resp.TerminateSubscriptionResponse = &TerminateSubscriptionResponse{
+				ResponseTimestamp: formatTime(now),
+				ResponderRef:      p.producerRef,
+				TerminationResponseStatus: []TerminationResponseStatus{{
+					ResponseTimestamp: formatTime(now),
+					ErrorCondition:    accessNotAllowed(),
+				}},
+			}
+			break
+		}
+		resp.TerminateSubscriptionResponse = p.Terminate(req.TerminateSubscriptionRequest, now)
+	case req.CheckStatusRequest != nil:
+		resp.CheckStatusResponse = &CheckStatusResponse{
+			ResponseTimestamp:  formatTime(now),
+			ProducerRef:        p.producerRef,
+			Status:             true,
+			ServiceStartedTime: formatTime(p.startedAt),
+		}
+	default:
+		httpapi.WriteError(w, r, http.StatusBadRequest, "unsupported SIRI request")
+		return
+	}
+
+	writeSiri(w, r, resp)
+}
+
+func (p *Producer) handleServiceRequest(r *http.Request, req *ServiceRequest, now time.Time) *ServiceDelivery {
+	if !p.mayRead(r, req.RequestorRef) {
+		return p.errorDelivery(accessNotAllowed(), now)
+	}
+	if req.EstimatedTimetableRequest == nil {
+		return p.errorDelivery(otherError("only EstimatedTimetableRequest is supported"), now)
+	}
+
+	q, err := newQuery(req.EstimatedTimetableRequest, p.preview)
+	if err != nil {
+		return p.errorDelivery(otherError(err.Error()), now)
+	}
+	return p.Delivery(q, now)
+}
+
+// handleGetEstimatedTimetable is a convenience for requesting a delivery without building a SIRI request, taking the
+// requestorRef, operatorRef and previewInterval query parameters
+func (p *Producer) handleGetEstimatedTimetable(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	req := &ServiceRequest{
+		RequestorRef: params.Get("requestorRef"),
+		EstimatedTimetableRequest: &EstimatedTimetableRequest{
+			PreviewInterval: params.Get("previewInterval"),
+			OperatorRefs:    params["operatorRef"],
+		},
+	}
+
+	resp := newSiri()
+	resp.ServiceDelivery = p.handleServiceRequest(r, req, time.Now())
+	writeSiri(w, r, resp)
+}
+
+func (p *Producer) errorDelivery(condition *ErrorCondition, now time.Time) *ServiceDelivery {
+	status := false
+	return &ServiceDelivery{
+		ResponseTimestamp: formatTime(now),
+		ProducerRef:       p.producerRef,
+		Status:            &status,
+		ErrorCondition:    condition,
+	}
+}
+
+func accessNotAllowed() *ErrorCondition {
+	return &ErrorCondition{AccessNotAllowedError: &ErrorText{ErrorText: "requestor is not allowed"}}
+}
+
+func otherError(text string) *ErrorCondition {
+	return &ErrorCondition{OtherError: &ErrorText{ErrorText: text}}
+}
+
+func writeSiri(w http.ResponseWriter, r *http.Request, body *Siri) {
+	encoded, err := marshal(body)
+	if err != nil {
+		httpapi.Logger(r).ErrorE("failed to encode SIRI response", err)
+		httpapi.WriteError(w, r, http.StatusInternalServerError, "failed to encode response")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	_, _ = w.Write(encoded)
+}
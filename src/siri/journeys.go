@@ -0,0 +1,221 @@
+package siri
+
+import (
+	"errors"
+	"gemini-push-port/darwintime"
+	"gemini-push-port/pushport"
+	"gemini-push-port/refdata"
+	"gemini-push-port/trainstate"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// services whose last call was longer ago than this are left out of deliveries
+const finishedServiceGrace = time.Hour
+
+// query selects the journeys in a delivery. Darwin has no lines, so a service's operator is used as its line, and
+// LineRef and OperatorRef filters both match the operator.
+type query struct {
+	preview   time.Duration
+	operators map[string]bool
+}
+
+func newQuery(req *EstimatedTimetableRequest, defaultPreview time.Duration) (query, error) {
+	q := query{preview: defaultPreview}
+	if req == nil {
+		return q, nil
+	}
+
+	if req.PreviewInterval != "" {
+		preview, err := parseDuration(req.PreviewInterval)
+		if err != nil {
+			return q, err
+		}
+		q.preview = preview
+	}
+
+	for _, ref := range append(req.OperatorRefs, req.LineRefs...) {
+		if q.operators == nil {
+			q.operators = make(map[string]bool)
+		}
+		q.operators[strings.ToUpper(strings.TrimSpace(ref))] = true
+	}
+	return q, nil
+}
+
+// matches returns whether a service is running, or starts within the preview interval, and is run by one of the
+// requested operators
+func (q query) matches(svc *trainstate.Service, times []trainstate.LocationTimes, now time.Time) bool {
+	if !svc.HasSchedule || !svc.IsPassengerSvc || svc.IsDeleted {
+		return false
+	}
+	if q.operators != nil && !q.operators[svc.TOC] {
+		return false
+	}
+
+	var first, last time.Time
+	for _, t := range times {
+		for _, v := range []time.Time{t.ScheduledArrival, t.ScheduledDeparture, t.ExpectedArrival, t.ExpectedDeparture} {
+			if v.IsZero() {
+				continue
+			}
+			if first.IsZero() || v.Before(first) {
+				first = v
+			}
+			if v.After(last) {
+				last = v
+			}
+		}
+	}
+	return !first.IsZero() && last.After(now.Add(-finishedServiceGrace)) && first.Before(now.Add(q.preview))
+}
+
+var durationPattern = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// parseDuration reads the day and time parts of an xsd:duration, such as PT2H30M
+func parseDuration(s string) (time.Duration, error) {
+	m := durationPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil || s == "P" || strings.HasSuffix(s, "T") {
+		return 0, errors.New("durations must be given as PnDTnHnMnS")
+	}
+
+	var d time.Duration
+	for i, unit := range []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		if m[i+1] == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(m[i+1], 64)
+		if err != nil {
+			return 0, err
+		}
+		d += time.Duration(v * float64(unit))
+	}
+	return d, nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(darwintime.UK).Format(time.RFC3339)
+}
+
+// journey maps a service onto an EstimatedVehicleJourney. Calls are the locations passengers can use, numbered in
+// order; calls up to the last one the train has reported leaving are recorded calls, and the rest are estimated.
+func journey(svc *trainstate.Service, times []trainstate.LocationTimes, names *refdata.Store) EstimatedVehicleJourney {
+	evj := EstimatedVehicleJourney{
+		RecordedAtTime: formatTime(svc.UpdatedAt),
+		LineRef:        svc.TOC,
+		FramedVehicleJourneyRef: &FramedVehicleJourneyRef{
+			DataFrameRef:           svc.SSD,
+			DatedVehicleJourneyRef: svc.RID,
+		},
+		Cancellation:           svc.IsCancelled,
+		VehicleMode:            "rail",
+		OperatorRef:            svc.TOC,
+		VehicleJourneyName:     svc.TrainID,
+		Monitored:              true,
+		IsCompleteStopSequence: true,
+	}
+
+	if origin, ok := svc.Origin(); ok {
+		evj.OriginRef = origin.Tiploc
+		evj.OriginName = names.LocationName(origin.Tiploc)
+	}
+	if destination, ok := svc.Destination(); ok {
+		evj.DestinationRef = destination.Tiploc
+		evj.DestinationName = names.LocationName(destination.Tiploc)
+		// there's no direction in Darwin, so journeys are told apart by where they're heading
+		evj.DirectionRef = destination.Tiploc
+	}
+
+	var calls []int
+	lastReported := -1
+	for i, loc := range svc.Locations {
+		if loc.Type == pushport.LocationTypePassing || loc.Pta == "" && loc.Ptd == "" {
+			continue
+		}
+		calls = append(calls, i)
+		if !times[i].ActualDeparture.IsZero() || loc.Ptd == "" && !times[i].ActualArrival.IsZero() {
+			lastReported = len(calls) - 1
+		}
+	}
+
+	for order, i := range calls {
+		loc := &svc.Locations[i]
+		t := &times[i]
+		platform := ""
+		if !loc.PlatformSuppressed {
+			platform = loc.Platform
+		}
+
+		if order <= lastReported {
+			call := RecordedCall{
+				StopPointRef:  loc.Tiploc,
+				Order:         order + 1,
+				StopPointName: names.LocationName(loc.Tiploc),
+				Cancellation:  loc.Cancelled,
+			}
+			if loc.Pta != "" {
+				call.AimedArrivalTime = formatTime(t.ScheduledArrival)
+				call.ActualArrivalTime = formatTime(t.ActualArrival)
+				call.ArrivalPlatformName = platform
+			}
+			if loc.Ptd != "" {
+				call.AimedDepartureTime = formatTime(t.ScheduledDeparture)
+				call.ActualDepartureTime = formatTime(t.ActualDeparture)
+				call.DeparturePlatformName = platform
+			}
+			if evj.RecordedCalls == nil {
+				evj.RecordedCalls = &RecordedCalls{}
+			}
+			evj.RecordedCalls.Calls = append(evj.RecordedCalls.Calls, call)
+			continue
+		}
+
+		call := EstimatedCall{
+			StopPointRef:  loc.Tiploc,
+			Order:         order + 1,
+			StopPointName: names.LocationName(loc.Tiploc),
+			Cancellation:  loc.Cancelled,
+		}
+		if loc.Pta != "" {
+			call.AimedArrivalTime = formatTime(t.ScheduledArrival)
+			call.ExpectedArrivalTime = formatTime(t.ExpectedArrival)
+			call.ArrivalStatus = callStatus(loc.Cancelled, loc.Arrival, t.ScheduledArrival, t.ExpectedArrival)
+			call.ArrivalPlatformName = platform
+		}
+		if loc.Ptd != "" {
+			call.AimedDepartureTime = formatTime(t.ScheduledDeparture)
+			call.ExpectedDepartureTime = formatTime(t.ExpectedDeparture)
+			call.DepartureStatus = callStatus(loc.Cancelled, loc.Departure, t.ScheduledDeparture, t.ExpectedDeparture)
+			call.DeparturePlatformName = platform
+		}
+		if evj.EstimatedCalls == nil {
+			evj.EstimatedCalls = &EstimatedCalls{}
+		}
+		evj.EstimatedCalls.Calls = append(evj.EstimatedCalls.Calls, call)
+	}
+
+	return evj
+}
+
+func callStatus(cancelled bool, estimate *trainstate.TimeEstimate, aimed time.Time, expected time.Time) string {
+	switch {
+	case cancelled:
+		return StatusCancelled
+	case estimate != nil && estimate.Delayed:
+		// Darwin doesn't know how late the train will be
+		return StatusDelayed
+	case expected.IsZero() || aimed.IsZero():
+		return StatusNoReport
+	case expected.Sub(aimed) >= time.Minute:
+		return StatusDelayed
+	case aimed.Sub(expected) >= time.Minute:
+		return StatusEarly
+	default:
+		return StatusOnTime
+	}
+}
@@ -0,0 +1,512 @@
+package siri
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"gemini-push-port/logging"
+	"gemini-push-port/outbound"
+	"gemini-push-port/pushport"
+	"gemini-push-port/refdata"
+	"gemini-push-port/trainstate"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultProducerRef      = "gemini-push-port"
+	defaultPreviewInterval  = 2 * time.Hour
+	defaultDeliveryInterval = 10 * time.Second
+	defaultSubscriptionTime = 24 * time.Hour
+	defaultMaxSubscriptions = 10
+	// subscriptions are dropped after this many deliveries in a row fail
+	maxDeliveryFailures = 5
+)
+
+// Producer serves SIRI Estimated Timetable deliveries built from the live train state, both in response to requests
+// and to subscribers as services change. It should be given messages after the train state engine.
+type Producer struct {
+	engine           *trainstate.Engine
+	names            *refdata.Store
+	producerRef      string
+	preview          time.Duration
+	deliveryInterval time.Duration
+	// requestorKeys holds the key each RequestorRef authenticates with
+	requestorKeys map[string]string
+	// maxSubscriptions limits how many subscriptions each requestor can hold at once
+	maxSubscriptions int
+	// allowPrivate lets subscriptions be delivered to loopback, link-local and private addresses
+	allowPrivate bool
+	client       *http.Client
+	startedAt    time.Time
+
+	mu            sync.Mutex
+	subscriptions map[string]*subscription
+
+	done chan struct{}
+}
+
+type subscription struct {
+	key           string
+	subscriberRef string
+	ref           string
+	address       string
+	query         query
+	heartbeat     time.Duration
+	validUntil    time.Time
+
+	// pending holds the RIDs of services which have changed since the last delivery
+	pending       map[string]bool
+	initial       bool
+	inFlight      bool
+	failures      int
+	lastDelivered time.Time
+}
+
+// EnabledFromEnv returns whether SIRI_ENABLED is set to true
+func EnabledFromEnv() bool {
+	return strings.EqualFold(os.Getenv("SIRI_ENABLED"), "true")
+}
+
+// NewProducerFromEnv creates a producer identified by SIRI_PRODUCER_REF, which includes journeys starting within
+// SIRI_PREVIEW_INTERVAL (2h by default) unless a request asks for another interval, and checks for changes to send
+// subscribers every SIRI_DELIVERY_INTERVAL (10s by default).
+//
+// SIRI_REQUESTOR_KEYS holds comma-separated RequestorRef:key pairs. Subscribing needs one of these keys, and once any
+// are set, so does every other request. Each requestor can hold SIRI_MAX_SUBSCRIPTIONS subscriptions (10 by default),
+// which can't be delivered to private addresses unless SIRI_ALLOW_PRIVATE_URLS is true.
+func NewProducerFromEnv(engine *trainstate.Engine, names *refdata.Store) (*Producer, error) {
+	allowPrivate, _ := strconv.ParseBool(os.Getenv("SIRI_ALLOW_PRIVATE_URLS"))
+	p := &Producer{
+		engine:           engine,
+		names:            names,
+		producerRef:      os.Getenv("SIRI_PRODUCER_REF"),
+		preview:          getDurationFromEnv("SIRI_PREVIEW_INTERVAL", defaultPreviewInterval),
+		deliveryInterval: getDurationFromEnv("SIRI_DELIVERY_INTERVAL", defaultDeliveryInterval),
+		requestorKeys:    make(map[string]string),
+		maxSubscriptions: defaultMaxSubscriptions,
+		allowPrivate:     allowPrivate,
+		client:           outbound.NewClient(allowPrivate, 10*time.Second),
+		startedAt:        time.Now(),
+		subscriptions:    make(map[string]*subscription),
+		done:             make(chan struct{}),
+	}
+	if p.producerRef == "" {
+		p.producerRef = defaultProducerRef
+	}
+
+	if keys := os.Getenv("SIRI_REQUESTOR_KEYS"); keys != "" {
+		for _, pair := range strings.Split(keys, ",") {
+			ref, key, ok := strings.Cut(strings.TrimSpace(pair), ":")
+			if !ok || ref == "" || key == "" {
+				return nil, fmt.Errorf("SIRI_REQUESTOR_KEYS must be RequestorRef:key pairs, not %q", pair)
+			}
+			p.requestorKeys[ref] = key
+		}
+	}
+
+	if max := os.Getenv("SIRI_MAX_SUBSCRIPTIONS"); max != "" {
+		n, err := strconv.Atoi(max)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("SIRI_MAX_SUBSCRIPTIONS must be a positive number, not %q", max)
+		}
+		p.maxSubscriptions = n
+	}
+
+	return p, nil
+}
+
+func getDurationFromEnv(name string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(name))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// authenticated returns whether the request carries the requestor's key as a bearer token
+func (p *Producer) authenticated(r *http.Request, requestorRef string) bool {
+	key, ok := p.requestorKeys[requestorRef]
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(key)) == 1
+}
+
+// mayRead returns whether the requestor can request deliveries, which anyone can do when no keys are set
+func (p *Producer) mayRead(r *http.Request, requestorRef string) bool {
+	return len(p.requestorKeys) == 0 || p.authenticated(r, requestorRef)
+}
+
+// HandleMessage marks the services in the message as changed for every subscription
+func (p *Producer) HandleMessage(msg *pushport.Message) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.subscriptions) == 0 {
+		return
+	}
+
+	rids := msg.Pport.Response().RIDs()
+	for _, sub := range p.subscriptions {
+		for _, rid := range rids {
+			sub.pending[rid] = true
+		}
+	}
+}
+
+// Delivery builds a delivery of every journey matching the query
+func (p *Producer) Delivery(q query, now time.Time) *ServiceDelivery {
+	var journeys []EstimatedVehicleJourney
+	p.engine.Services(func(svc *trainstate.Service) bool {
+		if !svc.HasSchedule || !svc.IsPassengerSvc || svc.IsDeleted {
+			return false
+		}
+		times := svc.Times()
+		if q.matches(svc, times, now) {
+			journeys = append(journeys, journey(svc, times, p.names))
+		}
+		// the journey has already been built under the engine's lock, so there's no need for a copy
+		return false
+	})
+	return p.serviceDelivery(journeys, now)
+}
+
+func (p *Producer) serviceDelivery(journeys []EstimatedVehicleJourney, now time.Time) *ServiceDelivery {
+	timestamp := formatTime(now)
+	return &ServiceDelivery{
+		ResponseTimestamp: timestamp,
+		ProducerRef:       p.producerRef,
+		EstimatedTimetableDelivery: &EstimatedTimetableDelivery{
+			Version:           Version,
+			ResponseTimestamp: timestamp,
+			EstimatedJourneyVersionFrame: &EstimatedJourneyVersionFrame{
+				RecordedAtTime:           timestamp,
+				EstimatedVehicleJourneys: journeys,
+			},
+		},
+	}
+}
+
+// Subscribe adds or replaces the subscriptions in a request, and returns the status of each. The requestor should
+// already have been authenticated.
+func (p *Producer) Subscribe(ctx context.Context, req *SubscriptionRequest, now time.Time) *SubscriptionResponse {
+	resp := &SubscriptionResponse{ResponseTimestamp: formatTime(now), ResponderRef: p.producerRef}
+
+	heartbeat := time.Duration(0)
+	if req.SubscriptionContext != nil && req.SubscriptionContext.HeartbeatInterval != "" {
+		heartbeat, _ = parseDuration(req.SubscriptionContext.HeartbeatInterval)
+	}
+
+	// the consumer address is only resolved once for the whole request
+	addressErr := p.checkAddress(ctx, req.ConsumerAddress)
+
+	for _, etReq := range req.EstimatedTimetableSubscriptionRequest {
+		status := ResponseStatus{
+			ResponseTimestamp: formatTime(now),
+			SubscriberRef:     req.RequestorRef,
+			SubscriptionRef:   etReq.SubscriptionIdentifier,
+		}
+
+		err := addressErr
+		var sub *subscription
+		if err == nil {
+			sub, err = p.newSubscription(req, &etReq, heartbeat, now)
+		}
+		if err == nil {
+			err = p.add(sub)
+		}
+		if err != nil {
+			status.ErrorCondition = otherError(err.Error())
+			resp.ResponseStatus = append(resp.ResponseStatus, status)
+			continue
+		}
+
+		logging.Logger.Infof("SIRI subscription %s from %s will be delivered to %s until %s", sub.ref, sub.subscriberRef, sub.address, formatTime(sub.validUntil))
+
+		status.SubscriptionRef = sub.ref
+		status.Status = true
+		status.ValidUntil = formatTime(sub.validUntil)
+		resp.ResponseStatus = append(resp.ResponseStatus, status)
+	}
+
+	return resp
+}
+
+// checkAddress returns an error if deliveries can't be made to the consumer address
+func (p *Producer) checkAddress(ctx context.Context, address string) error {
+	u, err := url.Parse(address)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("ConsumerAddress must be an http or https URL")
+	}
+	if p.allowPrivate {
+		return nil
+	}
+	return outbound.CheckHost(ctx, u.Hostname())
+}
+
+// add adds a subscription, or replaces one with the same reference, unless the requestor already has as many as
+// they're allowed
+func (p *Producer) add(sub *subscription) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.subscriptions[sub.key]; !ok {
+		n := 0
+		for _, existing := range p.subscriptions {
+			if existing.subscriberRef == sub.subscriberRef {
+				n++
+			}
+		}
+		if n >= p.maxSubscriptions {
+			return fmt.Errorf("requestor already has %d subscriptions, the most allowed", n)
+		}
+	}
+
+	p.subscriptions[sub.key] = sub
+	return nil
+}
+
+func (p *Producer) newSubscription(req *SubscriptionRequest, etReq *EstimatedTimetableSubscriptionRequest, heartbeat time.Duration, now time.Time) (*subscription, error) {
+	// subscriptions belong to the authenticated requestor, so one can't replace or terminate another's
+	if etReq.SubscriberRef != "" && etReq.SubscriberRef != req.RequestorRef {
+		return nil, errors.New("SubscriberRef must be the RequestorRef")
+	}
+
+	q, err := newQuery(etReq.EstimatedTimetableRequest, p.preview)
+	if err != nil {
+		return nil, err
+	}
+
+	validUntil := now.Add(defaultSubscriptionTime)
+	if etReq.InitialTerminationTime != "" {
+		validUntil, err = time.Parse(time.RFC3339, etReq.InitialTerminationTime)
+		if err != nil {
+			return nil, errors.New("InitialTerminationTime must be a date and time")
+		}
+		if !validUntil.After(now) {
+			return nil, errors.New("InitialTerminationTime has already passed")
+		}
+	}
+
+	ref := etReq.SubscriptionIdentifier
+	if ref == "" {
+		ref, err = randomHex(8)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &subscription{
+		key:           req.RequestorRef + "/" + ref,
+		subscriberRef: req.RequestorRef,
+		ref:           ref,
+		address:       req.ConsumerAddress,
+		query:         q,
+		heartbeat:     heartbeat,
+		validUntil:    validUntil,
+		pending:       make(map[string]bool),
+		initial:       true,
+		lastDelivered: now,
+	}, nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Terminate ends the subscriptions in a request, or all of the requestor's subscriptions. The requestor should already
+// have been authenticated.
+func (p *Producer) Terminate(req *TerminateSubscriptionRequest, now time.Time) *TerminateSubscriptionResponse {
+	resp := &TerminateSubscriptionResponse{ResponseTimestamp: formatTime(now), ResponderRef: p.producerRef}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	refs := req.SubscriptionRefs
+	if req.All != nil {
+		refs = nil
+		for _, sub := range p.subscriptions {
+			if sub.subscriberRef == req.RequestorRef {
+				refs = append(refs, sub.ref)
+			}
+		}
+	}
+
+	for _, ref := range refs {
+		status := TerminationResponseStatus{
+			ResponseTimestamp: formatTime(now),
+			SubscriberRef:     req.RequestorRef,
+			SubscriptionRef:   ref,
+		}
+		key := req.RequestorRef + "/" + ref
+		if _, ok := p.subscriptions[key]; ok {
+			delete(p.subscriptions, key)
+			status.Status = true
+			logging.Logger.Infof("SIRI subscription %s from %s terminated", ref, req.RequestorRef)
+		} else {
+			status.ErrorCondition = otherError("unknown subscription")
+		}
+		resp.TerminationResponseStatus = append(resp.TerminationResponseStatus, status)
+	}
+
+	return resp
+}
+
+// Thread delivers changes to subscribers until the producer is closed
+func (p *Producer) Thread() {
+	ticker := time.NewTicker(p.deliveryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case now := <-ticker.C:
+			p.deliverAll(now)
+		}
+	}
+}
+
+func (p *Producer) Close() {
+	close(p.done)
+}
+
+// deliverAll starts a delivery for every subscription which has changes, or is due a heartbeat. A subscription only
+// has one delivery in flight at a time, so a slow consumer doesn't hold up the others.
+func (p *Producer) deliverAll(now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for key, sub := range p.subscriptions {
+		if now.After(sub.validUntil) {
+			delete(p.subscriptions, key)
+			logging.Logger.Infof("SIRI subscription %s from %s has expired", sub.ref, sub.subscriberRef)
+			continue
+		}
+		if sub.inFlight {
+			continue
+		}
+
+		heartbeatDue := sub.heartbeat > 0 && now.Sub(sub.lastDelivered) >= sub.heartbeat
+		if !sub.initial && len(sub.pending) == 0 && !heartbeatDue {
+			continue
+		}
+
+		pending := sub.pending
+		sub.pending = make(map[string]bool)
+		sub.inFlight = true
+		go p.deliver(sub, pending, sub.initial, now)
+	}
+}
+
+func (p *Producer) deliver(sub *subscription, pending map[string]bool, initial bool, now time.Time) {
+	var journeys []EstimatedVehicleJourney
+	if initial {
+		journeys = p.Delivery(sub.query, now).EstimatedTimetableDelivery.EstimatedJourneyVersionFrame.EstimatedVehicleJourneys
+	} else {
+		for rid := range pending {
+			svc, ok := p.engine.Get(rid)
+			if !ok {
+				continue
+			}
+			times := svc.Times()
+			if sub.query.matches(&svc, times, now) {
+				journeys = append(journeys, journey(&svc, times, p.names))
+			}
+		}
+	}
+
+	body := newSiri()
+	if len(journeys) > 0 || initial {
+		body.ServiceDelivery = p.serviceDelivery(journeys, now)
+		body.ServiceDelivery.EstimatedTimetableDelivery.SubscriberRef = sub.subscriberRef
+		body.ServiceDelivery.EstimatedTimetableDelivery.SubscriptionRef = sub.ref
+	} else {
+		body.HeartbeatNotification = &HeartbeatNotification{
+			RequestTimestamp:   formatTime(now),
+			ProducerRef:        p.producerRef,
+			Status:             true,
+			ServiceStartedTime: formatTime(p.startedAt),
+		}
+	}
+
+	// changes to services the subscription doesn't cover don't need a delivery, unless a heartbeat is due anyway
+	send := body.ServiceDelivery != nil || sub.heartbeat > 0 && now.Sub(sub.lastDelivered) >= sub.heartbeat
+	var err error
+	if send {
+		err = p.post(sub.address, body)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	sub.inFlight = false
+
+	if err == nil {
+		sub.initial = false
+		sub.failures = 0
+		if send {
+			sub.lastDelivered = now
+		}
+		return
+	}
+
+	// keep the changes so they're sent with the next delivery
+	for rid := range pending {
+		sub.pending[rid] = true
+	}
+	sub.failures++
+	if sub.failures >= maxDeliveryFailures {
+		delete(p.subscriptions, sub.key)
+		logging.Logger.Warnf("Dropped SIRI subscription %s from %s after %d failed deliveries to %s: %v", sub.ref, sub.subscriberRef, sub.failures, sub.address, err)
+		return
+	}
+	logging.Logger.Warnf("SIRI delivery for subscription %s to %s failed (%d in a row): %v", sub.ref, sub.address, sub.failures, err)
+}
+
+func (p *Producer) post(address string, body *Siri) error {
+	encoded, err := marshal(body)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, address, bytes.NewReader(encoded))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/xml")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("consumer returned %s", resp.Status)
+	}
+	return nil
+}
+
+func marshal(body *Siri) ([]byte, error) {
+	encoded, err := xml.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode SIRI response: %v", err)
+	}
+	return append([]byte(xml.Header), encoded...), nil
+}
@@ -0,0 +1,317 @@
+package siri
+
+import (
+	"bytes"
+	"encoding/xml"
+	"gemini-push-port/internal/testfixtures"
+	"gemini-push-port/refdata"
+	"gemini-push-port/trainstate"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+// replayFixture builds the train state from the fixture archive
+func replayFixture(t *testing.T) *trainstate.Engine {
+	t.Helper()
+	engine := trainstate.NewEngine(48 * time.Hour)
+	for _, msg := range testfixtures.AllMessages(t) {
+		engine.HandleMessage(msg)
+	}
+	return engine
+}
+
+func newTestProducer(t *testing.T, engine *trainstate.Engine) *Producer {
+	t.Helper()
+	testfixtures.ClearEnv(t, "REFDATA_PATH", "REFDATA_S3_PREFIX", "SIRI_PRODUCER_REF", "SIRI_PREVIEW_INTERVAL",
+		"SIRI_REQUESTOR_KEYS", "SIRI_MAX_SUBSCRIPTIONS", "SIRI_ALLOW_PRIVATE_URLS")
+	p, err := NewProducerFromEnv(engine, refdata.NewStoreFromEnv(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestMain(m *testing.M) {
+	testfixtures.Main(m, "siri-test")
+}
+
+func TestDeliveryFromFixtureArchive(t *testing.T) {
+	p := newTestProducer(t, replayFixture(t))
+	now := time.Date(2025, 9, 19, 11, 5, 0, 0, time.FixedZone("BST", 3600))
+
+	q, err := newQuery(nil, p.preview)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame := p.Delivery(q, now).EstimatedTimetableDelivery.EstimatedJourneyVersionFrame
+	journeys := make(map[string]EstimatedVehicleJourney)
+	for _, evj := range frame.EstimatedVehicleJourneys {
+		journeys[evj.VehicleJourneyName] = evj
+	}
+	if len(frame.EstimatedVehicleJourneys) != 2 || len(journeys) != 2 {
+		t.Fatalf("%d journeys %v, want 1A02 and 1C10", len(frame.EstimatedVehicleJourneys), journeys)
+	}
+
+	evj, ok := journeys["1A02"]
+	if !ok {
+		t.Fatal("no journey for 1A02")
+	}
+	if evj.FramedVehicleJourneyRef.DatedVehicleJourneyRef != testfixtures.RID1A02 || evj.FramedVehicleJourneyRef.DataFrameRef != testfixtures.SSD {
+		t.Errorf("1A02 journey ref %+v", evj.FramedVehicleJourneyRef)
+	}
+	if evj.OperatorRef != "SW" || evj.OriginRef != "WATRLMN" || evj.DestinationRef != "SOTON" {
+		t.Errorf("1A02 operator %s from %s to %s", evj.OperatorRef, evj.OriginRef, evj.DestinationRef)
+	}
+	if evj.RecordedAtTime != "2025-09-19T10:59:05+01:00" {
+		t.Errorf("1A02 recorded at %s", evj.RecordedAtTime)
+	}
+
+	// Clapham Junction is only passed, so isn't a call
+	wantRecorded := []RecordedCall{
+		{StopPointRef: "WATRLMN", Order: 1, StopPointName: "WATRLMN", AimedDepartureTime: "2025-09-19T10:30:00+01:00",
+			ActualDepartureTime: "2025-09-19T10:31:00+01:00", DeparturePlatformName: "5"},
+		{StopPointRef: "WOKING", Order: 2, StopPointName: "WOKING",
+			AimedArrivalTime: "2025-09-19T10:57:00+01:00", ActualArrivalTime: "2025-09-19T10:59:00+01:00", ArrivalPlatformName: "2",
+			AimedDepartureTime: "2025-09-19T10:58:00+01:00", ActualDepartureTime: "2025-09-19T11:00:00+01:00", DeparturePlatformName: "2"},
+	}
+	if evj.RecordedCalls == nil || len(evj.RecordedCalls.Calls) != len(wantRecorded) {
+		t.Fatalf("1A02 recorded calls %+v", evj.RecordedCalls)
+	}
+	for i, want := range wantRecorded {
+		if got := evj.RecordedCalls.Calls[i]; got != want {
+			t.Errorf("1A02 recorded call %d:\n got %+v\nwant %+v", i, got, want)
+		}
+	}
+
+	wantEstimated := []EstimatedCall{
+		{StopPointRef: "BSNGSTK", Order: 3, StopPointName: "BSNGSTK",
+			AimedArrivalTime: "2025-09-19T11:20:00+01:00", ExpectedArrivalTime: "2025-09-19T11:23:00+01:00", ArrivalStatus: StatusDelayed,
+			AimedDepartureTime: "2025-09-19T11:21:00+01:00", ExpectedDepartureTime: "2025-09-19T11:24:00+01:00", DepartureStatus: StatusDelayed},
+		{StopPointRef: "SOTON", Order: 4, StopPointName: "SOTON",
+			AimedArrivalTime: "2025-09-19T11:45:00+01:00", ExpectedArrivalTime: "2025-09-19T11:48:00+01:00", ArrivalStatus: StatusDelayed},
+	}
+	if evj.EstimatedCalls == nil || len(evj.EstimatedCalls.Calls) != len(wantEstimated) {
+		t.Fatalf("1A02 estimated calls %+v", evj.EstimatedCalls)
+	}
+	for i, want := range wantEstimated {
+		if got := evj.EstimatedCalls.Calls[i]; got != want {
+			t.Errorf("1A02 estimated call %d:\n got %+v\nwant %+v", i, got, want)
+		}
+	}
+
+	evj, ok = journeys["1C10"]
+	if !ok {
+		t.Fatal("no journey for 1C10")
+	}
+	if evj.RecordedCalls != nil {
+		t.Errorf("1C10 recorded calls %+v, want none", evj.RecordedCalls)
+	}
+	if evj.EstimatedCalls == nil || len(evj.EstimatedCalls.Calls) != 2 {
+		t.Fatalf("1C10 estimated calls %+v", evj.EstimatedCalls)
+	}
+	departure := evj.EstimatedCalls.Calls[0]
+	if departure.StopPointRef != "PADTON" || departure.ExpectedDepartureTime != "2025-09-19T11:00:00+01:00" || departure.DepartureStatus != StatusOnTime {
+		t.Errorf("1C10 first call %+v", departure)
+	}
+	if arrival := evj.EstimatedCalls.Calls[1]; arrival.StopPointRef != "RDNGSTN" || arrival.Order != 2 || arrival.AimedArrivalTime != "2025-09-19T11:25:00+01:00" {
+		t.Errorf("1C10 second call %+v", arrival)
+	}
+
+	// the delivery survives being encoded and decoded
+	body := newSiri()
+	body.ServiceDelivery = p.Delivery(q, now)
+	encoded, err := marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Siri
+	err = xml.Unmarshal(encoded, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(decoded.ServiceDelivery.EstimatedTimetableDelivery.EstimatedJourneyVersionFrame.EstimatedVehicleJourneys); n != 2 {
+		t.Errorf("%d journeys after decoding, want 2", n)
+	}
+}
+
+func TestDeliveryFromFixtureArchiveFiltersOperators(t *testing.T) {
+	p := newTestProducer(t, replayFixture(t))
+	now := time.Date(2025, 9, 19, 11, 5, 0, 0, time.FixedZone("BST", 3600))
+
+	tests := []struct {
+		name    string
+		req     *EstimatedTimetableRequest
+		want    []string
+		wantErr bool
+	}{
+		{name: "by operator", req: &EstimatedTimetableRequest{OperatorRefs: []string{"gw"}}, want: []string{"1C10"}},
+		{name: "by line", req: &EstimatedTimetableRequest{LineRefs: []string{"SW"}}, want: []string{"1A02"}},
+		// a long enough preview includes the evening service
+		{name: "preview", req: &EstimatedTimetableRequest{OperatorRefs: []string{"SW"}, PreviewInterval: "PT10H"}, want: []string{"1A02", "1A50"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := newQuery(test.req, p.preview)
+			if err != nil {
+				t.Fatal(err)
+			}
+			frame := p.Delivery(q, now).EstimatedTimetableDelivery.EstimatedJourneyVersionFrame
+			got := make(map[string]bool)
+			for _, evj := range frame.EstimatedVehicleJourneys {
+				got[evj.VehicleJourneyName] = true
+			}
+			if len(got) != len(test.want) {
+				t.Errorf("journeys %v, want %v", got, test.want)
+			}
+			for _, name := range test.want {
+				if !got[name] {
+					t.Errorf("journeys %v, want %v", got, test.want)
+				}
+			}
+		})
+	}
+}
+
+// request sends a SIRI document to the producer with a bearer key, and decodes the response
+func request(t *testing.T, p *Producer, key string, req *Siri) *Siri {
+	t.Helper()
+	body, err := marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodPost, "/siri", bytes.NewReader(body))
+	if key != "" {
+		r.Header.Set("Authorization", "Bearer "+key)
+	}
+	w := httptest.NewRecorder()
+	p.handleRequest(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp Siri
+	err = xml.Unmarshal(w.Body.Bytes(), &resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &resp
+}
+
+func subscriptionRequest(requestorRef string, address string, etReqs ...EstimatedTimetableSubscriptionRequest) *Siri {
+	req := newSiri()
+	req.SubscriptionRequest = &SubscriptionRequest{
+		RequestorRef:                          requestorRef,
+		ConsumerAddress:                       address,
+		EstimatedTimetableSubscriptionRequest: etReqs,
+	}
+	return req
+}
+
+func TestSubscribe(t *testing.T) {
+	p := newTestProducer(t, trainstate.NewEngine(time.Hour))
+	p.requestorKeys = map[string]string{"partner": "partner-key", "other": "other-key"}
+	p.maxSubscriptions = 2
+	etReq := func(ref string) EstimatedTimetableSubscriptionRequest {
+		return EstimatedTimetableSubscriptionRequest{SubscriptionIdentifier: ref}
+	}
+
+	tests := []struct {
+		name       string
+		key        string
+		req        *Siri
+		wantStatus []bool
+		wantDenied bool
+	}{
+		{name: "without a key", req: subscriptionRequest("partner", "https://93.184.215.14/siri", etReq("a")), wantDenied: true},
+		{name: "with another requestor's key", key: "other-key", req: subscriptionRequest("partner", "https://93.184.215.14/siri", etReq("a")), wantDenied: true},
+		{name: "to a loopback address", key: "partner-key", req: subscriptionRequest("partner", "http://127.0.0.1:8080/siri", etReq("a")), wantStatus: []bool{false}},
+		{name: "to a private address", key: "partner-key", req: subscriptionRequest("partner", "http://10.0.0.1/siri", etReq("a")), wantStatus: []bool{false}},
+		{name: "not over http", key: "partner-key", req: subscriptionRequest("partner", "file:///etc/passwd", etReq("a")), wantStatus: []bool{false}},
+		{
+			name:       "for another subscriber",
+			key:        "partner-key",
+			req:        subscriptionRequest("partner", "https://93.184.215.14/siri", EstimatedTimetableSubscriptionRequest{SubscriberRef: "other", SubscriptionIdentifier: "a"}),
+			wantStatus: []bool{false},
+		},
+		// the third is over the limit of two
+		{name: "up to the limit", key: "partner-key", req: subscriptionRequest("partner", "https://93.184.215.14/siri", etReq("a"), etReq("b"), etReq("c")), wantStatus: []bool{true, true, false}},
+		{name: "replacing at the limit", key: "partner-key", req: subscriptionRequest("partner", "https://93.184.215.14/siri", etReq("b")), wantStatus: []bool{true}},
+		{name: "another requestor's limit", key: "other-key", req: subscriptionRequest("other", "https://93.184.215.14/siri", etReq("a")), wantStatus: []bool{true}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := request(t, p, test.key, test.req).SubscriptionResponse
+			if resp == nil {
+				t.Fatal("no SubscriptionResponse")
+			}
+			if test.wantDenied {
+				if len(resp.ResponseStatus) != 1 || resp.ResponseStatus[0].ErrorCondition == nil || resp.ResponseStatus[0].ErrorCondition.AccessNotAllowedError == nil {
+					t.Errorf("statuses %+v, want access not allowed", resp.ResponseStatus)
+				}
+				return
+			}
+			var got []bool
+			for _, status := range resp.ResponseStatus {
+				got = append(got, status.Status)
+			}
+			if !slices.Equal(got, test.wantStatus) {
+				t.Errorf("statuses %+v, want %v", resp.ResponseStatus, test.wantStatus)
+			}
+		})
+	}
+
+	if len(p.subscriptions) != 3 {
+		t.Errorf("%d subscriptions, want 3", len(p.subscriptions))
+	}
+}
+
+func TestServiceRequestNeedsKeyOnceKeysAreSet(t *testing.T) {
+	p := newTestProducer(t, trainstate.NewEngine(time.Hour))
+	req := newSiri()
+	req.ServiceRequest = &ServiceRequest{RequestorRef: "partner", EstimatedTimetableRequest: &EstimatedTimetableRequest{}}
+
+	if delivery := request(t, p, "", req).ServiceDelivery; delivery.ErrorCondition != nil {
+		t.Errorf("anonymous request refused without keys: %+v", delivery.ErrorCondition)
+	}
+
+	p.requestorKeys = map[string]string{"partner": "partner-key"}
+	if delivery := request(t, p, "", req).ServiceDelivery; delivery.ErrorCondition == nil || delivery.ErrorCondition.AccessNotAllowedError == nil {
+		t.Errorf("anonymous request error %+v, want access not allowed", delivery.ErrorCondition)
+	}
+	if delivery := request(t, p, "partner-key", req).ServiceDelivery; delivery.ErrorCondition != nil {
+		t.Errorf("request with key refused: %+v", delivery.ErrorCondition)
+	}
+}
+
+func TestTerminate(t *testing.T) {
+	p := newTestProducer(t, trainstate.NewEngine(time.Hour))
+	p.requestorKeys = map[string]string{"partner": "partner-key", "other": "other-key"}
+	resp := request(t, p, "partner-key", subscriptionRequest("partner", "https://93.184.215.14/siri",
+		EstimatedTimetableSubscriptionRequest{SubscriptionIdentifier: "a"}))
+	if len(resp.SubscriptionResponse.ResponseStatus) != 1 || !resp.SubscriptionResponse.ResponseStatus[0].Status {
+		t.Fatalf("subscribing failed: %+v", resp.SubscriptionResponse.ResponseStatus)
+	}
+
+	terminate := newSiri()
+	terminate.TerminateSubscriptionRequest = &TerminateSubscriptionRequest{RequestorRef: "partner", All: &struct{}{}}
+	for _, key := range []string{"", "other-key"} {
+		statuses := request(t, p, key, terminate).TerminateSubscriptionResponse.TerminationResponseStatus
+		if len(statuses) != 1 || statuses[0].Status || statuses[0].ErrorCondition == nil || statuses[0].ErrorCondition.AccessNotAllowedError == nil {
+			t.Errorf("terminating with key %q: statuses %+v, want access not allowed", key, statuses)
+		}
+	}
+	if len(p.subscriptions) != 1 {
+		t.Fatalf("%d subscriptions after unauthenticated terminations, want 1", len(p.subscriptions))
+	}
+
+	statuses := request(t, p, "partner-key", terminate).TerminateSubscriptionResponse.TerminationResponseStatus
+	if len(statuses) != 1 || !statuses[0].Status || statuses[0].SubscriptionRef != "a" {
+		t.Errorf("statuses %+v, want subscription a terminated", statuses)
+	}
+	if len(p.subscriptions) != 0 {
+		t.Errorf("%d subscriptions, want 0", len(p.subscriptions))
+	}
+}
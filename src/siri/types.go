@@ -0,0 +1,219 @@
+package siri
+
+import "encoding/xml"
+
+// Namespace and Version are those of the SIRI 2.0 schema. Elements are declared in the order the schema lists them.
+const (
+	Namespace = "http://www.siri.org.uk/siri"
+	Version   = "2.0"
+)
+
+// Siri is the root of every request and response. Exactly one of its children is set.
+type Siri struct {
+	XMLName xml.Name `xml:"Siri"`
+	Xmlns   string   `xml:"xmlns,attr,omitempty"`
+	Version string   `xml:"version,attr,omitempty"`
+
+	ServiceRequest                *ServiceRequest                `xml:"ServiceRequest,omitempty"`
+	SubscriptionRequest           *SubscriptionRequest           `xml:"SubscriptionRequest,omitempty"`
+	TerminateSubscriptionRequest  *TerminateSubscriptionRequest  `xml:"TerminateSubscriptionRequest,omitempty"`
+	CheckStatusRequest            *CheckStatusRequest            `xml:"CheckStatusRequest,omitempty"`
+	ServiceDelivery               *ServiceDelivery               `xml:"ServiceDelivery,omitempty"`
+	SubscriptionResponse          *SubscriptionResponse          `xml:"SubscriptionResponse,omitempty"`
+	TerminateSubscriptionResponse *TerminateSubscriptionResponse `xml:"TerminateSubscriptionResponse,omitempty"`
+	CheckStatusResponse           *CheckStatusResponse           `xml:"CheckStatusResponse,omitempty"`
+	HeartbeatNotification         *HeartbeatNotification         `xml:"HeartbeatNotification,omitempty"`
+}
+
+func newSiri() *Siri {
+	return &Siri{Xmlns: Namespace, Version: Version}
+}
+
+type ServiceRequest struct {
+	RequestTimestamp          string                     `xml:"RequestTimestamp"`
+	RequestorRef              string                     `xml:"RequestorRef"`
+	EstimatedTimetableRequest *EstimatedTimetableRequest `xml:"EstimatedTimetableRequest"`
+}
+
+type EstimatedTimetableRequest struct {
+	Version          string   `xml:"version,attr,omitempty"`
+	RequestTimestamp string   `xml:"RequestTimestamp,omitempty"`
+	PreviewInterval  string   `xml:"PreviewInterval,omitempty"`
+	OperatorRefs     []string `xml:"OperatorRef,omitempty"`
+	LineRefs         []string `xml:"Lines>LineDirection>LineRef,omitempty"`
+}
+
+type SubscriptionRequest struct {
+	RequestTimestamp                      string                                  `xml:"RequestTimestamp"`
+	RequestorRef                          string                                  `xml:"RequestorRef"`
+	ConsumerAddress                       string                                  `xml:"ConsumerAddress"`
+	SubscriptionContext                   *SubscriptionContext                    `xml:"SubscriptionContext"`
+	EstimatedTimetableSubscriptionRequest []EstimatedTimetableSubscriptionRequest `xml:"EstimatedTimetableSubscriptionRequest"`
+}
+
+type SubscriptionContext struct {
+	HeartbeatInterval string `xml:"HeartbeatInterval"`
+}
+
+type EstimatedTimetableSubscriptionRequest struct {
+	SubscriberRef             string                     `xml:"SubscriberRef"`
+	SubscriptionIdentifier    string                     `xml:"SubscriptionIdentifier"`
+	InitialTerminationTime    string                     `xml:"InitialTerminationTime"`
+	EstimatedTimetableRequest *EstimatedTimetableRequest `xml:"EstimatedTimetableRequest"`
+}
+
+type TerminateSubscriptionRequest struct {
+	RequestTimestamp string    `xml:"RequestTimestamp"`
+	RequestorRef     string    `xml:"RequestorRef"`
+	All              *struct{} `xml:"All"`
+	SubscriptionRefs []string  `xml:"SubscriptionRef"`
+}
+
+type CheckStatusRequest struct {
+	RequestTimestamp string `xml:"RequestTimestamp"`
+	RequestorRef     string `xml:"RequestorRef"`
+}
+
+type ServiceDelivery struct {
+	ResponseTimestamp          string                      `xml:"ResponseTimestamp"`
+	ProducerRef                string                      `xml:"ProducerRef"`
+	Status                     *bool                       `xml:"Status,omitempty"`
+	ErrorCondition             *ErrorCondition             `xml:"ErrorCondition,omitempty"`
+	MoreData                   bool                        `xml:"MoreData"`
+	EstimatedTimetableDelivery *EstimatedTimetableDelivery `xml:"EstimatedTimetableDelivery,omitempty"`
+}
+
+type ErrorCondition struct {
+	AccessNotAllowedError *ErrorText `xml:"AccessNotAllowedError,omitempty"`
+	OtherError            *ErrorText `xml:"OtherError,omitempty"`
+	Description           string     `xml:"Description,omitempty"`
+}
+
+type ErrorText struct {
+	ErrorText string `xml:"ErrorText"`
+}
+
+type EstimatedTimetableDelivery struct {
+	Version                      string                        `xml:"version,attr"`
+	ResponseTimestamp            string                        `xml:"ResponseTimestamp"`
+	SubscriberRef                string                        `xml:"SubscriberRef,omitempty"`
+	SubscriptionRef              string                        `xml:"SubscriptionRef,omitempty"`
+	EstimatedJourneyVersionFrame *EstimatedJourneyVersionFrame `xml:"EstimatedJourneyVersionFrame"`
+}
+
+type EstimatedJourneyVersionFrame struct {
+	RecordedAtTime           string                    `xml:"RecordedAtTime"`
+	EstimatedVehicleJourneys []EstimatedVehicleJourney `xml:"EstimatedVehicleJourney"`
+}
+
+type EstimatedVehicleJourney struct {
+	RecordedAtTime          string                   `xml:"RecordedAtTime"`
+	LineRef                 string                   `xml:"LineRef"`
+	DirectionRef            string                   `xml:"DirectionRef"`
+	FramedVehicleJourneyRef *FramedVehicleJourneyRef `xml:"FramedVehicleJourneyRef"`
+	Cancellation            bool                     `xml:"Cancellation,omitempty"`
+	VehicleMode             string                   `xml:"VehicleMode"`
+	OperatorRef             string                   `xml:"OperatorRef"`
+	OriginRef               string                   `xml:"OriginRef,omitempty"`
+	OriginName              string                   `xml:"OriginName,omitempty"`
+	DestinationRef          string                   `xml:"DestinationRef,omitempty"`
+	DestinationName         string                   `xml:"DestinationName,omitempty"`
+	VehicleJourneyName      string                   `xml:"VehicleJourneyName,omitempty"`
+	Monitored               bool                     `xml:"Monitored"`
+	RecordedCalls           *RecordedCalls           `xml:"RecordedCalls,omitempty"`
+	EstimatedCalls          *EstimatedCalls          `xml:"EstimatedCalls,omitempty"`
+	IsCompleteStopSequence  bool                     `xml:"IsCompleteStopSequence"`
+}
+
+type FramedVehicleJourneyRef struct {
+	DataFrameRef           string `xml:"DataFrameRef"`
+	DatedVehicleJourneyRef string `xml:"DatedVehicleJourneyRef"`
+}
+
+type RecordedCalls struct {
+	Calls []RecordedCall `xml:"RecordedCall"`
+}
+
+type RecordedCall struct {
+	StopPointRef          string `xml:"StopPointRef"`
+	Order                 int    `xml:"Order"`
+	StopPointName         string `xml:"StopPointName,omitempty"`
+	Cancellation          bool   `xml:"Cancellation,omitempty"`
+	AimedArrivalTime      string `xml:"AimedArrivalTime,omitempty"`
+	ActualArrivalTime     string `xml:"ActualArrivalTime,omitempty"`
+	ArrivalPlatformName   string `xml:"ArrivalPlatformName,omitempty"`
+	AimedDepartureTime    string `xml:"AimedDepartureTime,omitempty"`
+	ActualDepartureTime   string `xml:"ActualDepartureTime,omitempty"`
+	DeparturePlatformName string `xml:"DeparturePlatformName,omitempty"`
+}
+
+type EstimatedCalls struct {
+	Calls []EstimatedCall `xml:"EstimatedCall"`
+}
+
+type EstimatedCall struct {
+	StopPointRef          string `xml:"StopPointRef"`
+	Order                 int    `xml:"Order"`
+	StopPointName         string `xml:"StopPointName,omitempty"`
+	Cancellation          bool   `xml:"Cancellation,omitempty"`
+	AimedArrivalTime      string `xml:"AimedArrivalTime,omitempty"`
+	ExpectedArrivalTime   string `xml:"ExpectedArrivalTime,omitempty"`
+	ArrivalStatus         string `xml:"ArrivalStatus,omitempty"`
+	ArrivalPlatformName   string `xml:"ArrivalPlatformName,omitempty"`
+	AimedDepartureTime    string `xml:"AimedDepartureTime,omitempty"`
+	ExpectedDepartureTime string `xml:"ExpectedDepartureTime,omitempty"`
+	DepartureStatus       string `xml:"DepartureStatus,omitempty"`
+	DeparturePlatformName string `xml:"DeparturePlatformName,omitempty"`
+}
+
+// Call statuses, for ArrivalStatus and DepartureStatus
+const (
+	StatusOnTime    = "onTime"
+	StatusEarly     = "early"
+	StatusDelayed   = "delayed"
+	StatusCancelled = "cancelled"
+	StatusNoReport  = "noReport"
+)
+
+type SubscriptionResponse struct {
+	ResponseTimestamp string           `xml:"ResponseTimestamp"`
+	ResponderRef      string           `xml:"ResponderRef"`
+	ResponseStatus    []ResponseStatus `xml:"ResponseStatus"`
+}
+
+type ResponseStatus struct {
+	ResponseTimestamp string          `xml:"ResponseTimestamp"`
+	SubscriberRef     string          `xml:"SubscriberRef,omitempty"`
+	SubscriptionRef   string          `xml:"SubscriptionRef,omitempty"`
+	Status            bool            `xml:"Status"`
+	ErrorCondition    *ErrorCondition `xml:"ErrorCondition,omitempty"`
+	ValidUntil        string          `xml:"ValidUntil,omitempty"`
+}
+
+type TerminateSubscriptionResponse struct {
+	ResponseTimestamp         string                      `xml:"ResponseTimestamp"`
+	ResponderRef              string                      `xml:"ResponderRef"`
+	TerminationResponseStatus []TerminationResponseStatus `xml:"TerminationResponseStatus"`
+}
+
+type TerminationResponseStatus struct {
+	ResponseTimestamp string          `xml:"ResponseTimestamp"`
+	SubscriberRef     string          `xml:"SubscriberRef,omitempty"`
+	SubscriptionRef   string          `xml:"SubscriptionRef,omitempty"`
+	Status            bool            `xml:"Status"`
+	ErrorCondition    *ErrorCondition `xml:"ErrorCondition,omitempty"`
+}
+
+type CheckStatusResponse struct {
+	ResponseTimestamp  string `xml:"ResponseTimestamp"`
+	ProducerRef        string `xml:"ProducerRef"`
+	Status             bool   `xml:"Status"`
+	ServiceStartedTime string `xml:"ServiceStartedTime"`
+}
+
+type HeartbeatNotification struct {
+	RequestTimestamp   string `xml:"RequestTimestamp"`
+	ProducerRef        string `xml:"ProducerRef"`
+	Status             bool   `xml:"Status"`
+	ServiceStartedTime string `xml:"ServiceStartedTime"`
+}
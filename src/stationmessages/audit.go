@@ -0,0 +1,91 @@
+package stationmessages
+
+import (
+	"encoding/json"
+	"gemini-push-port/logging"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	EventRaised  = "raised"
+	EventUpdated = "updated"
+	EventCleared = "cleared"
+)
+
+type auditEntry struct {
+	Time     time.Time `json:"time"`
+	Event    string    `json:"event"`
+	ID       string    `json:"id"`
+	Category string    `json:"category"`
+	Severity int       `json:"severity"`
+	Stations []string  `json:"stations"`
+	Text     string    `json:"text"`
+	RaisedAt time.Time `json:"raisedAt"`
+}
+
+// AuditLog appends a line to a daily file every time a station message is raised, updated or cleared
+type AuditLog struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// NewAuditLogFromEnv writes the audit log to STATION_MESSAGES_AUDIT_DIR, or a stationmessages directory in the workdir
+func NewAuditLogFromEnv() *AuditLog {
+	dir := os.Getenv("STATION_MESSAGES_AUDIT_DIR")
+	if dir == "" {
+		workdir := os.Getenv("PUSH_PORT_DUMP_WORKDIR")
+		if workdir == "" {
+			panic("PUSH_PORT_DUMP_WORKDIR environment variable not set")
+		}
+		dir = filepath.Join(workdir, "stationmessages")
+	}
+
+	return &AuditLog{dir: dir}
+}
+
+func (a *AuditLog) Record(t time.Time, event string, msg *Message) {
+	line, err := json.Marshal(auditEntry{
+		Time:     t,
+		Event:    event,
+		ID:       msg.ID,
+		Category: msg.Category,
+		Severity: msg.Severity,
+		Stations: msg.Stations,
+		Text:     msg.Text,
+		RaisedAt: msg.RaisedAt,
+	})
+	if err != nil {
+		logging.Logger.ErrorE("failed to marshal station message audit entry", err)
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	err = os.MkdirAll(a.dir, 0755)
+	if err != nil {
+		logging.Logger.ErrorE("failed to create station message audit directory", err)
+		return
+	}
+
+	filePath := filepath.Join(a.dir, t.UTC().Format(time.DateOnly)+".log")
+	f, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		logging.Logger.ErrorE("failed to open station message audit log", err)
+		return
+	}
+	defer func(f *os.File) {
+		err := f.Close()
+		if err != nil {
+			logging.Logger.ErrorE("failed to close file", err)
+		}
+	}(f)
+
+	_, err = f.Write(append(line, '\n'))
+	if err != nil {
+		logging.Logger.ErrorE("failed to write station message audit entry", err)
+	}
+}
@@ -0,0 +1,19 @@
+package stationmessages
+
+import (
+	"gemini-push-port/httpapi"
+	"net/http"
+)
+
+func (s *Store) RegisterRoutes(server *httpapi.Server) {
+	server.HandleFunc("GET /station-messages", s.handleAll)
+	server.HandleFunc("GET /stations/{crs}/messages", s.handleForStation)
+}
+
+func (s *Store) handleAll(w http.ResponseWriter, r *http.Request) {
+	httpapi.WriteJSON(w, r, http.StatusOK, s.All())
+}
+
+func (s *Store) handleForStation(w http.ResponseWriter, r *http.Request) {
+	httpapi.WriteJSON(w, r, http.StatusOK, s.ForStation(r.PathValue("crs")))
+}
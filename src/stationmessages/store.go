@@ -0,0 +1,160 @@
+package stationmessages
+
+import (
+	"gemini-push-port/pushport"
+	"gemini-push-port/refdata"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Severities used by Darwin for station messages
+var severityNames = map[string]string{
+	"0": "normal",
+	"1": "minor",
+	"2": "major",
+	"3": "severe",
+}
+
+type Message struct {
+	ID           string    `json:"id"`
+	Category     string    `json:"category"`
+	Severity     int       `json:"severity"`
+	SeverityName string    `json:"severityName"`
+	Suppress     bool      `json:"suppress"`
+	Stations     []string  `json:"stations"`
+	StationNames []string  `json:"stationNames"`
+	Text         string    `json:"text"`
+	HTML         string    `json:"html"`
+	RaisedAt     time.Time `json:"raisedAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// Store keeps the set of station messages which are currently active
+type Store struct {
+	mu       sync.RWMutex
+	messages map[string]*Message
+	audit    *AuditLog
+	names    *refdata.Store
+}
+
+func NewStore(audit *AuditLog, names *refdata.Store) *Store {
+	return &Store{
+		messages: make(map[string]*Message),
+		audit:    audit,
+		names:    names,
+	}
+}
+
+func (s *Store) HandleMessage(msg *pushport.Message) {
+	s.handle(msg, true)
+}
+
+// Replay returns a handler which restores the active messages without recording them in the audit log, for replaying
+// messages which have already been recorded
+func (s *Store) Replay() pushport.Handler {
+	return replay{store: s}
+}
+
+type replay struct {
+	store *Store
+}
+
+func (r replay) HandleMessage(msg *pushport.Message) {
+	r.store.handle(msg, false)
+}
+
+func (s *Store) handle(msg *pushport.Message, audit bool) {
+	response := msg.Pport.Response()
+	if len(response.StationMessages) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, ow := range response.StationMessages {
+		existing, exists := s.messages[ow.ID]
+
+		if len(ow.Stations) == 0 {
+			if exists {
+				delete(s.messages, ow.ID)
+				if audit {
+					s.audit.Record(msg.Time, EventCleared, existing)
+				}
+			}
+			continue
+		}
+
+		severity, _ := strconv.Atoi(ow.Severity)
+		updated := &Message{
+			ID:           ow.ID,
+			Category:     ow.Category,
+			Severity:     severity,
+			SeverityName: severityNames[ow.Severity],
+			Suppress:     ow.Suppress,
+			Text:         ow.Msg.Text(),
+			HTML:         strings.TrimSpace(ow.Msg.InnerXML),
+			RaisedAt:     msg.Time,
+			UpdatedAt:    msg.Time,
+		}
+		for _, station := range ow.Stations {
+			updated.Stations = append(updated.Stations, strings.ToUpper(station.CRS))
+		}
+
+		event := EventRaised
+		if exists {
+			updated.RaisedAt = existing.RaisedAt
+			event = EventUpdated
+		}
+
+		s.messages[ow.ID] = updated
+		if audit {
+			s.audit.Record(msg.Time, event, updated)
+		}
+	}
+}
+
+// ForStation returns the active messages for a station, most severe first
+func (s *Store) ForStation(crs string) []Message {
+	crs = strings.ToUpper(crs)
+	return s.find(func(m *Message) bool {
+		return slices.Contains(m.Stations, crs)
+	})
+}
+
+// All returns every active message, most severe first
+func (s *Store) All() []Message {
+	return s.find(func(m *Message) bool {
+		return true
+	})
+}
+
+func (s *Store) find(match func(m *Message) bool) []Message {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := []Message{}
+	for _, m := range s.messages {
+		if match(m) {
+			clone := *m
+			clone.Stations = slices.Clone(m.Stations)
+			clone.StationNames = make([]string, len(m.Stations))
+			for i, crs := range m.Stations {
+				clone.StationNames[i] = s.names.StationName(crs)
+			}
+			result = append(result, clone)
+		}
+	}
+
+	slices.SortFunc(result, func(a, b Message) int {
+		if a.Severity != b.Severity {
+			return b.Severity - a.Severity
+		}
+		return a.RaisedAt.Compare(b.RaisedAt)
+	})
+
+	return result
+}
@@ -0,0 +1,154 @@
+package timetable
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"gemini-push-port/darwinfiles"
+	"gemini-push-port/logging"
+	"gemini-push-port/pushport"
+	"gemini-push-port/trainstate"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// journeys are passed to the engine in batches, so live messages aren't held up for the whole file
+const seedBatchSize = 5_000
+
+// Loader seeds the train state engine from the daily Darwin timetable file (PportTimetable)
+type Loader struct {
+	mu     sync.Mutex
+	source darwinfiles.Source
+	engine *trainstate.Engine
+	loaded string
+}
+
+// NewLoaderFromEnv creates a loader which reads timetable files from TIMETABLE_PATH (a file, or a directory containing
+// timetable files), or from the bucket under TIMETABLE_S3_PREFIX
+func NewLoaderFromEnv(s3client *s3.Client, engine *trainstate.Engine) *Loader {
+	return &Loader{
+		source: darwinfiles.Source{
+			Kind:       "timetable",
+			LocalPath:  os.Getenv("TIMETABLE_PATH"),
+			S3Client:   s3client,
+			BucketName: os.Getenv("S3_COMPATIBLE_BUCKET_NAME"),
+			S3Prefix:   os.Getenv("TIMETABLE_S3_PREFIX"),
+			Match:      isTimetableFile,
+		},
+		engine: engine,
+	}
+}
+
+// Configured returns whether a source of timetable files has been configured
+func (l *Loader) Configured() bool {
+	return l.source.Configured()
+}
+
+// Load seeds the engine from the newest timetable file, if it hasn't been loaded already
+func (l *Loader) Load(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	file, err := l.source.Newest(ctx)
+	if err != nil {
+		return err
+	}
+	if file.Name <= l.loaded {
+		return nil
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	start := time.Now()
+	journeys, seeded, err := l.seed(file.Name, reader)
+	if err != nil {
+		return err
+	}
+
+	l.loaded = file.Name
+	logging.Logger.Infof("Loaded timetable %s in %s: seeded %d of %d journeys", file.Name, time.Since(start).Round(time.Millisecond), seeded, journeys)
+	return nil
+}
+
+// seed streams journeys and associations from the file into the engine. Associations are applied last, once every
+// journey they refer to has been seeded.
+func (l *Loader) seed(name string, r io.Reader) (int, int, error) {
+	decoder := xml.NewDecoder(r)
+	loadedAt := time.Now()
+
+	var batch []pushport.Schedule
+	var associations []pushport.Association
+	journeys := 0
+	seeded := 0
+	foundRoot := false
+
+	for {
+		tok, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to read timetable %s: %v", name, err)
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "PportTimetable":
+			foundRoot = true
+		case "Journey":
+			var journey pushport.Schedule
+			err = decoder.DecodeElement(&journey, &start)
+			if err != nil {
+				return 0, 0, fmt.Errorf("failed to decode journey in timetable %s: %v", name, err)
+			}
+			batch = append(batch, journey)
+			journeys++
+
+			if len(batch) == seedBatchSize {
+				seeded += l.engine.SeedFromTimetable(batch, nil, loadedAt)
+				batch = batch[:0]
+			}
+		case "Association":
+			var assoc pushport.Association
+			err = decoder.DecodeElement(&assoc, &start)
+			if err != nil {
+				return 0, 0, fmt.Errorf("failed to decode association in timetable %s: %v", name, err)
+			}
+			associations = append(associations, assoc)
+		}
+	}
+
+	if !foundRoot {
+		return 0, 0, fmt.Errorf("%s is not a Darwin timetable file", name)
+	}
+
+	seeded += l.engine.SeedFromTimetable(batch, associations, loadedAt)
+	return journeys, seeded, nil
+}
+
+// isTimetableFile returns whether a file name looks like a Darwin timetable file, e.g. 20250919020500_v8.xml.gz
+func isTimetableFile(name string) bool {
+	name = strings.TrimSuffix(name, ".gz")
+	return strings.Contains(name, "_v") && !strings.Contains(name, "_ref_v") && strings.HasSuffix(name, ".xml")
+}
+
+func LoadJob(loader *Loader) {
+	err := loader.Load(context.Background())
+	if err != nil {
+		logging.Logger.ErrorE("failed to load timetable", err)
+	}
+}
@@ -0,0 +1,357 @@
+package trainstate
+
+import (
+	"gemini-push-port/pushport"
+	"sync"
+	"time"
+)
+
+// Engine keeps the live state of every service seen on the feed, built from schedule, TS, deactivated and association
+// messages
+type Engine struct {
+	mu       sync.RWMutex
+	services map[string]*Service
+	// byTiploc indexes the RIDs of services by every TIPLOC they call at or pass
+	byTiploc map[string]map[string]struct{}
+	expiry   time.Duration
+}
+
+func NewEngine(expiry time.Duration) *Engine {
+	return &Engine{
+		services: make(map[string]*Service),
+		byTiploc: make(map[string]map[string]struct{}),
+		expiry:   expiry,
+	}
+}
+
+func (e *Engine) HandleMessage(msg *pushport.Message) {
+	response := msg.Pport.Response()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for i := range response.Schedules {
+		e.applySchedule(&response.Schedules[i], msg.Time)
+		e.services[response.Schedules[i].RID].ScheduleSource = ScheduleSourceLive
+	}
+	for i := range response.TrainStatuses {
+		e.applyTrainStatus(&response.TrainStatuses[i], msg.Time)
+	}
+	for i := range response.Associations {
+		e.applyAssociation(&response.Associations[i], msg.Time)
+	}
+	for _, d := range response.Deactivated {
+		if svc, ok := e.services[d.RID]; ok {
+			svc.Deactivated = true
+			svc.UpdatedAt = msg.Time
+		}
+	}
+}
+
+func (e *Engine) getOrCreate(rid string) *Service {
+	svc, ok := e.services[rid]
+	if !ok {
+		svc = &Service{
+			RID:            rid,
+			IsPassengerSvc: true,
+			IsActive:       true,
+		}
+		e.services[rid] = svc
+	}
+	return svc
+}
+
+func (e *Engine) index(svc *Service) {
+	for _, loc := range svc.Locations {
+		rids, ok := e.byTiploc[loc.Tiploc]
+		if !ok {
+			rids = make(map[string]struct{})
+			e.byTiploc[loc.Tiploc] = rids
+		}
+		rids[svc.RID] = struct{}{}
+	}
+}
+
+func (e *Engine) unindex(svc *Service) {
+	for _, loc := range svc.Locations {
+		rids, ok := e.byTiploc[loc.Tiploc]
+		if !ok {
+			continue
+		}
+		delete(rids, svc.RID)
+		if len(rids) == 0 {
+			delete(e.byTiploc, loc.Tiploc)
+		}
+	}
+}
+
+func (e *Engine) applySchedule(schedule *pushport.Schedule, msgTime time.Time) {
+	svc := e.getOrCreate(schedule.RID)
+	e.unindex(svc)
+	defer e.index(svc)
+
+	svc.UID = schedule.UID
+	svc.TrainID = schedule.TrainID
+	svc.RSID = schedule.RSID
+	svc.SSD = schedule.SSD
+	svc.TOC = schedule.TOC
+	svc.Status = schedule.Status
+	svc.TrainCat = schedule.TrainCat
+	svc.IsPassengerSvc = schedule.PassengerService()
+	svc.IsActive = schedule.Active()
+	svc.IsCharter = schedule.Charter()
+	svc.IsDeleted = schedule.IsDeleted()
+	svc.CancelReason = schedule.CancelReason
+	svc.DivertedVia = schedule.DivertedVia
+	svc.HasSchedule = true
+	svc.UpdatedAt = msgTime
+
+	// keep any forecasts we already have for locations which are still in the schedule
+	previous := make(map[string]*Location, len(svc.Locations))
+	for i := range svc.Locations {
+		previous[svc.Locations[i].key()] = &svc.Locations[i]
+	}
+
+	locations := make([]Location, 0, len(schedule.Locations))
+	allCancelled := len(schedule.Locations) > 0
+	for _, sl := range schedule.Locations {
+		loc := Location{
+			Tiploc:            sl.Tiploc,
+			Type:              sl.Type,
+			Activities:        sl.Activities,
+			PlannedActivities: sl.PlannedActivities,
+			Cancelled:         sl.Cancelled,
+			FalseDestination:  sl.FalseDestination,
+			Pta:               sl.Pta,
+			Ptd:               sl.Ptd,
+			Wta:               sl.Wta,
+			Wtd:               sl.Wtd,
+			Wtp:               sl.Wtp,
+		}
+
+		if prev, ok := previous[loc.key()]; ok {
+			copyForecasts(&loc, prev)
+		}
+
+		if !sl.Cancelled && sl.Type != pushport.LocationTypePassing {
+			allCancelled = false
+		}
+
+		locations = append(locations, loc)
+	}
+
+	svc.Locations = locations
+	svc.IsCancelled = allCancelled
+}
+
+func copyForecasts(dst *Location, src *Location) {
+	dst.Arrival = src.Arrival
+	dst.Departure = src.Departure
+	dst.Pass = src.Pass
+	dst.Platform = src.Platform
+	dst.PlatformSuppressed = src.PlatformSuppressed
+	dst.PlatformConfirmed = src.PlatformConfirmed
+	dst.PlatformSource = src.PlatformSource
+	dst.Suppressed = src.Suppressed
+	dst.Length = src.Length
+	dst.DetachFront = src.DetachFront
+}
+
+func (e *Engine) applyTrainStatus(ts *pushport.TrainStatus, msgTime time.Time) {
+	svc := e.getOrCreate(ts.RID)
+	if svc.UID == "" {
+		svc.UID = ts.UID
+	}
+	if svc.SSD == "" {
+		svc.SSD = ts.SSD
+	}
+	if ts.LateReason != nil {
+		svc.LateReason = ts.LateReason
+	}
+	svc.IsReverseFormation = ts.IsReverseFormation
+	svc.UpdatedAt = msgTime
+	defer e.index(svc)
+
+	for _, tl := range ts.Locations {
+		loc := svc.findLocation(tl.Tiploc, tl.Wta, tl.Wtd, tl.Wtp)
+		if loc == nil {
+			// we haven't seen the schedule for this service yet, so keep the forecast until it arrives
+			svc.Locations = append(svc.Locations, Location{
+				Tiploc: tl.Tiploc,
+				Pta:    tl.Pta,
+				Ptd:    tl.Ptd,
+				Wta:    tl.Wta,
+				Wtd:    tl.Wtd,
+				Wtp:    tl.Wtp,
+			})
+			loc = &svc.Locations[len(svc.Locations)-1]
+		}
+
+		loc.Arrival = mergeTimeData(loc.Arrival, tl.Arrival)
+		loc.Departure = mergeTimeData(loc.Departure, tl.Departure)
+		loc.Pass = mergeTimeData(loc.Pass, tl.Pass)
+
+		if tl.Platform != nil {
+			loc.Platform = tl.Platform.Number
+			loc.PlatformSuppressed = tl.Platform.Suppressed || tl.Platform.CisSuppressed
+			loc.PlatformConfirmed = tl.Platform.Confirmed
+			loc.PlatformSource = tl.Platform.Source
+		}
+		loc.Suppressed = tl.Suppressed
+		if tl.Length != "" {
+			loc.Length = tl.Length
+		}
+		loc.DetachFront = tl.DetachFront
+	}
+}
+
+func (s *Service) findLocation(tiploc string, wta string, wtd string, wtp string) *Location {
+	key := (&Location{Tiploc: tiploc, Wta: wta, Wtd: wtd, Wtp: wtp}).key()
+	for i := range s.Locations {
+		if s.Locations[i].key() == key {
+			return &s.Locations[i]
+		}
+	}
+	return nil
+}
+
+func mergeTimeData(existing *TimeEstimate, update *pushport.TSTimeData) *TimeEstimate {
+	if update == nil {
+		return existing
+	}
+
+	merged := &TimeEstimate{
+		Estimated:        update.Estimated,
+		WorkingEstimated: update.WorkingEstimated,
+		Actual:           update.Actual,
+		Delayed:          update.Delayed,
+		EstimateUnknown:  update.EstimateUnknown,
+		Source:           update.Source,
+	}
+
+	// an actual time is only removed when Darwin explicitly tells us to
+	if merged.Actual == "" && existing != nil && !update.ActualRemoved {
+		merged.Actual = existing.Actual
+	}
+
+	return merged
+}
+
+func (e *Engine) applyAssociation(assoc *pushport.Association, msgTime time.Time) {
+	a := Association{
+		Category:    assoc.Category,
+		Tiploc:      assoc.Tiploc,
+		MainRID:     assoc.Main.RID,
+		AssocRID:    assoc.Assoc.RID,
+		IsCancelled: assoc.IsCancelled,
+	}
+
+	for _, rid := range []string{a.MainRID, a.AssocRID} {
+		svc := e.getOrCreate(rid)
+		svc.UpdatedAt = msgTime
+		svc.setAssociation(a, assoc.IsDeleted)
+	}
+}
+
+func (s *Service) setAssociation(a Association, deleted bool) {
+	for i, existing := range s.Associations {
+		if existing.Category == a.Category && existing.Tiploc == a.Tiploc && existing.MainRID == a.MainRID && existing.AssocRID == a.AssocRID {
+			if deleted {
+				s.Associations = append(s.Associations[:i], s.Associations[i+1:]...)
+			} else {
+				s.Associations[i] = a
+			}
+			return
+		}
+	}
+
+	if !deleted {
+		s.Associations = append(s.Associations, a)
+	}
+}
+
+// Get returns a copy of the current state of a service
+func (e *Engine) Get(rid string) (Service, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	svc, ok := e.services[rid]
+	if !ok {
+		return Service{}, false
+	}
+	return svc.Clone(), true
+}
+
+// ServicesAt returns copies of every service which calls at, or passes, one of the TIPLOCs
+func (e *Engine) ServicesAt(tiplocs []string) []Service {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var result []Service
+	for _, tiploc := range tiplocs {
+		for rid := range e.byTiploc[tiploc] {
+			if seen[rid] {
+				continue
+			}
+			seen[rid] = true
+			result = append(result, e.services[rid].Clone())
+		}
+	}
+	return result
+}
+
+// Services returns copies of every service which match accepts. Only the services which are accepted are copied, so
+// match must not keep hold of the service it is given.
+func (e *Engine) Services(match func(svc *Service) bool) []Service {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	var result []Service
+	for _, svc := range e.services {
+		if match(svc) {
+			result = append(result, svc.Clone())
+		}
+	}
+	return result
+}
+
+// Count returns the number of services currently held
+func (e *Engine) Count() int {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return len(e.services)
+}
+
+// Remove removes a service, returning whether it was held
+func (e *Engine) Remove(rid string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	svc, ok := e.services[rid]
+	if !ok {
+		return false
+	}
+	e.unindex(svc)
+	delete(e.services, rid)
+	return true
+}
+
+// Expire removes services which haven't been updated within the engine's expiry duration
+func (e *Engine) Expire(now time.Time) int {
+	cutoff := now.Add(-e.expiry)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	removed := 0
+	for rid, svc := range e.services {
+		if svc.UpdatedAt.Before(cutoff) {
+			e.unindex(svc)
+			delete(e.services, rid)
+			removed++
+		}
+	}
+	return removed
+}
@@ -0,0 +1,40 @@
+package trainstate
+
+import (
+	"gemini-push-port/logging"
+	"os"
+	"time"
+)
+
+const defaultExpiry = 36 * time.Hour
+
+// GetExpiryFromEnv returns how long a service is kept after its last update, from TRAIN_STATE_EXPIRY
+func GetExpiryFromEnv() time.Duration {
+	expiry := os.Getenv("TRAIN_STATE_EXPIRY")
+	if expiry == "" {
+		return defaultExpiry
+	}
+
+	d, err := time.ParseDuration(expiry)
+	if err != nil {
+		logging.Logger.FatalE("failed to parse TRAIN_STATE_EXPIRY", err)
+	}
+	return d
+}
+
+// GetSnapshotPathFromEnv returns where the train state is snapshotted to, or an empty string if snapshots are disabled
+func GetSnapshotPathFromEnv() string {
+	return os.Getenv("TRAIN_STATE_SNAPSHOT_PATH")
+}
+
+func ExpireJob(engine *Engine) {
+	removed := engine.Expire(time.Now())
+	logging.Logger.Infof("expired %d services from train state, %d remaining", removed, engine.Count())
+}
+
+func SnapshotJob(engine *Engine, path string) {
+	err := engine.SaveSnapshot(path)
+	if err != nil {
+		logging.Logger.ErrorE("failed to save train state snapshot", err)
+	}
+}
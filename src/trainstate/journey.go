@@ -0,0 +1,175 @@
+package trainstate
+
+import (
+	"fmt"
+	"gemini-push-port/pushport"
+)
+
+// Roles a service can play in an association
+const (
+	RoleMain       = "main"
+	RoleAssociated = "associated"
+)
+
+var associationCategoryNames = map[string]string{
+	pushport.AssociationJoin:        "join",
+	pushport.AssociationDivide:      "divide",
+	pushport.AssociationNextWorking: "next-working",
+	pushport.AssociationLinked:      "linked",
+}
+
+// JourneyLink describes how a service is joined to, divided from or worked on from another service
+type JourneyLink struct {
+	Category     string `json:"category"`
+	CategoryName string `json:"categoryName"`
+	Tiploc       string `json:"tiploc"`
+	// Role is the part this service plays in the association
+	Role             string `json:"role"`
+	OtherRID         string `json:"otherRid"`
+	OtherTrainID     string `json:"otherTrainId,omitempty"`
+	OtherOrigin      string `json:"otherOrigin,omitempty"`
+	OtherDestination string `json:"otherDestination,omitempty"`
+	OtherCancelled   bool   `json:"otherCancelled"`
+	IsCancelled      bool   `json:"isCancelled"`
+	// Coaches is the number of coaches in the portion which joins or divides, when known
+	Coaches string `json:"coaches,omitempty"`
+	// Portion is front or rear for divides, when known
+	Portion     string `json:"portion,omitempty"`
+	Description string `json:"description"`
+}
+
+// Journey resolves the associations of a service against the other services in the engine, describing how the
+// combined journey fits together
+func (e *Engine) Journey(rid string) []JourneyLink {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	svc, ok := e.services[rid]
+	if !ok {
+		return nil
+	}
+
+	links := make([]JourneyLink, 0, len(svc.Associations))
+	for _, a := range svc.Associations {
+		link := JourneyLink{
+			Category:     a.Category,
+			CategoryName: associationCategoryNames[a.Category],
+			Tiploc:       a.Tiploc,
+			Role:         RoleMain,
+			OtherRID:     a.AssocRID,
+			IsCancelled:  a.IsCancelled,
+		}
+		if a.AssocRID == rid {
+			link.Role = RoleAssociated
+			link.OtherRID = a.MainRID
+		}
+
+		other := e.services[link.OtherRID]
+		if other != nil {
+			link.OtherTrainID = other.TrainID
+			link.OtherCancelled = other.IsCancelled || other.IsDeleted
+			if origin, ok := other.Origin(); ok {
+				link.OtherOrigin = origin.Tiploc
+			}
+			if destination, ok := other.Destination(); ok {
+				link.OtherDestination = destination.Tiploc
+			}
+		}
+
+		main, assoc := svc, other
+		if link.Role == RoleAssociated {
+			main, assoc = other, svc
+		}
+		link.Coaches, link.Portion = portionAt(main, assoc, a.Tiploc)
+		if a.Category != pushport.AssociationDivide {
+			link.Portion = ""
+		}
+
+		link.Description = describeLink(link)
+		links = append(links, link)
+	}
+
+	return links
+}
+
+// portionAt returns the number of coaches in the associated service at a TIPLOC, and which end of the main service
+// they detach from
+func portionAt(main *Service, assoc *Service, tiploc string) (coaches string, portion string) {
+	if assoc != nil {
+		for _, loc := range assoc.Locations {
+			if loc.Tiploc == tiploc && loc.Length != "" && loc.Length != "0" {
+				coaches = loc.Length
+				break
+			}
+		}
+	}
+
+	if main != nil {
+		for _, loc := range main.Locations {
+			if loc.Tiploc != tiploc {
+				continue
+			}
+			if loc.DetachFront {
+				portion = "front"
+			} else if loc.Length != "" {
+				// only assume the rear detaches once Darwin has given us formation details for the location
+				portion = "rear"
+			}
+			break
+		}
+	}
+
+	return coaches, portion
+}
+
+func describeLink(link JourneyLink) string {
+	other := link.OtherTrainID
+	if other == "" {
+		other = link.OtherRID
+	}
+
+	portion := "part of the train"
+	if link.Portion != "" && link.Coaches != "" {
+		portion = fmt.Sprintf("the %s %s coaches", link.Portion, link.Coaches)
+	} else if link.Portion != "" {
+		portion = fmt.Sprintf("the %s portion", link.Portion)
+	} else if link.Coaches != "" {
+		portion = fmt.Sprintf("%s coaches", link.Coaches)
+	}
+
+	var description string
+	switch {
+	case link.Category == pushport.AssociationDivide && link.Role == RoleMain:
+		description = fmt.Sprintf("divides at %s: %s go to %s as %s", link.Tiploc, portion, orUnknown(link.OtherDestination), other)
+	case link.Category == pushport.AssociationDivide:
+		description = fmt.Sprintf("formed from %s of %s to %s, which divides at %s", portion, other, orUnknown(link.OtherDestination), link.Tiploc)
+	case link.Category == pushport.AssociationJoin && link.Role == RoleMain:
+		description = fmt.Sprintf("joined at %s by %s from %s", link.Tiploc, other, orUnknown(link.OtherOrigin))
+		if link.Coaches != "" {
+			description += fmt.Sprintf(", adding %s coaches", link.Coaches)
+		}
+	case link.Category == pushport.AssociationJoin:
+		description = fmt.Sprintf("joins %s to %s at %s", other, orUnknown(link.OtherDestination), link.Tiploc)
+	case link.Category == pushport.AssociationNextWorking && link.Role == RoleMain:
+		description = fmt.Sprintf("forms %s to %s at %s", other, orUnknown(link.OtherDestination), link.Tiploc)
+	case link.Category == pushport.AssociationNextWorking:
+		description = fmt.Sprintf("formed by %s from %s at %s", other, orUnknown(link.OtherOrigin), link.Tiploc)
+	default:
+		description = fmt.Sprintf("linked to %s at %s", other, link.Tiploc)
+	}
+
+	if link.IsCancelled {
+		description += " (association cancelled)"
+	} else if link.OtherCancelled {
+		description += fmt.Sprintf(" (%s is cancelled)", other)
+	}
+
+	return description
+}
+
+func orUnknown(tiploc string) string {
+	if tiploc == "" {
+		return "an unknown location"
+	}
+	return tiploc
+}
@@ -0,0 +1,161 @@
+package trainstate
+
+import (
+	"gemini-push-port/pushport"
+	"slices"
+	"time"
+)
+
+// Service is the latest known state of a single service, keyed by its RID
+type Service struct {
+	RID                string           `json:"rid"`
+	UID                string           `json:"uid"`
+	TrainID            string           `json:"trainId"`
+	RSID               string           `json:"rsid,omitempty"`
+	SSD                string           `json:"ssd"`
+	TOC                string           `json:"toc"`
+	Status             string           `json:"status,omitempty"`
+	TrainCat           string           `json:"trainCat,omitempty"`
+	IsPassengerSvc     bool             `json:"isPassengerSvc"`
+	IsActive           bool             `json:"isActive"`
+	IsCharter          bool             `json:"isCharter"`
+	IsDeleted          bool             `json:"isDeleted"`
+	IsCancelled        bool             `json:"isCancelled"`
+	Deactivated        bool             `json:"deactivated"`
+	HasSchedule        bool             `json:"hasSchedule"`
+	ScheduleSource     string           `json:"scheduleSource,omitempty"`
+	IsReverseFormation bool             `json:"isReverseFormation"`
+	CancelReason       *pushport.Reason `json:"cancelReason,omitempty"`
+	LateReason         *pushport.Reason `json:"lateReason,omitempty"`
+	DivertedVia        string           `json:"divertedVia,omitempty"`
+	Locations          []Location       `json:"locations"`
+	Associations       []Association    `json:"associations,omitempty"`
+	UpdatedAt          time.Time        `json:"updatedAt"`
+}
+
+// Where a service's schedule came from. A live schedule message always supersedes one from the timetable file.
+const (
+	ScheduleSourceTimetable = "timetable"
+	ScheduleSourceLive      = "live"
+)
+
+type Location struct {
+	Tiploc             string        `json:"tiploc"`
+	Type               string        `json:"type"`
+	Activities         string        `json:"activities,omitempty"`
+	PlannedActivities  string        `json:"plannedActivities,omitempty"`
+	Cancelled          bool          `json:"cancelled"`
+	FalseDestination   string        `json:"falseDestination,omitempty"`
+	Pta                string        `json:"pta,omitempty"`
+	Ptd                string        `json:"ptd,omitempty"`
+	Wta                string        `json:"wta,omitempty"`
+	Wtd                string        `json:"wtd,omitempty"`
+	Wtp                string        `json:"wtp,omitempty"`
+	Arrival            *TimeEstimate `json:"arrival,omitempty"`
+	Departure          *TimeEstimate `json:"departure,omitempty"`
+	Pass               *TimeEstimate `json:"pass,omitempty"`
+	Platform           string        `json:"platform,omitempty"`
+	PlatformSuppressed bool          `json:"platformSuppressed"`
+	PlatformConfirmed  bool          `json:"platformConfirmed"`
+	PlatformSource     string        `json:"platformSource,omitempty"`
+	Suppressed         bool          `json:"suppressed"`
+	Length             string        `json:"length,omitempty"`
+	DetachFront        bool          `json:"detachFront"`
+}
+
+// TimeEstimate holds the forecast or actual time for an arrival, departure or pass
+type TimeEstimate struct {
+	Estimated        string `json:"estimated,omitempty"`
+	WorkingEstimated string `json:"workingEstimated,omitempty"`
+	Actual           string `json:"actual,omitempty"`
+	Delayed          bool   `json:"delayed"`
+	EstimateUnknown  bool   `json:"estimateUnknown"`
+	Source           string `json:"source,omitempty"`
+}
+
+type Association struct {
+	Category    string `json:"category"`
+	Tiploc      string `json:"tiploc"`
+	MainRID     string `json:"mainRid"`
+	AssocRID    string `json:"assocRid"`
+	IsCancelled bool   `json:"isCancelled"`
+}
+
+// Clone returns a deep copy of the service, which can safely be used outside of the engine's lock
+func (s *Service) Clone() Service {
+	clone := *s
+
+	clone.Locations = slices.Clone(s.Locations)
+	for i := range clone.Locations {
+		clone.Locations[i].Arrival = cloneEstimate(s.Locations[i].Arrival)
+		clone.Locations[i].Departure = cloneEstimate(s.Locations[i].Departure)
+		clone.Locations[i].Pass = cloneEstimate(s.Locations[i].Pass)
+	}
+	clone.Associations = slices.Clone(s.Associations)
+
+	if s.CancelReason != nil {
+		reason := *s.CancelReason
+		clone.CancelReason = &reason
+	}
+	if s.LateReason != nil {
+		reason := *s.LateReason
+		clone.LateReason = &reason
+	}
+
+	return clone
+}
+
+func cloneEstimate(t *TimeEstimate) *TimeEstimate {
+	if t == nil {
+		return nil
+	}
+	clone := *t
+	return &clone
+}
+
+// key identifies a location within a schedule. TIPLOCs can appear more than once in a schedule (e.g. on circular
+// routes), so the working times are used to tell them apart.
+func (l *Location) key() string {
+	return l.Tiploc + "|" + l.Wta + "|" + l.Wtd + "|" + l.Wtp
+}
+
+// LastReportedLocation returns the last location the service has an actual arrival, departure or pass time for
+func (s *Service) LastReportedLocation() (Location, bool) {
+	for i := len(s.Locations) - 1; i >= 0; i-- {
+		loc := s.Locations[i]
+		if loc.Arrival.hasActual() || loc.Departure.hasActual() || loc.Pass.hasActual() {
+			return loc, true
+		}
+	}
+	return Location{}, false
+}
+
+func (t *TimeEstimate) hasActual() bool {
+	return t != nil && t.Actual != ""
+}
+
+// Origin returns the first public origin of the service, falling back to its operational origin
+func (s *Service) Origin() (Location, bool) {
+	for _, loc := range s.Locations {
+		if loc.Type == pushport.LocationTypeOrigin {
+			return loc, true
+		}
+	}
+	if len(s.Locations) > 0 {
+		return s.Locations[0], true
+	}
+	return Location{}, false
+}
+
+// Destination returns the last public destination of the service, falling back to its operational destination
+func (s *Service) Destination() (Location, bool) {
+	for i := len(s.Locations) - 1; i >= 0; i-- {
+		if s.Locations[i].Type == pushport.LocationTypeDestination {
+			return s.Locations[i], true
+		}
+	}
+	if len(s.Locations) > 0 {
+		return s.Locations[len(s.Locations)-1], true
+	}
+	return Location{}, false
+}
@@ -0,0 +1,103 @@
+package trainstate
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gemini-push-port/logging"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type snapshot struct {
+	TakenAt  time.Time  `json:"takenAt"`
+	Services []*Service `json:"services"`
+}
+
+// SaveSnapshot writes the state of every service to a gzipped JSON file. The file is written alongside the target and
+// renamed into place, so a crash part way through never leaves a truncated snapshot behind.
+func (e *Engine) SaveSnapshot(path string) error {
+	e.mu.RLock()
+	snap := snapshot{
+		TakenAt:  time.Now().UTC(),
+		Services: make([]*Service, 0, len(e.services)),
+	}
+	for _, svc := range e.services {
+		clone := svc.Clone()
+		snap.Services = append(snap.Services, &clone)
+	}
+	e.mu.RUnlock()
+
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	gzWriter := gzip.NewWriter(tmpFile)
+	err = json.NewEncoder(gzWriter).Encode(snap)
+	if err != nil {
+		_ = tmpFile.Close()
+		return err
+	}
+	if err := gzWriter.Close(); err != nil {
+		_ = tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), path)
+}
+
+// LoadSnapshot restores services from a snapshot file, skipping any which would already have expired. A missing
+// snapshot isn't an error, as there won't be one the first time the service starts.
+func (e *Engine) LoadSnapshot(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		logging.Logger.Infof("No train state snapshot found at %s", path)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gzReader, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot %s: %v", path, err)
+	}
+	defer gzReader.Close()
+
+	var snap snapshot
+	err = json.NewDecoder(gzReader).Decode(&snap)
+	if err != nil {
+		return fmt.Errorf("failed to decode snapshot %s: %v", path, err)
+	}
+
+	cutoff := time.Now().Add(-e.expiry)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	loaded := 0
+	for _, svc := range snap.Services {
+		if svc.UpdatedAt.Before(cutoff) {
+			continue
+		}
+		e.services[svc.RID] = svc
+		e.index(svc)
+		loaded++
+	}
+
+	logging.Logger.Infof("Loaded %d services from train state snapshot taken at %s", loaded, snap.TakenAt.Format(time.RFC3339))
+	return nil
+}
@@ -0,0 +1,74 @@
+package trainstate
+
+import (
+	"gemini-push-port/darwintime"
+	"time"
+)
+
+// LocationTimes holds the absolute times for a location in a service. Zero values mean there is no time of that kind.
+type LocationTimes struct {
+	ScheduledArrival   time.Time
+	ScheduledDeparture time.Time
+	ScheduledPass      time.Time
+	ExpectedArrival    time.Time
+	ExpectedDeparture  time.Time
+	ExpectedPass       time.Time
+	ActualArrival      time.Time
+	ActualDeparture    time.Time
+	ActualPass         time.Time
+}
+
+// Times resolves the times of every location in the service from Darwin's HH:MM times (see darwintime). Working times
+// are resolved in sequence, and public times, forecasts and actuals relative to the location's working or scheduled
+// time.
+func (s *Service) Times() []LocationTimes {
+	result := make([]LocationTimes, len(s.Locations))
+
+	ssd, err := darwintime.ParseSSD(s.SSD)
+	if err != nil {
+		return result
+	}
+
+	working := darwintime.NewSequence(ssd)
+	for i, loc := range s.Locations {
+		times := &result[i]
+
+		// working times are always in sequence, so are resolved first and used as the reference for public times
+		workingArrival := working.Next(loc.Wta)
+		workingDeparture := working.Next(loc.Wtd)
+		workingPass := working.Next(loc.Wtp)
+
+		times.ScheduledArrival = darwintime.Near(ssd, loc.Pta, workingArrival)
+		times.ScheduledDeparture = darwintime.Near(ssd, loc.Ptd, workingDeparture)
+		times.ScheduledPass = workingPass
+		if times.ScheduledArrival.IsZero() {
+			times.ScheduledArrival = workingArrival
+		}
+		if times.ScheduledDeparture.IsZero() {
+			times.ScheduledDeparture = workingDeparture
+		}
+
+		times.ExpectedArrival, times.ActualArrival = resolveEstimate(ssd, loc.Arrival, times.ScheduledArrival)
+		times.ExpectedDeparture, times.ActualDeparture = resolveEstimate(ssd, loc.Departure, times.ScheduledDeparture)
+		times.ExpectedPass, times.ActualPass = resolveEstimate(ssd, loc.Pass, times.ScheduledPass)
+	}
+
+	return result
+}
+
+func resolveEstimate(ssd time.Time, estimate *TimeEstimate, scheduled time.Time) (expected time.Time, actual time.Time) {
+	if estimate == nil {
+		return time.Time{}, time.Time{}
+	}
+
+	actual = darwintime.Near(ssd, estimate.Actual, scheduled)
+	if !actual.IsZero() {
+		return actual, actual
+	}
+
+	expected = darwintime.Near(ssd, estimate.Estimated, scheduled)
+	if expected.IsZero() {
+		expected = darwintime.Near(ssd, estimate.WorkingEstimated, scheduled)
+	}
+	return expected, time.Time{}
+}
@@ -0,0 +1,46 @@
+package trainstate
+
+import (
+	"gemini-push-port/pushport"
+	"time"
+)
+
+// SeedFromTimetable adds journeys and associations from a Darwin timetable file, so forecasts arriving later attach to
+// a known schedule. Services whose schedule has already come from a live schedule message are left alone, and a live
+// schedule arriving later replaces the timetable's. Returns the number of journeys seeded.
+func (e *Engine) SeedFromTimetable(journeys []pushport.Schedule, associations []pushport.Association, loadedAt time.Time) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	seeded := 0
+	for i := range journeys {
+		journey := &journeys[i]
+		if journey.IsDeleted() {
+			continue
+		}
+		if svc, ok := e.services[journey.RID]; ok && svc.HasSchedule && svc.ScheduleSource != ScheduleSourceTimetable {
+			continue
+		}
+
+		e.applySchedule(journey, loadedAt)
+		e.services[journey.RID].ScheduleSource = ScheduleSourceTimetable
+		seeded++
+	}
+
+	for i := range associations {
+		assoc := &associations[i]
+		if assoc.IsDeleted {
+			continue
+		}
+		// don't create empty services for journeys which were skipped above
+		if _, ok := e.services[assoc.Main.RID]; !ok {
+			continue
+		}
+		if _, ok := e.services[assoc.Assoc.RID]; !ok {
+			continue
+		}
+		e.applyAssociation(assoc, loadedAt)
+	}
+
+	return seeded
+}
@@ -0,0 +1,180 @@
+package validation
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const xsdNamespace = "http://www.w3.org/2001/XMLSchema"
+
+// Schema is a lightweight view of a set of XSD files. It doesn't implement the full XSD specification, but knows which
+// elements each namespace declares, which is enough to catch messages for the wrong Push Port version, unknown message
+// types and misplaced elements.
+type Schema struct {
+	namespaces map[string]*namespaceDecl
+}
+
+type namespaceDecl struct {
+	qualified bool
+	global    map[string]bool
+	elements  map[string]bool
+}
+
+// LoadSchema loads a single XSD file, or every .xsd file in a directory
+func LoadSchema(path string) (*Schema, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files, err = filepath.Glob(filepath.Join(path, "*.xsd"))
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no .xsd files found in %s", path)
+		}
+	}
+
+	schema := &Schema{namespaces: make(map[string]*namespaceDecl)}
+	for _, file := range files {
+		err := schema.loadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %v", file, err)
+		}
+	}
+
+	return schema, nil
+}
+
+func (s *Schema) loadFile(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	decoder := xml.NewDecoder(f)
+
+	var decl *namespaceDecl
+	depth := 0
+	for {
+		tok, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if t.Name.Space != xsdNamespace {
+				continue
+			}
+
+			if t.Name.Local == "schema" && depth == 1 {
+				targetNamespace := attr(t, "targetNamespace")
+				decl = s.namespaces[targetNamespace]
+				if decl == nil {
+					decl = &namespaceDecl{global: make(map[string]bool), elements: make(map[string]bool)}
+					s.namespaces[targetNamespace] = decl
+				}
+				decl.qualified = attr(t, "elementFormDefault") == "qualified"
+				continue
+			}
+
+			if t.Name.Local != "element" || decl == nil {
+				continue
+			}
+
+			name := attr(t, "name")
+			if name == "" {
+				// a reference to an element declared elsewhere
+				continue
+			}
+
+			decl.elements[name] = true
+			if depth == 2 {
+				decl.global[name] = true
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+
+	if decl == nil {
+		return errors.New("not an XSD file")
+	}
+
+	return nil
+}
+
+func attr(el xml.StartElement, name string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == name && a.Name.Space == "" {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// Check returns an error if the root element isn't a global element of the schema, or any element isn't declared by the
+// schema for its namespace
+func (s *Schema) Check(payload string) error {
+	decoder := xml.NewDecoder(strings.NewReader(payload))
+
+	depth := 0
+	for {
+		tok, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+
+			decl, ok := s.namespaces[t.Name.Space]
+			if !ok {
+				if t.Name.Space == "" {
+					// unqualified local elements may be declared by any namespace
+					if s.declaredUnqualified(t.Name.Local) {
+						continue
+					}
+					return fmt.Errorf("element <%s> is not declared in the schema", t.Name.Local)
+				}
+				return fmt.Errorf("namespace %s of element <%s> is not part of the schema", t.Name.Space, t.Name.Local)
+			}
+
+			if depth == 1 && !decl.global[t.Name.Local] {
+				return fmt.Errorf("root element <%s> is not a global element of namespace %s", t.Name.Local, t.Name.Space)
+			}
+			if !decl.elements[t.Name.Local] {
+				return fmt.Errorf("element <%s> is not declared in namespace %s", t.Name.Local, t.Name.Space)
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+}
+
+func (s *Schema) declaredUnqualified(name string) bool {
+	for _, decl := range s.namespaces {
+		if !decl.qualified && decl.elements[name] {
+			return true
+		}
+	}
+	return false
+}
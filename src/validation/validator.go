@@ -0,0 +1,153 @@
+package validation
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"gemini-push-port/logging"
+	"io"
+	"os"
+	"strings"
+)
+
+type ErrorKind string
+
+const (
+	KindMalformed ErrorKind = "malformed"
+	KindSchema    ErrorKind = "schema"
+)
+
+// Error describes why a message failed validation
+type Error struct {
+	Kind ErrorKind
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %v", e.Kind, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+type Validator struct {
+	schema *Schema
+}
+
+// NewFromEnv creates a validator which always checks messages are well-formed, and additionally checks Push Port
+// messages against the XSD set at PUSH_PORT_XSD_PATH when it is configured
+func NewFromEnv() *Validator {
+	xsdPath := os.Getenv("PUSH_PORT_XSD_PATH")
+	if xsdPath == "" {
+		return &Validator{}
+	}
+
+	schema, err := LoadSchema(xsdPath)
+	if err != nil {
+		logging.Logger.FatalE("failed to load Push Port XSD", err)
+	}
+
+	logging.Logger.Infof("Validating messages against %d schema namespaces from %s", len(schema.namespaces), xsdPath)
+	return &Validator{schema: schema}
+}
+
+// pushPortRoot is the root element of Push Port messages. Other feeds on the same consumer, such as PTAC, have their
+// own schemas, so are only checked for being well-formed.
+const pushPortRoot = "Pport"
+
+// Validate returns an *Error if the message isn't well-formed, or is a Push Port message which doesn't conform to the
+// configured schema
+func (v *Validator) Validate(raw string) error {
+	payload, err := ExtractXml(raw)
+	if err != nil {
+		return &Error{Kind: KindMalformed, Err: err}
+	}
+
+	root, err := checkWellFormed(payload)
+	if err != nil {
+		return &Error{Kind: KindMalformed, Err: err}
+	}
+
+	if v.schema != nil && root == pushPortRoot {
+		err = v.schema.Check(payload)
+		if err != nil {
+			return &Error{Kind: KindSchema, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// ExtractXml returns the XML payload of a message. Some feeds wrap their XML in a JSON envelope with the document in
+// its bytes field, in which case the envelope must be valid JSON too.
+func ExtractXml(raw string) (string, error) {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return "", errors.New("message is empty")
+	}
+
+	if !strings.HasPrefix(trimmed, "{") {
+		return trimmed, nil
+	}
+
+	var envelope struct {
+		Bytes *string `json:"bytes"`
+	}
+	err := json.Unmarshal([]byte(trimmed), &envelope)
+	if err != nil {
+		return "", fmt.Errorf("invalid JSON envelope: %v", err)
+	}
+	if envelope.Bytes == nil {
+		return "", errors.New("JSON envelope has no bytes field")
+	}
+
+	return *envelope.Bytes, nil
+}
+
+// checkWellFormed returns the local name of the document's root element if it's well-formed
+func checkWellFormed(payload string) (string, error) {
+	decoder := xml.NewDecoder(strings.NewReader(payload))
+	decoder.Strict = true
+
+	depth := 0
+	roots := 0
+	root := ""
+	for {
+		tok, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				roots++
+				if roots > 1 {
+					return "", fmt.Errorf("unexpected second root element <%s>", t.Name.Local)
+				}
+				root = t.Name.Local
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			if depth == 0 && len(strings.TrimSpace(string(t))) > 0 {
+				return "", errors.New("unexpected text outside of root element")
+			}
+		}
+	}
+
+	if roots == 0 {
+		return "", errors.New("no root element")
+	}
+	if depth != 0 {
+		return "", errors.New("unexpected end of document")
+	}
+
+	return root, nil
+}
@@ -0,0 +1,210 @@
+package warmstart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gemini-push-port/darwintime"
+	"gemini-push-port/logging"
+	"gemini-push-port/pushport"
+	"gemini-push-port/rawstore"
+	"hash/fnv"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCutoff = "02:00"
+	// messages archived within this long of the last replayed message are remembered, as Kafka may deliver them again
+	// if they were archived but their offsets weren't committed before the restart
+	dedupeLookback = 15 * time.Minute
+	// once live consumption has been running for this long, re-delivered messages will have been and gone
+	dedupeWindow = 30 * time.Minute
+)
+
+// Replayer rebuilds the in-memory state on startup by replaying the current railway day's archives through the
+// Push Port handlers, before live consumption resumes
+type Replayer struct {
+	archive *rawstore.ArchiveReader
+	enabled bool
+	// cutoffHour and cutoffMinute are the UK local time the railway day starts at. Nothing archived before the most
+	// recent cut-off is replayed.
+	cutoffHour   int
+	cutoffMinute int
+
+	mu sync.Mutex
+	// replayed holds a hash of each replayed message along with its time
+	replayed   map[uint64]time.Time
+	finishedAt time.Time
+}
+
+// NewReplayerFromEnv creates a replayer which is enabled by WARM_START_ENABLED, and replays everything archived since
+// WARM_START_CUTOFF (HH:MM in UK local time, 02:00 by default)
+func NewReplayerFromEnv(archive *rawstore.ArchiveReader) *Replayer {
+	enabled, _ := strconv.ParseBool(os.Getenv("WARM_START_ENABLED"))
+
+	cutoff := os.Getenv("WARM_START_CUTOFF")
+	if cutoff == "" {
+		cutoff = defaultCutoff
+	}
+	cutoffTime, err := time.Parse("15:04", cutoff)
+	if err != nil {
+		logging.Logger.FatalE("failed to parse WARM_START_CUTOFF", err)
+	}
+
+	return &Replayer{
+		archive:      archive,
+		enabled:      enabled,
+		cutoffHour:   cutoffTime.Hour(),
+		cutoffMinute: cutoffTime.Minute(),
+		replayed:     make(map[uint64]time.Time),
+	}
+}
+
+func (r *Replayer) Enabled() bool {
+	return r.enabled
+}
+
+// RailwayDayStart returns the most recent cut-off at or before now
+func (r *Replayer) RailwayDayStart(now time.Time) time.Time {
+	local := now.In(darwintime.UK)
+	start := time.Date(local.Year(), local.Month(), local.Day(), r.cutoffHour, r.cutoffMinute, 0, 0, darwintime.UK)
+	if start.After(local) {
+		start = time.Date(local.Year(), local.Month(), local.Day()-1, r.cutoffHour, r.cutoffMinute, 0, 0, darwintime.UK)
+	}
+	return start
+}
+
+// Replay passes every message archived between the start of the railway day and now to the handlers, in order.
+// Hours which were never archived are skipped.
+func (r *Replayer) Replay(ctx context.Context, now time.Time, handlers ...pushport.Handler) error {
+	from := r.RailwayDayStart(now)
+	hours := rawstore.HoursBetween(from, now)
+	logging.Logger.Infof("Warm start: replaying %d archive hours since %s", len(hours), from.Format(time.RFC3339))
+
+	start := time.Now()
+	total := 0
+	for i, hour := range hours {
+		messages := 0
+		err := r.archive.ReadHour(ctx, hour, func(raw *rawstore.XmlMessageWithTime) error {
+			msg, err := pushport.NewMessage(raw)
+			if err != nil {
+				return nil
+			}
+			// the first archive hour can start before the cut-off
+			if msg.Time.Before(from) {
+				return nil
+			}
+
+			for _, handler := range handlers {
+				handler.HandleMessage(msg)
+			}
+			r.remember(raw.Message, msg.Time)
+			messages++
+			return nil
+		})
+		if errors.Is(err, rawstore.ErrArchiveNotFound) {
+			logging.Logger.Infof("Warm start: no archive for %s (%d/%d), skipping", hour.Format("2006-01-02 15:00"), i+1, len(hours))
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to replay archive for %s: %v", hour.Format("2006-01-02 15:00"), err)
+		}
+
+		total += messages
+		r.forgetOld()
+		logging.Logger.Infof("Warm start: replayed %d messages for %s (%d/%d)", messages, hour.Format("2006-01-02 15:00"), i+1, len(hours))
+	}
+
+	r.mu.Lock()
+	r.finishedAt = time.Now()
+	remembered := len(r.replayed)
+	r.mu.Unlock()
+
+	logging.Logger.Infof("Warm start: replayed %d messages in %s, watching for %d re-delivered messages", total, time.Since(start).Round(time.Millisecond), remembered)
+	return nil
+}
+
+func (r *Replayer) remember(message string, msgTime time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.replayed[hashMessage(message)] = msgTime
+}
+
+// forgetOld forgets replayed messages which are too old to be re-delivered, so only the end of the replay is kept
+func (r *Replayer) forgetOld() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var latest time.Time
+	for _, msgTime := range r.replayed {
+		if msgTime.After(latest) {
+			latest = msgTime
+		}
+	}
+	for key, msgTime := range r.replayed {
+		if latest.Sub(msgTime) > dedupeLookback {
+			delete(r.replayed, key)
+		}
+	}
+}
+
+// Deduplicate returns a handler which passes live messages to the handlers, except for messages which were already
+// replayed and have been delivered again by Kafka
+func (r *Replayer) Deduplicate(handlers ...pushport.Handler) pushport.Handler {
+	return &deduplicator{
+		replayer: r,
+		handlers: handlers,
+	}
+}
+
+type deduplicator struct {
+	replayer *Replayer
+	handlers []pushport.Handler
+	skipped  int
+}
+
+func (d *deduplicator) HandleMessage(msg *pushport.Message) {
+	if d.replayer.seen(msg.Raw.Message) {
+		d.skipped++
+		if d.skipped%100 == 1 {
+			logging.Logger.Infof("Warm start: skipped %d messages re-delivered after being replayed", d.skipped)
+		}
+		return
+	}
+
+	for _, handler := range d.handlers {
+		handler.HandleMessage(msg)
+	}
+}
+
+// seen returns whether a message was replayed, forgetting it so a genuine repeat later on still gets through
+func (r *Replayer) seen(message string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.replayed) == 0 {
+		return false
+	}
+	if time.Since(r.finishedAt) > dedupeWindow {
+		r.replayed = make(map[uint64]time.Time)
+		return false
+	}
+
+	key := hashMessage(message)
+	if _, ok := r.replayed[key]; !ok {
+		return false
+	}
+	delete(r.replayed, key)
+	return true
+}
+
+// hashMessage hashes a message as it's archived, so a live message matches its replayed copy even if it had line
+// breaks
+func hashMessage(message string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(rawstore.ArchiveLine(message)))
+	return h.Sum64()
+}
@@ -0,0 +1,82 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"gemini-push-port/logging"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type deadLetter struct {
+	Time           time.Time       `json:"time"`
+	SubscriptionID string          `json:"subscriptionId"`
+	URL            string          `json:"url"`
+	Attempts       int             `json:"attempts"`
+	Error          string          `json:"error"`
+	Payload        json.RawMessage `json:"payload"`
+}
+
+// DeadLetterLog appends a line to a daily file for every webhook delivery which failed on every attempt
+type DeadLetterLog struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// NewDeadLetterLogFromEnv writes to WEBHOOKS_DEAD_LETTER_DIR, or a webhooks-dead-letter directory in the workdir
+func NewDeadLetterLogFromEnv() *DeadLetterLog {
+	dir := os.Getenv("WEBHOOKS_DEAD_LETTER_DIR")
+	if dir == "" {
+		workdir := os.Getenv("PUSH_PORT_DUMP_WORKDIR")
+		if workdir == "" {
+			panic("PUSH_PORT_DUMP_WORKDIR environment variable not set")
+		}
+		dir = filepath.Join(workdir, "webhooks-dead-letter")
+	}
+
+	return &DeadLetterLog{dir: dir}
+}
+
+func (l *DeadLetterLog) Record(sub Subscription, attempts int, deliveryErr error, payload []byte) {
+	now := time.Now().UTC()
+	line, err := json.Marshal(deadLetter{
+		Time:           now,
+		SubscriptionID: sub.ID,
+		URL:            sub.URL,
+		Attempts:       attempts,
+		Error:          deliveryErr.Error(),
+		Payload:        payload,
+	})
+	if err != nil {
+		logging.Logger.ErrorE("failed to marshal webhook dead letter", err)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	err = os.MkdirAll(l.dir, 0755)
+	if err != nil {
+		logging.Logger.ErrorE("failed to create webhook dead letter directory", err)
+		return
+	}
+
+	filePath := filepath.Join(l.dir, now.Format(time.DateOnly)+".log")
+	f, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		logging.Logger.ErrorE("failed to open webhook dead letter log", err)
+		return
+	}
+	defer func(f *os.File) {
+		err := f.Close()
+		if err != nil {
+			logging.Logger.ErrorE("failed to close file", err)
+		}
+	}(f)
+
+	_, err = f.Write(append(line, '\n'))
+	if err != nil {
+		logging.Logger.ErrorE("failed to write webhook dead letter", err)
+	}
+}
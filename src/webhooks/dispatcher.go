@@ -0,0 +1,311 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"gemini-push-port/boards"
+	"gemini-push-port/logging"
+	"gemini-push-port/outbound"
+	"gemini-push-port/pushport"
+	"gemini-push-port/trainstate"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxAttempts = 5
+	defaultWorkers     = 4
+	initialBackoff     = time.Second
+	maxBackoff         = 5 * time.Minute
+	deliveryQueueSize  = 10_000
+)
+
+// Payload is the JSON body POSTed to a subscription's URL for each matching Push Port message
+type Payload struct {
+	SubscriptionID string    `json:"subscriptionId"`
+	DeliveryID     string    `json:"deliveryId"`
+	Time           time.Time `json:"time"`
+	Types          []string  `json:"types"`
+	RIDs           []string  `json:"rids"`
+	UpdateOrigin   string    `json:"updateOrigin,omitempty"`
+	// Services is the current state of every matching service after the message was applied
+	Services []trainstate.Service `json:"services,omitempty"`
+	XML      string               `json:"xml"`
+}
+
+type delivery struct {
+	subscription Subscription
+	payload      Payload
+	// body is the marshalled payload, set on the first attempt
+	body     []byte
+	attempts int
+	backoff  time.Duration
+}
+
+// Dispatcher matches every Push Port message against the webhook subscriptions, and POSTs a signed payload to each
+// matching subscription. It should be given messages after the train state engine, so payloads include the update.
+type Dispatcher struct {
+	store          *Store
+	engine         *trainstate.Engine
+	resolver       boards.Resolver
+	deadLetters    *DeadLetterLog
+	client         *http.Client
+	maxAttempts    int
+	workers        int
+	initialBackoff time.Duration
+	// allowPrivate lets webhooks be delivered to loopback, link-local and private addresses
+	allowPrivate bool
+
+	mu            sync.RWMutex
+	subscriptions []Subscription
+
+	deliveries chan delivery
+	dropped    int
+}
+
+// NewDispatcherFromEnv creates a dispatcher which tries each delivery WEBHOOKS_MAX_ATTEMPTS times (5 by default) from
+// WEBHOOKS_WORKERS goroutines (4 by default). Deliveries to loopback, link-local and private addresses are refused
+// unless WEBHOOKS_ALLOW_PRIVATE_URLS is true.
+func NewDispatcherFromEnv(store *Store, engine *trainstate.Engine, resolver boards.Resolver) (*Dispatcher, error) {
+	allowPrivate, _ := strconv.ParseBool(os.Getenv("WEBHOOKS_ALLOW_PRIVATE_URLS"))
+	d := &Dispatcher{
+		store:          store,
+		engine:         engine,
+		resolver:       resolver,
+		deadLetters:    NewDeadLetterLogFromEnv(),
+		client:         outbound.NewClient(allowPrivate, 10*time.Second),
+		maxAttempts:    getPositiveIntFromEnv("WEBHOOKS_MAX_ATTEMPTS", defaultMaxAttempts),
+		workers:        getPositiveIntFromEnv("WEBHOOKS_WORKERS", defaultWorkers),
+		initialBackoff: initialBackoff,
+		allowPrivate:   allowPrivate,
+		deliveries:     make(chan delivery, deliveryQueueSize),
+	}
+
+	err := d.reload()
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func getPositiveIntFromEnv(name string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || value < 1 {
+		return defaultValue
+	}
+	return value
+}
+
+// reload refreshes the in-memory copy of the subscriptions, which messages are matched against
+func (d *Dispatcher) reload() error {
+	subs, err := d.store.List()
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.subscriptions = subs
+	return nil
+}
+
+func (d *Dispatcher) HandleMessage(msg *pushport.Message) {
+	d.mu.RLock()
+	subs := d.subscriptions
+	d.mu.RUnlock()
+	if len(subs) == 0 {
+		return
+	}
+
+	response := msg.Pport.Response()
+	rids := response.RIDs()
+
+	services := make(map[string]trainstate.Service, len(rids))
+	for _, rid := range rids {
+		if svc, ok := d.engine.Get(rid); ok {
+			services[rid] = svc
+		}
+	}
+
+	stations := make(map[string]bool)
+	for _, sm := range response.StationMessages {
+		for _, station := range sm.Stations {
+			stations[strings.ToUpper(station.CRS)] = true
+		}
+	}
+
+	for _, sub := range subs {
+		matched, ok := d.match(&sub, rids, services, stations)
+		if !ok {
+			continue
+		}
+
+		payload := Payload{
+			SubscriptionID: sub.ID,
+			Time:           msg.Time,
+			Types:          response.MessageTypes(),
+			RIDs:           rids,
+			UpdateOrigin:   response.UpdateOrigin,
+			Services:       matched,
+			XML:            msg.Raw.Message,
+		}
+
+		select {
+		case d.deliveries <- delivery{subscription: sub, payload: payload}:
+		default:
+			d.dropped++
+			if d.dropped%100 == 1 {
+				logging.Logger.Warnf("Webhook delivery queue full, %d deliveries dropped so far", d.dropped)
+			}
+		}
+	}
+}
+
+// match returns whether a message matches the subscription, along with the services it matched
+func (d *Dispatcher) match(sub *Subscription, rids []string, services map[string]trainstate.Service, stations map[string]bool) ([]trainstate.Service, bool) {
+	switch {
+	case sub.RID != "":
+		if !slices.Contains(rids, sub.RID) {
+			return nil, false
+		}
+		if svc, ok := services[sub.RID]; ok {
+			return []trainstate.Service{svc}, true
+		}
+		return nil, true
+	case sub.Headcode != "":
+		var matched []trainstate.Service
+		for _, rid := range rids {
+			svc, ok := services[rid]
+			if ok && strings.EqualFold(svc.TrainID, sub.Headcode) && svc.SSD == sub.Date {
+				matched = append(matched, svc)
+			}
+		}
+		return matched, len(matched) > 0
+	case sub.CRS != "":
+		tiplocs := d.resolver.TiplocsForCRS(sub.CRS)
+		var matched []trainstate.Service
+		for _, rid := range rids {
+			svc, ok := services[rid]
+			if ok && slices.ContainsFunc(svc.Locations, func(l trainstate.Location) bool {
+				return l.Type != pushport.LocationTypePassing && slices.Contains(tiplocs, l.Tiploc)
+			}) {
+				matched = append(matched, svc)
+			}
+		}
+		return matched, len(matched) > 0 || stations[strings.ToUpper(sub.CRS)]
+	}
+	return nil, false
+}
+
+// Thread delivers queued payloads from the dispatcher's workers, until the process exits
+func (d *Dispatcher) Thread() {
+	var wg sync.WaitGroup
+	for range d.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for del := range d.deliveries {
+				d.deliver(del)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// deliver makes one attempt to POST a payload. Failed attempts are requeued after an exponential backoff rather than
+// waiting in the worker, so a failing receiver doesn't hold up deliveries to the others. Deliveries which never
+// succeed go to the dead-letter log.
+func (d *Dispatcher) deliver(del delivery) {
+	if del.body == nil {
+		var err error
+		del.payload.DeliveryID, err = randomHex(8)
+		if err != nil {
+			logging.Logger.ErrorE("failed to generate webhook delivery ID", err)
+			return
+		}
+
+		del.body, err = json.Marshal(del.payload)
+		if err != nil {
+			logging.Logger.ErrorE("failed to marshal webhook payload", err)
+			return
+		}
+		del.backoff = d.initialBackoff
+	}
+
+	del.attempts++
+	err := d.post(del.subscription, del.payload.DeliveryID, del.body)
+	if err == nil {
+		return
+	}
+
+	if del.attempts >= d.maxAttempts {
+		logging.Logger.ErrorE(fmt.Sprintf("webhook delivery %s to %s failed after %d attempts", del.payload.DeliveryID, del.subscription.URL, del.attempts), err)
+		d.deadLetters.Record(del.subscription, del.attempts, err, del.body)
+		return
+	}
+
+	logging.Logger.Warnf("Webhook delivery %s to %s failed (attempt %d of %d), retrying in %s: %v", del.payload.DeliveryID, del.subscription.URL, del.attempts, d.maxAttempts, del.backoff, err)
+	backoff := del.backoff
+	del.backoff = min(del.backoff*2, maxBackoff)
+	time.AfterFunc(backoff, func() {
+		d.requeue(del, err)
+	})
+}
+
+// requeue puts a delivery back on the queue to be retried. If the queue is full, it goes to the dead-letter log rather
+// than waiting for space.
+func (d *Dispatcher) requeue(del delivery, lastErr error) {
+	select {
+	case d.deliveries <- del:
+	default:
+		logging.Logger.ErrorE(fmt.Sprintf("webhook delivery %s to %s couldn't be retried because the delivery queue is full", del.payload.DeliveryID, del.subscription.URL), lastErr)
+		d.deadLetters.Record(del.subscription, del.attempts, lastErr, del.body)
+	}
+}
+
+func (d *Dispatcher) post(sub Subscription, deliveryID string, body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-ID", deliveryID)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+Sign(sub.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the hex HMAC-SHA256 of "{timestamp}.{body}" using the subscription's secret, which receivers compare
+// against the X-Webhook-Signature header
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,338 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"gemini-push-port/internal/testfixtures"
+	"gemini-push-port/outbound"
+	"gemini-push-port/pushport"
+	"gemini-push-port/trainstate"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testResolver map[string][]string
+
+func (r testResolver) TiplocsForCRS(crs string) []string {
+	return r[crs]
+}
+
+func TestMain(m *testing.M) {
+	testfixtures.Main(m, "webhooks-test")
+}
+
+func newTestDispatcher(t *testing.T, maxAttempts int) *Dispatcher {
+	t.Helper()
+	store, err := OpenStore(filepath.Join(t.TempDir(), "webhooks.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	return &Dispatcher{
+		store:          store,
+		engine:         trainstate.NewEngine(time.Hour),
+		resolver:       testResolver{"WOK": {"WOKING"}, "CLJ": {"CLPHMJC"}},
+		deadLetters:    &DeadLetterLog{dir: t.TempDir()},
+		client:         outbound.NewClient(true, 10*time.Second),
+		maxAttempts:    maxAttempts,
+		workers:        2,
+		initialBackoff: 10 * time.Millisecond,
+		allowPrivate:   true,
+		deliveries:     make(chan delivery, 100),
+	}
+}
+
+func subscribe(t *testing.T, d *Dispatcher, sub Subscription) Subscription {
+	t.Helper()
+	sub, err := d.store.Create(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = d.reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sub
+}
+
+// handle passes the fixture archive's schedule for 1A02 to the train state and the dispatcher, and returns it
+func handle(t *testing.T, d *Dispatcher) *pushport.Message {
+	t.Helper()
+	msg := testfixtures.Messages(t, 9)[0]
+	d.engine.HandleMessage(msg)
+	d.HandleMessage(msg)
+	return msg
+}
+
+// queued drains the delivery queue, returning the IDs of the subscriptions deliveries were queued for
+func queued(d *Dispatcher) []string {
+	var ids []string
+	for {
+		select {
+		case del := <-d.deliveries:
+			ids = append(ids, del.subscription.ID)
+		default:
+			slices.Sort(ids)
+			return ids
+		}
+	}
+}
+
+func TestHandleMessageMatchesSubscriptions(t *testing.T) {
+	d := newTestDispatcher(t, 1)
+
+	const url = "https://example.com/hook"
+	matching := []Subscription{
+		subscribe(t, d, Subscription{URL: url, RID: testfixtures.RID1A02}),
+		subscribe(t, d, Subscription{URL: url, Headcode: "1A02", Date: "2025-09-19"}),
+		subscribe(t, d, Subscription{URL: url, CRS: "WOK"}),
+	}
+	// another service, the same headcode on another day, and a station the service only passes through
+	subscribe(t, d, Subscription{URL: url, RID: testfixtures.RID1C10})
+	subscribe(t, d, Subscription{URL: url, Headcode: "1A02", Date: "2025-09-20"})
+	subscribe(t, d, Subscription{URL: url, CRS: "CLJ"})
+
+	handle(t, d)
+
+	var want []string
+	for _, sub := range matching {
+		want = append(want, sub.ID)
+	}
+	slices.Sort(want)
+	got := queued(d)
+	if !slices.Equal(got, want) {
+		t.Errorf("deliveries queued for %v, want %v", got, want)
+	}
+}
+
+func TestDeliverSignsPayload(t *testing.T) {
+	d := newTestDispatcher(t, 1)
+
+	type request struct {
+		header http.Header
+		body   []byte
+	}
+	requests := make(chan request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{header: r.Header, body: body}
+	}))
+	defer server.Close()
+
+	sub := subscribe(t, d, Subscription{URL: server.URL, RID: testfixtures.RID1A02})
+	schedule := handle(t, d)
+	d.deliver(<-d.deliveries)
+
+	req := <-requests
+	timestamp := req.header.Get("X-Webhook-Timestamp")
+	want := "sha256=" + Sign(sub.Secret, timestamp, req.body)
+	if got := req.header.Get("X-Webhook-Signature"); got != want {
+		t.Errorf("signature %q, want %q", got, want)
+	}
+	if Sign("another secret", timestamp, req.body) == Sign(sub.Secret, timestamp, req.body) {
+		t.Error("signature doesn't depend on the secret")
+	}
+
+	var payload Payload
+	err := json.Unmarshal(req.body, &payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload.SubscriptionID != sub.ID || payload.DeliveryID != req.header.Get("X-Webhook-ID") {
+		t.Errorf("payload for subscription %q, delivery %q", payload.SubscriptionID, payload.DeliveryID)
+	}
+	if !slices.Equal(payload.Types, []string{"schedule"}) || !slices.Equal(payload.RIDs, []string{testfixtures.RID1A02}) {
+		t.Errorf("payload types %v, RIDs %v", payload.Types, payload.RIDs)
+	}
+	if len(payload.Services) != 1 || payload.Services[0].TrainID != "1A02" {
+		t.Errorf("payload services %+v", payload.Services)
+	}
+	if payload.XML != schedule.Raw.Message {
+		t.Errorf("payload XML %q", payload.XML)
+	}
+}
+
+// failingServer responds with status to the first failures requests, and 204 after that
+func failingServer(t *testing.T, status int, failures int) (*httptest.Server, func() int) {
+	t.Helper()
+	var mu sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		n := requests
+		mu.Unlock()
+		if n <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func deadLetters(t *testing.T, d *Dispatcher) []deadLetter {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(d.deadLetters.dir, time.Now().UTC().Format(time.DateOnly)+".log"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var letters []deadLetter
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var letter deadLetter
+		err = json.Unmarshal([]byte(line), &letter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		letters = append(letters, letter)
+	}
+	return letters
+}
+
+func TestDeliverRetriesServerErrors(t *testing.T) {
+	d := newTestDispatcher(t, 5)
+	server, requests := failingServer(t, http.StatusInternalServerError, 2)
+	subscribe(t, d, Subscription{URL: server.URL, RID: testfixtures.RID1A02})
+	go d.Thread()
+
+	handle(t, d)
+	waitFor(t, func() bool { return requests() == 3 })
+
+	// give a wrongly scheduled retry a chance to arrive
+	time.Sleep(50 * time.Millisecond)
+	if n := requests(); n != 3 {
+		t.Errorf("%d requests, want 3", n)
+	}
+	if letters := deadLetters(t, d); len(letters) != 0 {
+		t.Errorf("dead letters %+v, want none", letters)
+	}
+}
+
+func TestDeliverGivesUpAfterLastAttempt(t *testing.T) {
+	d := newTestDispatcher(t, 3)
+	server, requests := failingServer(t, http.StatusServiceUnavailable, 100)
+	sub := subscribe(t, d, Subscription{URL: server.URL, RID: testfixtures.RID1A02})
+	go d.Thread()
+
+	handle(t, d)
+	waitFor(t, func() bool { return len(deadLetters(t, d)) > 0 })
+
+	time.Sleep(50 * time.Millisecond)
+	if n := requests(); n != 3 {
+		t.Errorf("%d requests, want 3", n)
+	}
+	letters := deadLetters(t, d)
+	if len(letters) != 1 {
+		t.Fatalf("%d dead letters, want 1", len(letters))
+	}
+	if letters[0].SubscriptionID != sub.ID || letters[0].Attempts != 3 || !strings.Contains(letters[0].Error, "503") {
+		t.Errorf("dead letter %+v", letters[0])
+	}
+}
+
+func TestDeliverRefusesPrivateAddresses(t *testing.T) {
+	d := newTestDispatcher(t, 1)
+	d.client = outbound.NewClient(false, 10*time.Second)
+	server, requests := failingServer(t, http.StatusInternalServerError, 0)
+	subscribe(t, d, Subscription{URL: server.URL, RID: testfixtures.RID1A02})
+
+	handle(t, d)
+	d.deliver(<-d.deliveries)
+
+	if n := requests(); n != 0 {
+		t.Errorf("%d requests reached a loopback server", n)
+	}
+	if letters := deadLetters(t, d); len(letters) != 1 || !strings.Contains(letters[0].Error, "refused") {
+		t.Errorf("dead letters %+v", letters)
+	}
+}
+
+func TestValidateRejectsPrivateAddresses(t *testing.T) {
+	tests := []struct {
+		url string
+		ok  bool
+	}{
+		{"https://93.184.215.14/hook", true},
+		{"https://[2606:2800:21f:cb07:6820:80da:af6b:8b2c]/hook", true},
+		{"http://127.0.0.1:8080/hook", false},
+		{"http://[::1]/hook", false},
+		{"http://10.1.2.3/hook", false},
+		{"http://172.16.0.1/hook", false},
+		{"http://192.168.1.1/hook", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+		{"http://[fe80::1]/hook", false},
+		{"http://[fd00::1]/hook", false},
+		{"http://[::ffff:127.0.0.1]/hook", false},
+		{"http://0.0.0.0/hook", false},
+		{"http://localhost/hook", false},
+	}
+	for _, test := range tests {
+		t.Run(test.url, func(t *testing.T) {
+			req := createRequest{URL: test.url, CRS: "WOK"}
+			_, err := req.validate(context.Background(), false)
+			if (err == nil) != test.ok {
+				t.Errorf("validate() error = %v, want ok %v", err, test.ok)
+			}
+
+			_, err = req.validate(context.Background(), true)
+			if err != nil {
+				t.Errorf("validate() with private addresses allowed, error = %v", err)
+			}
+		})
+	}
+}
+
+func TestDeleteWithToken(t *testing.T) {
+	d := newTestDispatcher(t, 1)
+	sub := subscribe(t, d, Subscription{URL: "https://example.com/hook", CRS: "WOK"})
+	other := subscribe(t, d, Subscription{URL: "https://example.com/hook", CRS: "WOK"})
+
+	for _, token := range []string{"", other.DeleteToken, sub.Secret} {
+		err := d.store.DeleteWithToken(sub.ID, token)
+		if err != ErrSubscriptionNotFound {
+			t.Errorf("DeleteWithToken(%q) error = %v, want ErrSubscriptionNotFound", token, err)
+		}
+	}
+
+	err := d.store.DeleteWithToken(sub.ID, sub.DeleteToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subs, err := d.store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subs) != 1 || subs[0].ID != other.ID {
+		t.Errorf("subscriptions after delete %+v", subs)
+	}
+}
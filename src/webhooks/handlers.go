@@ -0,0 +1,172 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"gemini-push-port/httpapi"
+	"gemini-push-port/logging"
+	"gemini-push-port/outbound"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+type createRequest struct {
+	URL      string `json:"url"`
+	RID      string `json:"rid"`
+	Headcode string `json:"headcode"`
+	Date     string `json:"date"`
+	CRS      string `json:"crs"`
+}
+
+// RegisterRoutes adds the endpoints for clients to subscribe if WEBHOOKS_CREATE_TOKEN is set and to delete their own
+// subscriptions, and if WEBHOOKS_ADMIN_TOKEN is set, the admin endpoints to list and delete any subscription
+func (d *Dispatcher) RegisterRoutes(server *httpapi.Server) {
+	createToken := os.Getenv("WEBHOOKS_CREATE_TOKEN")
+	if createToken == "" {
+		logging.Logger.Warnf("WEBHOOKS_CREATE_TOKEN not set, webhook subscriptions can't be created")
+	} else {
+		server.Handle("POST /webhooks", requireToken(createToken, "invalid webhook token", http.HandlerFunc(d.handleCreate)))
+	}
+	server.HandleFunc("DELETE /webhooks/{id}", d.handleDeleteOwn)
+
+	adminToken := os.Getenv("WEBHOOKS_ADMIN_TOKEN")
+	if adminToken == "" {
+		logging.Logger.Warnf("WEBHOOKS_ADMIN_TOKEN not set, webhook admin endpoints are disabled")
+		return
+	}
+	server.Handle("GET /admin/webhooks", requireToken(adminToken, "invalid admin token", http.HandlerFunc(d.handleList)))
+	server.Handle("DELETE /admin/webhooks/{id}", requireToken(adminToken, "invalid admin token", http.HandlerFunc(d.handleDelete)))
+}
+
+func bearerToken(r *http.Request) string {
+	return strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
+
+func requireToken(token string, message string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(bearerToken(r)), []byte(token)) != 1 {
+			httpapi.WriteError(w, r, http.StatusUnauthorized, message)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (d *Dispatcher) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req createRequest
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req)
+	if err != nil {
+		httpapi.WriteError(w, r, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	sub, err := req.validate(r.Context(), d.allowPrivate)
+	if err != nil {
+		httpapi.WriteError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sub, err = d.store.Create(sub)
+	if err != nil {
+		httpapi.Logger(r).ErrorE("failed to create webhook subscription", err)
+		httpapi.WriteError(w, r, http.StatusInternalServerError, "failed to create subscription")
+		return
+	}
+	err = d.reload()
+	if err != nil {
+		httpapi.Logger(r).ErrorE("failed to reload webhook subscriptions", err)
+	}
+
+	httpapi.WriteJSON(w, r, http.StatusCreated, sub)
+}
+
+func (req *createRequest) validate(ctx context.Context, allowPrivate bool) (Subscription, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return Subscription{}, errors.New("url must be an absolute http or https URL")
+	}
+	if !allowPrivate {
+		err = outbound.CheckHost(ctx, u.Hostname())
+		if err != nil {
+			return Subscription{}, err
+		}
+	}
+
+	sub := Subscription{
+		URL:      req.URL,
+		RID:      strings.TrimSpace(req.RID),
+		Headcode: strings.ToUpper(strings.TrimSpace(req.Headcode)),
+		Date:     strings.TrimSpace(req.Date),
+		CRS:      strings.ToUpper(strings.TrimSpace(req.CRS)),
+	}
+
+	targets := 0
+	for _, target := range []string{sub.RID, sub.Headcode, sub.CRS} {
+		if target != "" {
+			targets++
+		}
+	}
+	if targets != 1 {
+		return Subscription{}, errors.New("exactly one of rid, headcode (with date) or crs must be given")
+	}
+	if sub.Headcode != "" {
+		_, err = time.Parse(time.DateOnly, sub.Date)
+		if err != nil {
+			return Subscription{}, errors.New("date must be given as YYYY-MM-DD with headcode")
+		}
+	} else if sub.Date != "" {
+		return Subscription{}, errors.New("date is only used with headcode")
+	}
+
+	return sub, nil
+}
+
+func (d *Dispatcher) handleList(w http.ResponseWriter, r *http.Request) {
+	subs, err := d.store.List()
+	if err != nil {
+		httpapi.Logger(r).ErrorE("failed to list webhook subscriptions", err)
+		httpapi.WriteError(w, r, http.StatusInternalServerError, "failed to list subscriptions")
+		return
+	}
+
+	// secrets are only shown to the client when they subscribe
+	for i := range subs {
+		subs[i].Secret = ""
+	}
+	if subs == nil {
+		subs = []Subscription{}
+	}
+	httpapi.WriteJSON(w, r, http.StatusOK, subs)
+}
+
+func (d *Dispatcher) handleDelete(w http.ResponseWriter, r *http.Request) {
+	d.writeDeleted(w, r, d.store.Delete(r.PathValue("id")))
+}
+
+// handleDeleteOwn deletes a subscription given the delete token returned when it was created
+func (d *Dispatcher) handleDeleteOwn(w http.ResponseWriter, r *http.Request) {
+	d.writeDeleted(w, r, d.store.DeleteWithToken(r.PathValue("id"), bearerToken(r)))
+}
+
+func (d *Dispatcher) writeDeleted(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, ErrSubscriptionNotFound) {
+		httpapi.WriteError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		httpapi.Logger(r).ErrorE("failed to delete webhook subscription", err)
+		httpapi.WriteError(w, r, http.StatusInternalServerError, "failed to delete subscription")
+		return
+	}
+
+	err = d.reload()
+	if err != nil {
+		httpapi.Logger(r).ErrorE("failed to reload webhook subscriptions", err)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,204 @@
+package webhooks
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+var ErrSubscriptionNotFound = errors.New("subscription not found")
+
+// Subscription is a webhook registered for a RID, a headcode on a date, or a station (CRS). Exactly one of RID,
+// Headcode (with Date) or CRS is set.
+type Subscription struct {
+	ID       string `json:"id"`
+	URL      string `json:"url"`
+	RID      string `json:"rid,omitempty"`
+	Headcode string `json:"headcode,omitempty"`
+	// Date is the scheduled start date of the service with the headcode, as YYYY-MM-DD
+	Date string `json:"date,omitempty"`
+	CRS  string `json:"crs,omitempty"`
+	// Secret signs every delivery. It is only returned when the subscription is created.
+	Secret string `json:"secret,omitempty"`
+	// DeleteToken lets the client delete the subscription. Only a hash of it is stored, so it is only returned when the
+	// subscription is created.
+	DeleteToken string    `json:"deleteToken,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+const schema = `
+CREATE TABLE IF NOT EXISTS subscriptions (
+	id         TEXT PRIMARY KEY,
+	url        TEXT NOT NULL,
+	rid        TEXT NOT NULL DEFAULT '',
+	headcode   TEXT NOT NULL DEFAULT '',
+	date       TEXT NOT NULL DEFAULT '',
+	crs        TEXT NOT NULL DEFAULT '',
+	secret     TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL
+)`
+
+// addDeleteTokenHash adds the delete token column to databases created before subscriptions had one. Their
+// subscriptions can only be deleted by an admin.
+const addDeleteTokenHash = `ALTER TABLE subscriptions ADD COLUMN delete_token_hash TEXT NOT NULL DEFAULT ''`
+
+// Store persists subscriptions in a local SQLite database
+type Store struct {
+	db *sql.DB
+}
+
+// OpenStoreFromEnv opens the database at WEBHOOKS_DB_PATH, or webhooks.db in the workdir, creating it if needed
+func OpenStoreFromEnv() (*Store, error) {
+	dbPath := os.Getenv("WEBHOOKS_DB_PATH")
+	if dbPath == "" {
+		workdir := os.Getenv("PUSH_PORT_DUMP_WORKDIR")
+		if workdir == "" {
+			panic("PUSH_PORT_DUMP_WORKDIR environment variable not set")
+		}
+		dbPath = filepath.Join(workdir, "webhooks.db")
+	}
+
+	return OpenStore(dbPath)
+}
+
+func OpenStore(dbPath string) (*Store, error) {
+	err := os.MkdirAll(filepath.Dir(dbPath), 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open webhook database: %v", err)
+	}
+	// SQLite only allows one writer at a time
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(schema)
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to create webhook database: %v", err)
+	}
+
+	var hasDeleteTokenHash bool
+	err = db.QueryRow(`SELECT COUNT(*) > 0 FROM pragma_table_info('subscriptions') WHERE name = 'delete_token_hash'`).Scan(&hasDeleteTokenHash)
+	if err == nil && !hasDeleteTokenHash {
+		_, err = db.Exec(addDeleteTokenHash)
+	}
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to migrate webhook database: %v", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Create saves a new subscription, generating its ID, signing secret and delete token
+func (s *Store) Create(sub Subscription) (Subscription, error) {
+	var err error
+	sub.ID, err = randomHex(16)
+	if err != nil {
+		return Subscription{}, err
+	}
+	sub.Secret, err = randomHex(32)
+	if err != nil {
+		return Subscription{}, err
+	}
+	sub.DeleteToken, err = randomHex(32)
+	if err != nil {
+		return Subscription{}, err
+	}
+	sub.CreatedAt = time.Now().UTC().Truncate(time.Second)
+
+	_, err = s.db.Exec(
+		`INSERT INTO subscriptions (id, url, rid, headcode, date, crs, secret, delete_token_hash, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		sub.ID, sub.URL, sub.RID, sub.Headcode, sub.Date, sub.CRS, sub.Secret, hashToken(sub.DeleteToken), sub.CreatedAt,
+	)
+	if err != nil {
+		return Subscription{}, fmt.Errorf("failed to save subscription: %v", err)
+	}
+	return sub, nil
+}
+
+// List returns every subscription, including their secrets, oldest first
+func (s *Store) List() ([]Subscription, error) {
+	rows, err := s.db.Query(`SELECT id, url, rid, headcode, date, crs, secret, created_at FROM subscriptions ORDER BY created_at, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %v", err)
+	}
+	defer rows.Close()
+
+	var subs []Subscription
+	for rows.Next() {
+		var sub Subscription
+		err = rows.Scan(&sub.ID, &sub.URL, &sub.RID, &sub.Headcode, &sub.Date, &sub.CRS, &sub.Secret, &sub.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read subscription: %v", err)
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+func (s *Store) Delete(id string) error {
+	result, err := s.db.Exec(`DELETE FROM subscriptions WHERE id = ?`, id)
+	return checkDeleted(result, err)
+}
+
+// DeleteWithToken deletes a subscription if deleteToken is the one it was created with. Returns
+// ErrSubscriptionNotFound if it doesn't exist or the token is wrong, so callers can't tell which.
+func (s *Store) DeleteWithToken(id string, deleteToken string) error {
+	if deleteToken == "" {
+		return ErrSubscriptionNotFound
+	}
+	result, err := s.db.Exec(`DELETE FROM subscriptions WHERE id = ? AND delete_token_hash = ?`, id, hashToken(deleteToken))
+	return checkDeleted(result, err)
+}
+
+func checkDeleted(result sql.Result, err error) error {
+	if err != nil {
+		return fmt.Errorf("failed to delete subscription: %v", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// EnabledFromEnv returns whether webhook subscriptions are enabled by WEBHOOKS_ENABLED
+func EnabledFromEnv() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("WEBHOOKS_ENABLED"))
+	return enabled
+}